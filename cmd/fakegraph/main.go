@@ -0,0 +1,39 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+
+	"school-assistant-wh/internal/fakegraph"
+)
+
+// fakegraph serves a local stand-in for the Graph API. Point the bot at it with
+// FB_GRAPH_BASE_URL=http://localhost:9090 and drive conversations through
+// POST /_fake/deliver; replies are recorded under GET /_fake/transcripts/{psid}.
+func main() {
+	gin.SetMode(gin.ReleaseMode)
+
+	addr := ":9090"
+	if p := os.Getenv("FAKEGRAPH_PORT"); p != "" {
+		addr = ":" + p
+	}
+
+	webhookURL := os.Getenv("FAKEGRAPH_WEBHOOK_URL")
+	if webhookURL == "" {
+		webhookURL = "http://localhost:8080/webhook"
+	}
+
+	srv := fakegraph.New(fakegraph.Options{
+		WebhookURL: webhookURL,
+		AppSecret:  os.Getenv("FB_APP_SECRET"),
+		PageID:     os.Getenv("FAKEGRAPH_PAGE_ID"),
+	})
+
+	log.Printf("Fake Graph API listening on %s, delivering webhook events to %s", addr, webhookURL)
+	if err := http.ListenAndServe(addr, srv); err != nil {
+		log.Fatalf("Failed to start fake Graph API: %v", err)
+	}
+}
@@ -1,7 +1,11 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"log"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -11,10 +15,15 @@ import (
 	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
 
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/cache"
 	"school-assistant-wh/internal/config"
+	"school-assistant-wh/internal/dispatcher"
 	"school-assistant-wh/internal/handlers"
 	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/notify"
 	"school-assistant-wh/internal/services/facebook"
+	"school-assistant-wh/internal/state"
 )
 
 func main() {
@@ -34,24 +43,58 @@ func main() {
 
 	fbSvc := facebook.NewService(fbCfg)
 
-	h := handlers.NewHandler(db, fbSvc)
+	webhookCfg := config.LoadWebhookConfig()
+	disp := dispatcher.New(webhookCfg.Workers, webhookCfg.QueueSize)
 
-	if err := h.SetupMessengerProfile(); err != nil {
+	events := cache.NewEventCache(webhookCfg.DedupTTL)
+
+	stateManager, err := newStateManager(db, config.LoadStateConfig())
+	if err != nil {
+		log.Fatalf("Failed to set up state store: %v", err)
+	}
+
+	notifier, err := notify.New(config.LoadNotifyConfig())
+	if err != nil {
+		log.Fatalf("Failed to set up notifier: %v", err)
+	}
+
+	rolePolicy, err := config.LoadRolePolicyConfig()
+	if err != nil {
+		log.Fatalf("Failed to load role policy: %v", err)
+	}
+	policy, err := access.NewPolicy(rolePolicy)
+	if err != nil {
+		log.Fatalf("Invalid role policy: %v", err)
+	}
+
+	h := handlers.NewHandler(db, fbSvc, disp, events, stateManager, notifier, policy)
+
+	if updated, err := h.SetupMessengerProfile(); err != nil {
 		log.Printf("Warning: Failed to set up Messenger profile: %v", err)
+	} else if len(updated) > 0 {
+		log.Printf("Updated Messenger profile fields: %v", updated)
 	} else {
-		log.Println("Successfully set up Messenger profile")
+		log.Println("Messenger profile is up to date")
 	}
 
-	r := setupRouter(h)
+	noticeCtx, stopNotices := context.WithCancel(context.Background())
+	go h.RunLinkNotices(noticeCtx, config.LoadLinkConfig().NoticeInterval)
+
+	r := setupRouter(h, fbCfg, config.LoadAdminConfig())
 
 	port := ":8080"
 	if p := os.Getenv("PORT"); p != "" {
 		port = ":" + p
 	}
 
+	srv := &http.Server{
+		Addr:    port,
+		Handler: r,
+	}
+
 	go func() {
 		log.Printf("Server starting on port %s...\n", port)
-		if err := r.Run(port); err != nil {
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 			log.Fatalf("Failed to start server: %v", err)
 		}
 	}()
@@ -61,6 +104,19 @@ func main() {
 	signal.Notify(quit, os.Interrupt, syscall.SIGTERM)
 	<-quit
 	log.Println("Shutting down server...")
+	stopNotices()
+
+	ctx, cancel := context.WithTimeout(context.Background(), webhookCfg.ShutdownTimeout)
+	defer cancel()
+
+	// Stop taking new deliveries first, then let the workers drain what is queued
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("Error shutting down HTTP server: %v", err)
+	}
+	if err := disp.Shutdown(ctx); err != nil {
+		log.Printf("Webhook queue did not drain before timeout: %v (%d events left)", err, disp.Stats().Queued)
+	}
+	log.Println("Server stopped")
 }
 
 func initDB() (*gorm.DB, error) {
@@ -69,7 +125,22 @@ func initDB() (*gorm.DB, error) {
 	return gorm.Open(mysql.Open(dsn), &gorm.Config{})
 }
 
-func setupRouter(h *handlers.Handler) *gin.Engine {
+// newStateManager creates the conversation state store selected by STATE_STORE
+func newStateManager(db *gorm.DB, cfg config.StateConfig) (state.StateManager, error) {
+	switch cfg.Store {
+	case "memory":
+		return state.NewMemoryStateManager(), nil
+	case "mysql":
+		if err := db.AutoMigrate(&models.ConversationState{}); err != nil {
+			return nil, fmt.Errorf("failed to migrate conversation states: %w", err)
+		}
+		return state.NewMySQLStateManager(db), nil
+	default:
+		return nil, fmt.Errorf("unknown STATE_STORE %q, expected memory or mysql", cfg.Store)
+	}
+}
+
+func setupRouter(h *handlers.Handler, fbCfg config.FacebookConfig, adminCfg config.AdminConfig) *gin.Engine {
 	r := gin.Default()
 
 	r.GET("/health", func(c *gin.Context) {
@@ -78,11 +149,37 @@ func setupRouter(h *handlers.Handler) *gin.Engine {
 		})
 	})
 
+	// Data exports are authorized by the signed token in the URL
+	r.GET("/privacy/export/:token", h.DownloadExport)
+
 	webhook := r.Group("/webhook")
+	webhook.Use(handlers.VerifySignatureMiddleware(fbCfg.AppSecret))
 	{
 		webhook.GET("", h.VerifyWebhook)
 		webhook.POST("", h.HandleWebhook)
 	}
 
+	admin := r.Group("/admin")
+	admin.Use(handlers.AdminAuthMiddleware(adminCfg.APIKey))
+	{
+		admin.POST("/messenger-profile", h.ApplyMessengerProfile)
+		admin.POST("/link-tokens", h.IssueLinkToken)
+		admin.GET("/metrics/queue", h.QueueStats)
+	}
+
+	// The v1 API also accepts per-school keys, limited to their own school
+	adminV1 := r.Group("/admin/v1")
+	adminV1.Use(handlers.AdminScopeMiddleware(adminCfg))
+	{
+		adminV1.GET("/users", h.AdminFindUsers)
+		adminV1.GET("/users/:id", h.AdminGetUser)
+		adminV1.POST("/users/:id/activate", h.AdminSetUserActive(true))
+		adminV1.POST("/users/:id/deactivate", h.AdminSetUserActive(false))
+		adminV1.POST("/links", h.AdminCreateLink)
+		adminV1.DELETE("/links", h.AdminRemoveLink)
+		adminV1.POST("/links/primary", h.AdminSetPrimaryLink)
+		adminV1.GET("/schools", h.AdminListSchools)
+	}
+
 	return r
 }
@@ -0,0 +1,99 @@
+// Package access decides which main menu features a messenger user may use for
+// a linked student, based on their role towards the student and the student's
+// school.
+package access
+
+import (
+	"fmt"
+
+	"school-assistant-wh/internal/config"
+	"school-assistant-wh/internal/models"
+)
+
+// Feature is a main menu option that can be restricted by role
+type Feature string
+
+const (
+	Grades     Feature = "grades"
+	Fees       Feature = "fees"
+	Bulletin   Feature = "bulletin"
+	Attendance Feature = "attendance"
+	Support    Feature = "support"
+)
+
+// Features lists every feature, in main menu order
+var Features = []Feature{Grades, Fees, Bulletin, Attendance, Support}
+
+// featureSet holds the features a role may use
+type featureSet map[Feature]bool
+
+// Policy answers whether a link's role may use a feature. Roles without an
+// entry may use nothing.
+type Policy struct {
+	defaults map[string]featureSet
+	schools  map[string]map[string]featureSet
+}
+
+// NewPolicy builds a policy from its configuration, rejecting unknown roles and
+// features so a typo does not silently lock users out
+func NewPolicy(cfg config.RolePolicyConfig) (*Policy, error) {
+	defaults, err := roleFeatures(cfg.Default)
+	if err != nil {
+		return nil, fmt.Errorf("default role policy: %w", err)
+	}
+
+	p := &Policy{defaults: defaults, schools: make(map[string]map[string]featureSet, len(cfg.Schools))}
+	for schoolID, roles := range cfg.Schools {
+		p.schools[schoolID], err = roleFeatures(roles)
+		if err != nil {
+			return nil, fmt.Errorf("role policy for school %s: %w", schoolID, err)
+		}
+	}
+	return p, nil
+}
+
+// Allows reports whether the link's role may use the feature for the link's
+// school
+func (p *Policy) Allows(link models.UserLink, f Feature) bool {
+	role := link.LinkRole()
+	if features, ok := p.schools[link.SchoolID][role]; ok {
+		return features[f]
+	}
+	return p.defaults[role][f]
+}
+
+func roleFeatures(cfg config.RoleFeatures) (map[string]featureSet, error) {
+	roles := make(map[string]featureSet, len(cfg))
+	for role, names := range cfg {
+		if !knownRole(role) {
+			return nil, fmt.Errorf("unknown role %q", role)
+		}
+		set := make(featureSet, len(names))
+		for _, name := range names {
+			if !knownFeature(Feature(name)) {
+				return nil, fmt.Errorf("unknown feature %q for role %s", name, role)
+			}
+			set[Feature(name)] = true
+		}
+		roles[role] = set
+	}
+	return roles, nil
+}
+
+func knownRole(role string) bool {
+	for _, r := range models.LinkRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func knownFeature(f Feature) bool {
+	for _, known := range Features {
+		if known == f {
+			return true
+		}
+	}
+	return false
+}
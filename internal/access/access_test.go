@@ -0,0 +1,67 @@
+package access
+
+import (
+	"testing"
+
+	"school-assistant-wh/internal/config"
+	"school-assistant-wh/internal/models"
+)
+
+func TestPolicyAllows(t *testing.T) {
+	p, err := NewPolicy(config.RolePolicyConfig{
+		Default: config.RoleFeatures{
+			"guardian": {"grades", "fees", "support"},
+			"sponsor":  {"fees"},
+		},
+		Schools: map[string]config.RoleFeatures{
+			"strict_school": {"guardian": {"fees"}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		link    models.UserLink
+		feature Feature
+		want    bool
+	}{
+		{"guardian default", models.UserLink{SchoolID: "demo_school", Role: models.LinkRoleGuardian}, Grades, true},
+		{"guardian not listed", models.UserLink{SchoolID: "demo_school", Role: models.LinkRoleGuardian}, Attendance, false},
+		{"no role is guardian", models.UserLink{SchoolID: "demo_school"}, Support, true},
+		{"sponsor fees", models.UserLink{SchoolID: "demo_school", Role: models.LinkRoleSponsor}, Fees, true},
+		{"sponsor grades", models.UserLink{SchoolID: "demo_school", Role: models.LinkRoleSponsor}, Grades, false},
+		{"role without entry", models.UserLink{SchoolID: "demo_school", Role: models.LinkRoleStudent}, Fees, false},
+		{"school override", models.UserLink{SchoolID: "strict_school", Role: models.LinkRoleGuardian}, Grades, false},
+		{"school override keeps listed", models.UserLink{SchoolID: "strict_school", Role: models.LinkRoleGuardian}, Fees, true},
+		{"school without override for role", models.UserLink{SchoolID: "strict_school", Role: models.LinkRoleSponsor}, Fees, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Allows(tt.link, tt.feature); got != tt.want {
+				t.Fatalf("Allows(%s, %s) = %v, want %v", tt.link.LinkRole(), tt.feature, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPolicyRejectsUnknownNames(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  config.RolePolicyConfig
+	}{
+		{"unknown role", config.RolePolicyConfig{Default: config.RoleFeatures{"teacher": {"grades"}}}},
+		{"unknown feature", config.RolePolicyConfig{Default: config.RoleFeatures{"sponsor": {"tuition"}}}},
+		{"unknown feature for school", config.RolePolicyConfig{Schools: map[string]config.RoleFeatures{"demo_school": {"student": {"gradez"}}}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewPolicy(tt.cfg); err == nil {
+				t.Fatal("NewPolicy succeeded, want error")
+			}
+		})
+	}
+}
@@ -35,16 +35,19 @@ func (c *Cache) Set(key string, value interface{}, ttl time.Duration) {
 // Get retrieves an item from the cache
 func (c *Cache) Get(key string) (interface{}, bool) {
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
 	item, exists := c.items[key]
+	c.mu.RUnlock()
 	if !exists {
 		return nil, false
 	}
 
 	// Check if item has expired
 	if time.Now().After(item.expiresAt) {
-		delete(c.items, key)
+		c.mu.Lock()
+		if current, ok := c.items[key]; ok && time.Now().After(current.expiresAt) {
+			delete(c.items, key)
+		}
+		c.mu.Unlock()
 		return nil, false
 	}
 
@@ -64,3 +67,34 @@ func (c *Cache) Clear() {
 	defer c.mu.Unlock()
 	c.items = make(map[string]item)
 }
+
+// SetIfAbsent adds an item unless an unexpired item already exists under key.
+// It reports whether the item was added.
+func (c *Cache) SetIfAbsent(key string, value interface{}, ttl time.Duration) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	if existing, exists := c.items[key]; exists && now.Before(existing.expiresAt) {
+		return false
+	}
+
+	c.items[key] = item{
+		value:     value,
+		expiresAt: now.Add(ttl),
+	}
+	return true
+}
+
+// DeleteExpired removes all expired items from the cache
+func (c *Cache) DeleteExpired() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	for key, item := range c.items {
+		if now.After(item.expiresAt) {
+			delete(c.items, key)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package cache
+
+import "time"
+
+// EventStore remembers which webhook events have already been accepted so that
+// redeliveries can be skipped. EventCache keeps them in memory, which is enough
+// for a single replica; a shared implementation can be plugged in when running
+// several replicas.
+type EventStore interface {
+	// MarkSeen records key and reports whether it had already been recorded
+	MarkSeen(key string) (bool, error)
+	// Forget removes key so a redelivery of the event is processed again
+	Forget(key string) error
+}
+
+type EventCache struct {
+	cache *Cache
+	ttl   time.Duration
+}
+
+// NewEventCache creates an in-memory event store that remembers keys for ttl.
+// A ttl of 0 or less turns deduplication off: no key is ever reported as seen.
+func NewEventCache(ttl time.Duration) *EventCache {
+	ec := &EventCache{
+		cache: New(),
+		ttl:   ttl,
+	}
+	if ttl <= 0 {
+		return ec
+	}
+
+	go func() {
+		ticker := time.NewTicker(ttl)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			ec.cache.DeleteExpired()
+		}
+	}()
+
+	return ec
+}
+
+// MarkSeen records key and reports whether it was already seen within the TTL
+func (ec *EventCache) MarkSeen(key string) (bool, error) {
+	if ec.ttl <= 0 {
+		return false, nil
+	}
+	return !ec.cache.SetIfAbsent(key, struct{}{}, ec.ttl), nil
+}
+
+// Forget removes key from the store
+func (ec *EventCache) Forget(key string) error {
+	ec.cache.Delete(key)
+	return nil
+}
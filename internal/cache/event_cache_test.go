@@ -0,0 +1,50 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEventCacheMarkSeen(t *testing.T) {
+	ec := NewEventCache(time.Hour)
+
+	if seen, _ := ec.MarkSeen("mid:m_1"); seen {
+		t.Fatal("a new event was reported as seen")
+	}
+	if seen, _ := ec.MarkSeen("mid:m_1"); !seen {
+		t.Fatal("a redelivered event was not reported as seen")
+	}
+	if seen, _ := ec.MarkSeen("postback:u1:10"); seen {
+		t.Fatal("another event was reported as seen")
+	}
+
+	if err := ec.Forget("mid:m_1"); err != nil {
+		t.Fatal(err)
+	}
+	if seen, _ := ec.MarkSeen("mid:m_1"); seen {
+		t.Fatal("a forgotten event was reported as seen")
+	}
+}
+
+func TestEventCacheExpires(t *testing.T) {
+	ec := NewEventCache(20 * time.Millisecond)
+
+	ec.MarkSeen("mid:m_1")
+	time.Sleep(40 * time.Millisecond)
+	if seen, _ := ec.MarkSeen("mid:m_1"); seen {
+		t.Fatal("an event was still reported as seen after the TTL")
+	}
+}
+
+func TestEventCacheWithoutTTL(t *testing.T) {
+	for _, ttl := range []time.Duration{0, -time.Second} {
+		ec := NewEventCache(ttl)
+
+		ec.MarkSeen("mid:m_1")
+		if seen, _ := ec.MarkSeen("mid:m_1"); seen {
+			t.Fatalf("with a TTL of %s a redelivered event was reported as seen, want deduplication off", ttl)
+		}
+	}
+	// Let a sweeper started with a non-positive interval panic before the test ends
+	time.Sleep(10 * time.Millisecond)
+}
@@ -1,6 +1,11 @@
 package config
 
-import "os"
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
 
 type DBConfig struct {
 	Host     string
@@ -13,6 +18,84 @@ type DBConfig struct {
 type FacebookConfig struct {
 	VerifyToken     string
 	PageAccessToken string
+	AppSecret       string
+	GraphBaseURL    string
+	GraphAPIVersion string
+	HTTPTimeout     time.Duration
+	MaxRetries      int
+
+	// SendInterval is the minimum gap between two messages to the same recipient
+	SendInterval time.Duration
+	// SendRatePerSecond caps outbound messages across all recipients
+	SendRatePerSecond int
+}
+
+// WebhookConfig controls the asynchronous webhook processing pipeline
+type WebhookConfig struct {
+	Workers         int
+	QueueSize       int
+	ShutdownTimeout time.Duration
+	// DedupTTL is how long redelivered events are recognized; 0 turns
+	// deduplication off
+	DedupTTL time.Duration
+}
+
+// StateConfig selects where conversation states are kept
+type StateConfig struct {
+	// Store is "memory" or "mysql"
+	Store string
+	TTL   time.Duration
+}
+
+// AdminConfig protects the operator endpoints
+type AdminConfig struct {
+	// APIKey grants access to every school
+	APIKey string
+	// SchoolKeys maps school IDs to keys that only grant access to that school
+	// in the /admin/v1 API
+	SchoolKeys map[string]string
+}
+
+// LinkConfig controls how users link themselves to students, either through the
+// signed tokens schools put in m.me links or by verifying the student's details
+type LinkConfig struct {
+	TokenSecret string
+	// TokenTTL is how long an issued token can be redeemed
+	TokenTTL time.Duration
+
+	// MaxFailures failed verifications within LockoutWindow lock a user out of
+	// self-service linking until the oldest of them is outside the window
+	MaxFailures   int
+	LockoutWindow time.Duration
+	// MaxCodes caps the one-time codes sent to a user within LockoutWindow
+	MaxCodes int
+	CodeTTL  time.Duration
+
+	// NoticeInterval is how often users are told about students a school admin
+	// linked to their account. Links older than NoticeMaxAge are not announced.
+	NoticeInterval time.Duration
+	NoticeMaxAge   time.Duration
+
+	// SaIDTTL is how long an SA-ID shown for linking stays valid before it is
+	// replaced; 0 keeps SA-IDs until the user regenerates them
+	SaIDTTL time.Duration
+}
+
+// PrivacyConfig controls the data exports users download from My Data
+type PrivacyConfig struct {
+	// ExportSecret signs export download URLs; exports are off without it
+	ExportSecret string
+	// ExportTTL is how long an export download URL works
+	ExportTTL time.Duration
+	// PublicBaseURL is where this server is reachable from a browser, such as
+	// https://bot.example.com
+	PublicBaseURL string
+}
+
+// NotifyConfig selects how SMS and email notices are delivered
+type NotifyConfig struct {
+	// Driver is "log", which writes notices to the log instead of sending them
+	Driver string
 }
 
 func LoadDBConfig() DBConfig {
@@ -29,6 +112,81 @@ func LoadFacebookConfig() FacebookConfig {
 	return FacebookConfig{
 		VerifyToken:     getEnv("FB_VERIFY_TOKEN", ""),
 		PageAccessToken: getEnv("PAGE_ACCESS_TOKEN", ""),
+		AppSecret:       getEnv("FB_APP_SECRET", ""),
+		GraphBaseURL:    getEnv("FB_GRAPH_BASE_URL", "https://graph.facebook.com"),
+		GraphAPIVersion: getEnv("FB_GRAPH_API_VERSION", "v18.0"),
+		HTTPTimeout:     getEnvDuration("FB_HTTP_TIMEOUT", 10*time.Second),
+		MaxRetries:      getEnvInt("FB_MAX_RETRIES", 3),
+
+		SendInterval:      getEnvDuration("FB_SEND_INTERVAL", 200*time.Millisecond),
+		SendRatePerSecond: getEnvInt("FB_SEND_RATE_PER_SECOND", 50),
+	}
+}
+
+func LoadWebhookConfig() WebhookConfig {
+	return WebhookConfig{
+		Workers:         getEnvInt("WEBHOOK_WORKERS", 8),
+		QueueSize:       getEnvInt("WEBHOOK_QUEUE_SIZE", 1024),
+		ShutdownTimeout: getEnvDuration("WEBHOOK_SHUTDOWN_TIMEOUT", 30*time.Second),
+		DedupTTL:        getEnvDuration("WEBHOOK_DEDUP_TTL", 24*time.Hour),
+	}
+}
+
+func LoadStateConfig() StateConfig {
+	return StateConfig{
+		Store: getEnv("STATE_STORE", "memory"),
+		TTL:   getEnvDuration("STATE_TTL", 24*time.Hour),
+	}
+}
+
+func LoadAdminConfig() AdminConfig {
+	return AdminConfig{
+		APIKey:     getEnv("ADMIN_API_KEY", ""),
+		SchoolKeys: parseSchoolKeys(getEnv("ADMIN_SCHOOL_KEYS", "")),
+	}
+}
+
+// parseSchoolKeys reads comma separated school_id=key pairs, skipping pairs
+// without a school or key
+func parseSchoolKeys(value string) map[string]string {
+	keys := make(map[string]string)
+	for _, pair := range strings.Split(value, ",") {
+		schoolID, key, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if ok && schoolID != "" && key != "" {
+			keys[schoolID] = key
+		}
+	}
+	return keys
+}
+
+func LoadLinkConfig() LinkConfig {
+	return LinkConfig{
+		TokenSecret: getEnv("LINK_TOKEN_SECRET", ""),
+		TokenTTL:    getEnvDuration("LINK_TOKEN_TTL", 7*24*time.Hour),
+
+		MaxFailures:   getEnvInt("LINK_MAX_FAILURES", 5),
+		LockoutWindow: getEnvDuration("LINK_LOCKOUT_WINDOW", 24*time.Hour),
+		MaxCodes:      getEnvInt("LINK_MAX_CODES", 3),
+		CodeTTL:       getEnvDuration("LINK_CODE_TTL", 10*time.Minute),
+
+		NoticeInterval: getEnvDuration("LINK_NOTICE_INTERVAL", time.Minute),
+		NoticeMaxAge:   getEnvDuration("LINK_NOTICE_MAX_AGE", 7*24*time.Hour),
+
+		SaIDTTL: getEnvDuration("SA_ID_TTL", 0),
+	}
+}
+
+func LoadPrivacyConfig() PrivacyConfig {
+	return PrivacyConfig{
+		ExportSecret:  getEnv("PRIVACY_EXPORT_SECRET", ""),
+		ExportTTL:     getEnvDuration("PRIVACY_EXPORT_TTL", 24*time.Hour),
+		PublicBaseURL: strings.TrimRight(getEnv("PUBLIC_BASE_URL", ""), "/"),
+	}
+}
+
+func LoadNotifyConfig() NotifyConfig {
+	return NotifyConfig{
+		Driver: getEnv("NOTIFIER", "log"),
 	}
 }
 
@@ -39,3 +197,19 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
@@ -0,0 +1,107 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"school-assistant-wh/internal/i18n"
+)
+
+// MessengerProfileConfig describes the page's Messenger profile: the Get Started
+// button, greetings, persistent menu and ice breakers. Payloads must be ones the
+// webhook handler understands.
+type MessengerProfileConfig struct {
+	GetStartedPayload     string             `json:"get_started_payload"`
+	Greetings             []GreetingConfig   `json:"greetings"`
+	PersistentMenu        []MenuItemConfig   `json:"persistent_menu"`
+	ComposerInputDisabled bool               `json:"composer_input_disabled"`
+	IceBreakers           []IceBreakerConfig `json:"ice_breakers"`
+}
+
+// GreetingConfig is the greeting shown for one locale; "default" is required.
+// Locales a profile has no greeting for, "default" included, get the message
+// catalog's.
+type GreetingConfig struct {
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+// MenuItemConfig is one persistent menu entry. Entries with a URL open it;
+// all others send their payload as a postback.
+type MenuItemConfig struct {
+	Title   string `json:"title"`
+	Payload string `json:"payload,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// IceBreakerConfig is a question offered to users opening the conversation
+type IceBreakerConfig struct {
+	Question string `json:"question"`
+	Payload  string `json:"payload"`
+}
+
+// DefaultMessengerProfile is used when no profile definition file exists
+var DefaultMessengerProfile = MessengerProfileConfig{
+	GetStartedPayload: "GET_STARTED",
+	Greetings:         withCatalogGreetings(nil),
+	PersistentMenu: []MenuItemConfig{
+		{Title: "Main Menu", Payload: "MAIN_MENU"},
+		{Title: "Grades", Payload: "VIEW_GRADES"},
+		{Title: "Fees", Payload: "VIEW_FEES"},
+		{Title: "Bulletin", Payload: "VIEW_BULLETIN"},
+		{Title: "Attendance", Payload: "VIEW_ATTENDANCE"},
+		{Title: "Support", Payload: "SUPPORT"},
+	},
+	IceBreakers: []IceBreakerConfig{
+		{Question: "How do I get started?", Payload: "GET_STARTED"},
+		{Question: "Show me my grades", Payload: "VIEW_GRADES"},
+		{Question: "What are my school fees?", Payload: "VIEW_FEES"},
+		{Question: "I need help from a person", Payload: "SUPPORT"},
+	},
+}
+
+// withCatalogGreetings adds the catalog's greeting for every supported language
+// the greetings do not cover. Without a "default" greeting, the default
+// language's is used.
+func withCatalogGreetings(greetings []GreetingConfig) []GreetingConfig {
+	configured := make(map[string]bool, len(greetings))
+	for _, g := range greetings {
+		configured[g.Locale] = true
+	}
+
+	merged := append([]GreetingConfig(nil), greetings...)
+	if !configured["default"] {
+		merged = append(merged, GreetingConfig{Locale: "default", Text: i18n.T(i18n.Default, "greeting")})
+	}
+	for _, lang := range i18n.Supported {
+		if !configured[lang.MessengerLocale()] {
+			merged = append(merged, GreetingConfig{Locale: lang.MessengerLocale(), Text: i18n.T(lang, "greeting")})
+		}
+	}
+	return merged
+}
+
+// LoadMessengerProfileConfig reads the profile definition from the JSON file named
+// by MESSENGER_PROFILE_FILE, falling back to DefaultMessengerProfile when the
+// file does not exist
+func LoadMessengerProfileConfig() (MessengerProfileConfig, error) {
+	path := getEnv("MESSENGER_PROFILE_FILE", "config/messenger_profile.json")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultMessengerProfile, nil
+	}
+	if err != nil {
+		return MessengerProfileConfig{}, fmt.Errorf("error reading messenger profile %s: %w", path, err)
+	}
+
+	var cfg MessengerProfileConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return MessengerProfileConfig{}, fmt.Errorf("error parsing messenger profile %s: %w", path, err)
+	}
+	cfg.Greetings = withCatalogGreetings(cfg.Greetings)
+	return cfg, nil
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"school-assistant-wh/internal/i18n"
+)
+
+func TestLoadMessengerProfileConfigAddsCatalogGreetings(t *testing.T) {
+	tests := []struct {
+		name string
+		file string
+		want map[string]string
+	}{
+		{
+			name: "no greetings",
+			file: `{"get_started_payload":"GET_STARTED"}`,
+			want: map[string]string{
+				"default":                       i18n.T(i18n.Default, "greeting"),
+				i18n.English.MessengerLocale():  i18n.T(i18n.English, "greeting"),
+				i18n.Filipino.MessengerLocale(): i18n.T(i18n.Filipino, "greeting"),
+				i18n.Cebuano.MessengerLocale():  i18n.T(i18n.Cebuano, "greeting"),
+			},
+		},
+		{
+			name: "configured greetings are kept",
+			file: `{"greetings":[{"locale":"default","text":"Hi!"},{"locale":"` + i18n.Filipino.MessengerLocale() + `","text":"Kumusta!"}]}`,
+			want: map[string]string{
+				"default":                       "Hi!",
+				i18n.English.MessengerLocale():  i18n.T(i18n.English, "greeting"),
+				i18n.Filipino.MessengerLocale(): "Kumusta!",
+				i18n.Cebuano.MessengerLocale():  i18n.T(i18n.Cebuano, "greeting"),
+			},
+		},
+		{
+			name: "no default greeting",
+			file: `{"greetings":[{"locale":"` + i18n.English.MessengerLocale() + `","text":"Hello!"},{"locale":"` + i18n.Filipino.MessengerLocale() + `","text":"Kumusta!"}]}`,
+			want: map[string]string{
+				"default":                       i18n.T(i18n.Default, "greeting"),
+				i18n.English.MessengerLocale():  "Hello!",
+				i18n.Filipino.MessengerLocale(): "Kumusta!",
+				i18n.Cebuano.MessengerLocale():  i18n.T(i18n.Cebuano, "greeting"),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "messenger_profile.json")
+			if err := os.WriteFile(path, []byte(tt.file), 0o600); err != nil {
+				t.Fatal(err)
+			}
+			t.Setenv("MESSENGER_PROFILE_FILE", path)
+
+			cfg, err := LoadMessengerProfileConfig()
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := make(map[string]string)
+			for _, g := range cfg.Greetings {
+				if _, dup := got[g.Locale]; dup {
+					t.Fatalf("greeting for %s appears twice", g.Locale)
+				}
+				got[g.Locale] = g.Text
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("greetings = %v, want %v", got, tt.want)
+			}
+			for locale, text := range tt.want {
+				if got[locale] != text {
+					t.Errorf("greeting for %s = %q, want %q", locale, got[locale], text)
+				}
+			}
+		})
+	}
+}
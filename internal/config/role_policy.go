@@ -0,0 +1,61 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+)
+
+// RoleFeatures maps a link role ("student", "guardian" or "sponsor") to the main
+// menu features it may use: "grades", "fees", "bulletin", "attendance" and
+// "support"
+type RoleFeatures map[string][]string
+
+// RolePolicyConfig decides which main menu features each link role may use.
+// Schools holds overrides by SchoolID; a school's entry for a role replaces the
+// default entry for that role.
+type RolePolicyConfig struct {
+	Default RoleFeatures            `json:"default"`
+	Schools map[string]RoleFeatures `json:"schools"`
+}
+
+// DefaultRolePolicy is used when no policy file exists. Students and guardians
+// see everything; sponsors only see fees.
+var DefaultRolePolicy = RolePolicyConfig{
+	Default: RoleFeatures{
+		"student":  {"grades", "fees", "bulletin", "attendance", "support"},
+		"guardian": {"grades", "fees", "bulletin", "attendance", "support"},
+		"sponsor":  {"fees"},
+	},
+}
+
+// LoadRolePolicyConfig reads the role policy from the JSON file named by
+// ROLE_POLICY_FILE, falling back to DefaultRolePolicy when the file does not
+// exist. Roles missing from the file's default entry keep DefaultRolePolicy's.
+func LoadRolePolicyConfig() (RolePolicyConfig, error) {
+	path := getEnv("ROLE_POLICY_FILE", "config/role_policy.json")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return DefaultRolePolicy, nil
+	}
+	if err != nil {
+		return RolePolicyConfig{}, fmt.Errorf("error reading role policy %s: %w", path, err)
+	}
+
+	var cfg RolePolicyConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return RolePolicyConfig{}, fmt.Errorf("error parsing role policy %s: %w", path, err)
+	}
+	if cfg.Default == nil {
+		cfg.Default = RoleFeatures{}
+	}
+	for role, features := range DefaultRolePolicy.Default {
+		if _, ok := cfg.Default[role]; !ok {
+			cfg.Default[role] = features
+		}
+	}
+	return cfg, nil
+}
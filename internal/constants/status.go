@@ -0,0 +1,10 @@
+package constants
+
+// User-facing copy lives in the i18n message catalog; these are the user
+// statuses used to pick quick replies
+const (
+	UserStatusUnregistered  = "UNREGISTERED"
+	UserStatusRegistered    = "REGISTERED"
+	UserStatusLinkedPrimary = "LINKED_PRIMARY"
+	UserStatusDeactivated   = "DEACTIVATED"
+)
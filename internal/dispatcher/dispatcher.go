@@ -0,0 +1,174 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"hash/fnv"
+	"log"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// ErrQueueFull is returned by Submit when the target worker's queue has no room left
+	ErrQueueFull = errors.New("dispatcher queue is full")
+	// ErrClosed is returned by Submit once Shutdown has been called
+	ErrClosed = errors.New("dispatcher is shut down")
+)
+
+// Stats is a point-in-time snapshot of the dispatcher's queue metrics
+type Stats struct {
+	Workers   int    `json:"workers"`
+	Capacity  int    `json:"capacity"`
+	Queued    int    `json:"queued"`
+	InFlight  int64  `json:"in_flight"`
+	Accepted  uint64 `json:"accepted"`
+	Rejected  uint64 `json:"rejected"`
+	Processed uint64 `json:"processed"`
+	Panics    uint64 `json:"panics"`
+}
+
+type job struct {
+	key string
+	run func()
+}
+
+// Dispatcher runs submitted jobs on a fixed pool of workers. Jobs that share a
+// key always land on the same worker, so they run one at a time and in the order
+// they were submitted, while jobs with different keys run in parallel.
+type Dispatcher struct {
+	queues   []chan job
+	capacity int
+	wg       sync.WaitGroup
+
+	mu     sync.RWMutex
+	closed bool
+
+	inFlight  atomic.Int64
+	accepted  atomic.Uint64
+	rejected  atomic.Uint64
+	processed atomic.Uint64
+	panics    atomic.Uint64
+}
+
+// New starts a dispatcher with the given number of workers sharing queueSize
+// slots of buffered work between them
+func New(workers, queueSize int) *Dispatcher {
+	if workers < 1 {
+		workers = 1
+	}
+	perWorker := (queueSize + workers - 1) / workers
+	if perWorker < 1 {
+		perWorker = 1
+	}
+
+	d := &Dispatcher{
+		queues:   make([]chan job, workers),
+		capacity: perWorker * workers,
+	}
+
+	for i := range d.queues {
+		d.queues[i] = make(chan job, perWorker)
+		d.wg.Add(1)
+		go d.work(d.queues[i])
+	}
+
+	return d
+}
+
+// Submit queues run behind any earlier job with the same key. It never blocks;
+// when the worker's queue is full it returns ErrQueueFull so the caller can
+// apply backpressure upstream.
+func (d *Dispatcher) Submit(key string, run func()) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if d.closed {
+		d.rejected.Add(1)
+		return ErrClosed
+	}
+
+	select {
+	case d.queues[d.shard(key)] <- job{key: key, run: run}:
+		d.accepted.Add(1)
+		return nil
+	default:
+		d.rejected.Add(1)
+		return ErrQueueFull
+	}
+}
+
+// Shutdown stops accepting new jobs and waits for the queued ones to finish,
+// or for ctx to be done, whichever comes first
+func (d *Dispatcher) Shutdown(ctx context.Context) error {
+	d.mu.Lock()
+	if !d.closed {
+		d.closed = true
+		for _, q := range d.queues {
+			close(q)
+		}
+	}
+	d.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		d.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stats returns the current queue metrics
+func (d *Dispatcher) Stats() Stats {
+	queued := 0
+	for _, q := range d.queues {
+		queued += len(q)
+	}
+
+	return Stats{
+		Workers:   len(d.queues),
+		Capacity:  d.capacity,
+		Queued:    queued,
+		InFlight:  d.inFlight.Load(),
+		Accepted:  d.accepted.Load(),
+		Rejected:  d.rejected.Load(),
+		Processed: d.processed.Load(),
+		Panics:    d.panics.Load(),
+	}
+}
+
+func (d *Dispatcher) work(queue <-chan job) {
+	defer d.wg.Done()
+	for j := range queue {
+		d.execute(j)
+	}
+}
+
+// execute runs a single job, recovering from panics so one bad event cannot
+// take the worker (and every user hashed to it) down
+func (d *Dispatcher) execute(j job) {
+	d.inFlight.Add(1)
+	defer func() {
+		if r := recover(); r != nil {
+			d.panics.Add(1)
+			log.Printf("Dispatcher job for %s panicked: %v\n%s", j.key, r, debug.Stack())
+		}
+		d.inFlight.Add(-1)
+		d.processed.Add(1)
+	}()
+
+	j.run()
+}
+
+func (d *Dispatcher) shard(key string) int {
+	h := fnv.New32a()
+	h.Write([]byte(key))
+	return int(h.Sum32() % uint32(len(d.queues)))
+}
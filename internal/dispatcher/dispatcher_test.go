@@ -0,0 +1,80 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSameKeyRunsInOrder(t *testing.T) {
+	d := New(4, 1000)
+
+	var mu sync.Mutex
+	got := make(map[string][]int)
+	for i := 0; i < 100; i++ {
+		for _, psid := range []string{"psid-1", "psid-2", "psid-3"} {
+			if err := d.Submit(psid, func() {
+				mu.Lock()
+				got[psid] = append(got[psid], i)
+				mu.Unlock()
+			}); err != nil {
+				t.Fatalf("Submit: %v", err)
+			}
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	for psid, order := range got {
+		if len(order) != 100 {
+			t.Fatalf("%s ran %d jobs, want 100", psid, len(order))
+		}
+		for i, n := range order {
+			if n != i {
+				t.Fatalf("%s ran job %d at position %d", psid, n, i)
+			}
+		}
+	}
+}
+
+func TestFullQueueRejects(t *testing.T) {
+	d := New(1, 1)
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	if err := d.Submit("psid-1", func() {
+		close(started)
+		<-release
+	}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	<-started
+
+	// The worker is busy, so this one fills its only queue slot
+	if err := d.Submit("psid-1", func() {}); err != nil {
+		t.Fatalf("Submit: %v", err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := d.Submit(fmt.Sprintf("psid-%d", i), func() {}); !errors.Is(err, ErrQueueFull) {
+			t.Fatalf("err = %v, want %v", err, ErrQueueFull)
+		}
+	}
+	if stats := d.Stats(); stats.Rejected != 3 || stats.Accepted != 2 {
+		t.Fatalf("stats = %+v, want 2 accepted and 3 rejected", stats)
+	}
+
+	close(release)
+	if err := d.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+	if err := d.Submit("psid-1", func() {}); !errors.Is(err, ErrClosed) {
+		t.Fatalf("after shutdown: err = %v, want %v", err, ErrClosed)
+	}
+}
@@ -0,0 +1,86 @@
+package fakegraph
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Inbound is an event delivered to the bot as if the user had sent it. Set one
+// of Text, Postback or Ref; QuickReply adds a quick reply payload to Text.
+type Inbound struct {
+	PSID       string `json:"psid"`
+	Text       string `json:"text,omitempty"`
+	QuickReply string `json:"quick_reply,omitempty"`
+	Postback   string `json:"postback,omitempty"`
+	Ref        string `json:"ref,omitempty"`
+}
+
+// Deliver posts a signed webhook event for in to the configured webhook URL
+func (s *Server) Deliver(in Inbound) error {
+	if in.PSID == "" {
+		return fmt.Errorf("psid is required")
+	}
+
+	now := time.Now()
+	event := map[string]any{
+		"sender":    map[string]string{"id": in.PSID},
+		"recipient": map[string]string{"id": s.opts.PageID},
+		"timestamp": now.UnixMilli(),
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	mid := fmt.Sprintf("m_inbound_%d", s.nextID)
+	s.mu.Unlock()
+
+	switch {
+	case in.Postback != "":
+		postback := map[string]any{"mid": mid, "title": in.Postback, "payload": in.Postback}
+		if in.Ref != "" {
+			postback["referral"] = map[string]string{"ref": in.Ref, "source": "SHORTLINK", "type": "OPEN_THREAD"}
+		}
+		event["postback"] = postback
+	case in.Text != "":
+		message := map[string]any{"mid": mid, "text": in.Text}
+		if in.QuickReply != "" {
+			message["quick_reply"] = map[string]string{"payload": in.QuickReply}
+		}
+		event["message"] = message
+	case in.Ref != "":
+		event["referral"] = map[string]string{"ref": in.Ref, "source": "SHORTLINK", "type": "OPEN_THREAD"}
+	default:
+		return fmt.Errorf("one of text, postback or ref is required")
+	}
+
+	body, err := json.Marshal(map[string]any{
+		"object": "page",
+		"entry": []map[string]any{{
+			"id":        s.opts.PageID,
+			"time":      now.UnixMilli(),
+			"messaging": []map[string]any{event},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	return s.post(body)
+}
+
+func (s *Server) handleDeliver(c *gin.Context) {
+	var in Inbound
+	if err := c.ShouldBindJSON(&in); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.Deliver(in); err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "delivered"})
+}
@@ -0,0 +1,134 @@
+package fakegraph
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Fault is an error the fake returns instead of handling a request
+type Fault struct {
+	// Path limits the fault to one endpoint, e.g. "/me/messages"; empty matches all
+	Path string `json:"path,omitempty"`
+	// Recipient limits the fault to one PSID; empty matches all
+	Recipient string `json:"recipient,omitempty"`
+	// Times is how many matching requests fail; 0 fails them until faults are cleared
+	Times int `json:"times,omitempty"`
+
+	Status     int    `json:"status"`
+	Code       int    `json:"code"`
+	Subcode    int    `json:"error_subcode,omitempty"`
+	Message    string `json:"message,omitempty"`
+	RetryAfter string `json:"retry_after,omitempty"`
+}
+
+// Common Graph API failures
+var faultKinds = map[string]Fault{
+	"rate_limit":        {Status: http.StatusTooManyRequests, Code: 613, Message: "Calls to this api have exceeded the rate limit."},
+	"server_error":      {Status: http.StatusInternalServerError, Code: 2, Message: "An unexpected error has occurred. Please retry your request later."},
+	"user_unavailable":  {Status: http.StatusBadRequest, Code: 551, Subcode: 1545041, Message: "This person isn't available right now."},
+	"outside_window":    {Status: http.StatusBadRequest, Code: 10, Subcode: 2018278, Message: "This message is sent outside of allowed window."},
+	"invalid_recipient": {Status: http.StatusBadRequest, Code: 100, Subcode: 2018001, Message: "No matching user found"},
+}
+
+// FaultKind returns one of the predefined faults: rate_limit, server_error,
+// user_unavailable, outside_window or invalid_recipient
+func FaultKind(kind string) (Fault, bool) {
+	f, ok := faultKinds[kind]
+	return f, ok
+}
+
+// FaultBadRequest is the error returned for malformed requests
+func FaultBadRequest(message string) *Fault {
+	return &Fault{Status: http.StatusBadRequest, Code: 100, Message: message}
+}
+
+// InjectFault makes matching requests fail until the fault is used up or cleared
+func (s *Server) InjectFault(f Fault) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, &f)
+}
+
+// ClearFaults removes all injected faults
+func (s *Server) ClearFaults() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = nil
+}
+
+// takeFault returns the first fault matching the request, using one of its times
+func (s *Server) takeFault(fullPath, recipient string) *Fault {
+	path := strings.TrimPrefix(fullPath, "/:version")
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i, f := range s.faults {
+		if f.Path != "" && f.Path != path {
+			continue
+		}
+		if f.Recipient != "" && f.Recipient != recipient {
+			continue
+		}
+
+		if f.Times > 0 {
+			f.Times--
+			if f.Times == 0 {
+				s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			}
+		}
+		return f
+	}
+	return nil
+}
+
+func writeError(c *gin.Context, f *Fault) {
+	if f.RetryAfter != "" {
+		c.Header("Retry-After", f.RetryAfter)
+	}
+	c.JSON(f.Status, gin.H{
+		"error": gin.H{
+			"message":       f.Message,
+			"type":          "OAuthException",
+			"code":          f.Code,
+			"error_subcode": f.Subcode,
+			"fbtrace_id":    "FAKE_TRACE",
+		},
+	})
+}
+
+func (s *Server) handleAddFault(c *gin.Context) {
+	var req struct {
+		Fault
+		Kind string `json:"kind,omitempty"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	f := req.Fault
+	if req.Kind != "" {
+		preset, ok := FaultKind(req.Kind)
+		if !ok {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "unknown fault kind " + req.Kind})
+			return
+		}
+		preset.Path, preset.Recipient, preset.Times, preset.RetryAfter = f.Path, f.Recipient, f.Times, f.RetryAfter
+		f = preset
+	}
+	if f.Status == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status or kind is required"})
+		return
+	}
+
+	s.InjectFault(f)
+	c.JSON(http.StatusOK, f)
+}
+
+func (s *Server) handleClearFaults(c *gin.Context) {
+	s.ClearFaults()
+	c.Status(http.StatusNoContent)
+}
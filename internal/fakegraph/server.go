@@ -0,0 +1,362 @@
+// Package fakegraph is a local stand-in for the parts of the Graph API used by
+// facebook.Service. It records every outbound message per recipient, can inject
+// Graph errors, and can deliver signed webhook events to the bot, so the menu
+// flows can be exercised without a real page token. Run it with cmd/fakegraph or
+// embed it in tests with httptest.NewServer(fakegraph.New(opts)).
+package fakegraph
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Options configures the fake server
+type Options struct {
+	// WebhookURL is where Deliver* posts inbound events, e.g. http://localhost:8080/webhook
+	WebhookURL string
+	// AppSecret signs delivered events the same way Facebook does
+	AppSecret string
+	// PageID is used as the recipient of delivered events
+	PageID string
+}
+
+// Record is one request the bot made to the Send API
+type Record struct {
+	MessageID     string          `json:"message_id,omitempty"`
+	Recipient     string          `json:"recipient"`
+	Time          time.Time       `json:"time"`
+	MessagingType string          `json:"messaging_type,omitempty"`
+	Tag           string          `json:"tag,omitempty"`
+	SenderAction  string          `json:"sender_action,omitempty"`
+	Message       json.RawMessage `json:"message,omitempty"`
+}
+
+// Profile is a user profile returned for /{psid} lookups
+type Profile struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"`
+	FirstName string `json:"first_name"`
+	LastName  string `json:"last_name"`
+	Email     string `json:"email,omitempty"`
+	Locale    string `json:"locale,omitempty"`
+}
+
+// Server is the fake Graph API. It implements http.Handler.
+type Server struct {
+	opts    Options
+	handler http.Handler
+	client  *http.Client
+
+	mu          sync.Mutex
+	transcripts map[string][]Record
+	profile     map[string]json.RawMessage
+	users       map[string]Profile
+	faults      []*Fault
+	nextID      int
+}
+
+// New creates a fake Graph API server
+func New(opts Options) *Server {
+	if opts.PageID == "" {
+		opts.PageID = "PAGE_ID"
+	}
+
+	s := &Server{
+		opts:        opts,
+		client:      &http.Client{Timeout: 30 * time.Second},
+		transcripts: make(map[string][]Record),
+		profile:     make(map[string]json.RawMessage),
+		users:       make(map[string]Profile),
+	}
+	s.handler = s.routes()
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+func (s *Server) routes() http.Handler {
+	r := gin.New()
+	r.Use(gin.Recovery())
+
+	// Graph API endpoints, always versioned like https://graph.facebook.com/v18.0/...
+	r.POST("/:version/me/messages", s.handleSend)
+	r.POST("/:version/me/message_attachments", s.handleAttachmentUpload)
+	r.GET("/:version/me/messenger_profile", s.handleGetProfile)
+	r.POST("/:version/me/messenger_profile", s.handleSetProfile)
+	r.DELETE("/:version/me/messenger_profile", s.handleDeleteProfile)
+	r.GET("/:version/:psid", s.handleUserProfile)
+
+	// Control endpoints for inspecting and steering the fake
+	fake := r.Group("/_fake")
+	{
+		fake.GET("/transcripts", s.handleTranscripts)
+		fake.GET("/transcripts/:psid", s.handleTranscript)
+		fake.DELETE("/transcripts", s.handleReset)
+		fake.GET("/messenger_profile", s.handleGetProfile)
+		fake.POST("/faults", s.handleAddFault)
+		fake.DELETE("/faults", s.handleClearFaults)
+		fake.PUT("/users/:psid", s.handleSetUser)
+		fake.POST("/deliver", s.handleDeliver)
+	}
+
+	return r
+}
+
+// Transcript returns everything sent to the recipient, oldest first
+func (s *Server) Transcript(psid string) []Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Record(nil), s.transcripts[psid]...)
+}
+
+// Transcripts returns the transcripts of every recipient
+func (s *Server) Transcripts() map[string][]Record {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	all := make(map[string][]Record, len(s.transcripts))
+	for psid, records := range s.transcripts {
+		all[psid] = append([]Record(nil), records...)
+	}
+	return all
+}
+
+// MessengerProfile returns the fields the bot has set on the page's Messenger profile
+func (s *Server) MessengerProfile() map[string]json.RawMessage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	profile := make(map[string]json.RawMessage, len(s.profile))
+	for k, v := range s.profile {
+		profile[k] = v
+	}
+	return profile
+}
+
+// SetUser sets the profile returned for a PSID lookup
+func (s *Server) SetUser(p Profile) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.users[p.ID] = p
+}
+
+// Reset clears transcripts, faults and the Messenger profile
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.transcripts = make(map[string][]Record)
+	s.profile = make(map[string]json.RawMessage)
+	s.faults = nil
+}
+
+func (s *Server) handleSend(c *gin.Context) {
+	var req struct {
+		Recipient struct {
+			ID string `json:"id"`
+		} `json:"recipient"`
+		MessagingType string          `json:"messaging_type"`
+		Tag           string          `json:"tag"`
+		SenderAction  string          `json:"sender_action"`
+		Message       json.RawMessage `json:"message"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, FaultBadRequest("invalid JSON body: "+err.Error()))
+		return
+	}
+	if req.Recipient.ID == "" {
+		writeError(c, FaultBadRequest("recipient id is required"))
+		return
+	}
+	if !s.authorized(c) {
+		return
+	}
+	if f := s.takeFault(c.FullPath(), req.Recipient.ID); f != nil {
+		writeError(c, f)
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	record := Record{
+		Recipient:     req.Recipient.ID,
+		Time:          time.Now(),
+		MessagingType: req.MessagingType,
+		Tag:           req.Tag,
+		SenderAction:  req.SenderAction,
+		Message:       req.Message,
+	}
+	if req.SenderAction == "" {
+		record.MessageID = fmt.Sprintf("m_fake_%d", s.nextID)
+	}
+	s.transcripts[req.Recipient.ID] = append(s.transcripts[req.Recipient.ID], record)
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{
+		"recipient_id": req.Recipient.ID,
+		"message_id":   record.MessageID,
+	})
+}
+
+func (s *Server) handleAttachmentUpload(c *gin.Context) {
+	if !s.authorized(c) {
+		return
+	}
+	if f := s.takeFault(c.FullPath(), ""); f != nil {
+		writeError(c, f)
+		return
+	}
+
+	s.mu.Lock()
+	s.nextID++
+	id := fmt.Sprintf("%d", 1000000+s.nextID)
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"attachment_id": id})
+}
+
+func (s *Server) handleGetProfile(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": []map[string]json.RawMessage{s.MessengerProfile()}})
+}
+
+func (s *Server) handleSetProfile(c *gin.Context) {
+	var fields map[string]json.RawMessage
+	if err := c.ShouldBindJSON(&fields); err != nil {
+		writeError(c, FaultBadRequest("invalid JSON body: "+err.Error()))
+		return
+	}
+	if !s.authorized(c) {
+		return
+	}
+	if f := s.takeFault(c.FullPath(), ""); f != nil {
+		writeError(c, f)
+		return
+	}
+
+	s.mu.Lock()
+	for k, v := range fields {
+		s.profile[k] = v
+	}
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"result": "success"})
+}
+
+func (s *Server) handleDeleteProfile(c *gin.Context) {
+	var req struct {
+		Fields []string `json:"fields"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		writeError(c, FaultBadRequest("invalid JSON body: "+err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	for _, field := range req.Fields {
+		delete(s.profile, field)
+	}
+	s.mu.Unlock()
+
+	c.JSON(http.StatusOK, gin.H{"result": "success"})
+}
+
+func (s *Server) handleUserProfile(c *gin.Context) {
+	psid := c.Param("psid")
+	if !s.authorized(c) {
+		return
+	}
+	if f := s.takeFault(c.FullPath(), psid); f != nil {
+		writeError(c, f)
+		return
+	}
+
+	s.mu.Lock()
+	profile, exists := s.users[psid]
+	s.mu.Unlock()
+	if !exists {
+		profile = Profile{
+			ID:        psid,
+			Name:      "Test User " + psid,
+			FirstName: "Test",
+			LastName:  "User " + psid,
+			Locale:    "en_US",
+		}
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+func (s *Server) handleTranscripts(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Transcripts())
+}
+
+func (s *Server) handleTranscript(c *gin.Context) {
+	c.JSON(http.StatusOK, s.Transcript(c.Param("psid")))
+}
+
+func (s *Server) handleReset(c *gin.Context) {
+	s.Reset()
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) handleSetUser(c *gin.Context) {
+	var p Profile
+	if err := c.ShouldBindJSON(&p); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	p.ID = c.Param("psid")
+	s.SetUser(p)
+	c.JSON(http.StatusOK, p)
+}
+
+// authorized rejects requests without an access token the way Graph does
+func (s *Server) authorized(c *gin.Context) bool {
+	if c.Query("access_token") == "" {
+		writeError(c, &Fault{Status: http.StatusBadRequest, Code: 190, Message: "An access token is required to request this resource."})
+		return false
+	}
+	return true
+}
+
+// signature computes the X-Hub-Signature-256 header value for body
+func (s *Server) signature(body []byte) string {
+	mac := hmac.New(sha256.New, []byte(s.opts.AppSecret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// post delivers a signed webhook body to the bot
+func (s *Server) post(body []byte) error {
+	if s.opts.WebhookURL == "" {
+		return fmt.Errorf("no webhook URL configured")
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.opts.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Hub-Signature-256", s.signature(body))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("webhook responded with %s", resp.Status)
+	}
+	return nil
+}
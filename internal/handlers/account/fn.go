@@ -5,9 +5,10 @@ import (
 	"log"
 	"strconv"
 	"strings"
+	"time"
 
-	"school-assistant-wh/internal/constants"
 	"school-assistant-wh/internal/handlers/utils"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/models"
 	"school-assistant-wh/internal/repositories"
 	"school-assistant-wh/internal/services/facebook"
@@ -20,16 +21,19 @@ type AccountHandler struct {
 	linkRepo     repositories.UserLinkRepository
 	fbSvc        *facebook.Service
 	utils        *utils.ResponseUtils
-	stateManager *state.StateManager
+	stateManager state.StateManager
+	// saIDTTL is how long an SA-ID shown for linking stays valid, 0 for ever
+	saIDTTL time.Duration
 }
 
-func NewAccountHandler(repo repositories.UserRepository, linkRepo repositories.UserLinkRepository, fbSvc *facebook.Service, stateManager *state.StateManager) *AccountHandler {
+func NewAccountHandler(repo repositories.UserRepository, linkRepo repositories.UserLinkRepository, fbSvc *facebook.Service, stateManager state.StateManager, saIDTTL time.Duration) *AccountHandler {
 	return &AccountHandler{
 		repo:         repo,
 		linkRepo:     linkRepo,
 		fbSvc:        fbSvc,
 		utils:        utils.NewResponseUtils(repo, linkRepo, fbSvc),
 		stateManager: stateManager,
+		saIDTTL:      saIDTTL,
 	}
 }
 
@@ -37,14 +41,24 @@ func (h *AccountHandler) HandleRegistration(senderID string) error {
 	user, err := h.repo.RegisterUser(senderID)
 	if err != nil {
 		log.Printf("Error registering user: %v", err)
-		return h.fbSvc.SendTextMessage(senderID, "Sorry, we couldn't complete your registration. Please try again.")
+		return h.fbSvc.SendTextMessage(senderID, i18n.T(h.utils.Lang(senderID), "registration.failed"))
 	}
+	lang := i18n.Of(user.Language)
 
-	if err := h.fbSvc.SendTextMessage(senderID, "Congratulations! You are now registered to School Assistant"); err != nil {
-		return fmt.Errorf("failed to send header message: %v", err)
+	if err := h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "registration.done")); err != nil {
+		return fmt.Errorf("failed to send header message: %w", err)
 	}
 
-	welcomeMsg := fmt.Sprintf(constants.LinkAccountInstructions, *user.Code)
+	code, err := h.repo.LinkingCode(user, h.saIDTTL)
+	if err != nil {
+		return fmt.Errorf("failed to get linking code: %w", err)
+	}
+
+	welcomeMsg := i18n.T(lang, "link.instructions", code)
+	_, data := h.stateManager.GetState(senderID)
+	if school, err := state.Get[state.ReferredSchool](data); err == nil && school.SchoolName != "" {
+		welcomeMsg = i18n.T(lang, "link.instructions_school", school.SchoolName, code)
+	}
 	return h.utils.SendResponseWithQuickReplies(senderID, welcomeMsg)
 }
 
@@ -52,46 +66,49 @@ func (h *AccountHandler) HandleViewProfile(senderID string) error {
 	// Get user's linked profiles
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %v", err)
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	profiles, err := h.linkRepo.GetUserLinks(int(user.ID))
 	if err != nil {
-		return fmt.Errorf("failed to get user links: %v", err)
+		return fmt.Errorf("failed to get user links: %w", err)
 	}
 
 	if len(profiles) == 0 {
-		if err := h.fbSvc.SendTextMessage(senderID, "You don't have any linked accounts yet."); err != nil {
-			return fmt.Errorf("failed to send header message: %v", err)
+		if err := h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "profile.none_linked")); err != nil {
+			return fmt.Errorf("failed to send header message: %w", err)
 		}
 
-		message := fmt.Sprintf(constants.LinkAccountInstructions, *user.Code)
-		return h.utils.SendResponseWithQuickReplies(senderID, message)
+		code, err := h.repo.LinkingCode(user, h.saIDTTL)
+		if err != nil {
+			return fmt.Errorf("failed to get linking code: %w", err)
+		}
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "link.instructions", code))
 	}
 
 	// If there's only one profile and no primary set, ask for confirmation
 	if len(profiles) == 1 && !profiles[0].IsPrimary {
 		profile := profiles[0]
-		message := fmt.Sprintf(
-			constants.SingleProfileConfirmation,
+		message := i18n.T(lang, "profile.single_confirmation",
 			profile.Student.FirstName,
 			safeString(&profile.Student.LastName),
 			safeString(&profile.Student.StudentID),
 			safeString(&profile.Student.School.SchoolName),
 		)
-		quickReplies := helpers.GetProfileConfirmationReplies()
+		quickReplies := helpers.GetProfileConfirmationReplies(lang)
 		return h.fbSvc.SendQuickReplies(senderID, message, quickReplies)
 	}
 
 	// Check if there's a primary profile
 	for _, profile := range profiles {
 		if profile.IsPrimary && profile.Student != nil {
-			message := fmt.Sprintf(
-				constants.AccountPrimaryMessage,
+			message := i18n.T(lang, "profile.primary",
 				profile.Student.FirstName,
 				profile.Student.LastName,
 				profile.Student.StudentID,
@@ -100,28 +117,28 @@ func (h *AccountHandler) HandleViewProfile(senderID string) error {
 
 			var quickReplies []facebook.QuickReply
 			if len(profiles) > 1 {
-				quickReplies = helpers.GetProfileManagementReplies()
+				quickReplies = helpers.GetProfileManagementReplies(lang)
 			} else {
-				quickReplies = helpers.GetProfileConfirmationReplies()
+				quickReplies = helpers.GetProfileConfirmationReplies(lang)
 			}
 			return h.fbSvc.SendQuickReplies(senderID, message, quickReplies)
 		}
 	}
-	profileMap := make(map[string]map[string]any)
+	profileMap := make(state.ProfileChoices)
 
 	for i, profile := range profiles {
 		key := strconv.Itoa(i + 1)
-		profileMap[key] = map[string]any{
-			"StudentID": profile.Student.StudentID,
-			"SchoolID":  profile.Student.School.SchoolID,
+		profileMap[key] = state.ProfileChoice{
+			StudentID: profile.Student.StudentID,
+			SchoolID:  profile.Student.School.SchoolID,
 		}
 	}
 
-	h.stateManager.SetState(senderID, state.StateProfileView, map[string]any{
-		state.KeyProfileMap: profileMap,
-	})
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateProfileView}, profileMap); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
 
-	return h.ShowAccountList(senderID, profiles, "Select a profile to switch to:")
+	return h.ShowAccountList(senderID, lang, profiles, i18n.T(lang, "profile.select"))
 
 }
 
@@ -129,71 +146,85 @@ func (h *AccountHandler) HandleSwitchProfile(senderID string) error {
 	// Get user's linked profiles
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %v", err)
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	profiles, err := h.linkRepo.GetUserLinks(int(user.ID))
 	if err != nil {
-		return fmt.Errorf("failed to get user links: %v", err)
+		return fmt.Errorf("failed to get user links: %w", err)
 	}
 
 	if len(profiles) <= 1 {
-		return h.utils.SendResponseWithQuickReplies(senderID,
-			"You only have one linked profile. Contact your school admin to link more profiles.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.only_one"))
 	}
-	profileMap := make(map[string]map[string]any)
+	profileMap := make(state.ProfileChoices)
 
 	for i, profile := range profiles {
 		key := strconv.Itoa(i + 1)
-		profileMap[key] = map[string]any{
-			"StudentID": profile.Student.StudentID,
-			"SchoolID":  profile.Student.School.SchoolID,
+		profileMap[key] = state.ProfileChoice{
+			StudentID: profile.Student.StudentID,
+			SchoolID:  profile.Student.School.SchoolID,
 		}
 	}
 
-	h.stateManager.SetState(senderID, state.StateProfileSwitch, map[string]any{
-		state.KeyProfileMap: profileMap,
-	})
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateProfileSwitch}, profileMap); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
 
-	return h.ShowAccountList(senderID, profiles, "Select a profile to switch to:")
+	return h.ShowAccountList(senderID, lang, profiles, i18n.T(lang, "profile.select"))
 }
 func (h *AccountHandler) HandleViewSaID(senderID string) error {
 	// Get user's linked profiles
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
-		return fmt.Errorf("failed to get user: %v", err)
+		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
-	if err := h.fbSvc.SendTextMessage(senderID, "Your School Assistant ID:"); err != nil {
-		return fmt.Errorf("failed to send header message: %v", err)
+	code, err := h.repo.LinkingCode(user, h.saIDTTL)
+	if err != nil {
+		return fmt.Errorf("failed to get linking code: %w", err)
 	}
 
-	return h.utils.SendResponseWithQuickReplies(senderID, fmt.Sprintf("%s", *user.Code))
+	text := code
+	if user.CodeExpiresAt != nil {
+		text += "\n\n" + i18n.T(lang, "sa_id.expires", user.CodeExpiresAt.Format("Jan 2, 2006 3:04 PM"))
+	}
+	messages := []facebook.Message{facebook.TextMessage(i18n.T(lang, "sa_id.header"))}
+	if qr, err := h.codeQR(code); err != nil {
+		log.Printf("Error preparing SA-ID QR code for %s: %v", senderID, err)
+	} else {
+		messages = append(messages, qr)
+	}
+	messages = append(messages, facebook.QuickRepliesMessage(text, helpers.GetSaIDReplies(lang)))
+	return h.fbSvc.SendSequence(senderID, messages)
 }
 
 // internal/handlers/account/handler.go
-func (h *AccountHandler) HandleProfileSelection(senderID string, selectedProfile map[string]any) error {
+func (h *AccountHandler) HandleProfileSelection(senderID string, selectedProfile state.ProfileChoice) error {
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
-	studentID := selectedProfile["StudentID"].(string)
-	schoolID := selectedProfile["SchoolID"].(string)
-
-	if err := h.linkRepo.UpdatePrimaryStatus(int(user.ID), studentID, schoolID); err != nil {
+	if err := h.linkRepo.UpdatePrimaryStatus(int(user.ID), selectedProfile.StudentID, selectedProfile.SchoolID); err != nil {
 		return fmt.Errorf("failed to update primary profile: %w", err)
 	}
 
@@ -203,7 +234,7 @@ func (h *AccountHandler) HandleProfileSelection(senderID string, selectedProfile
 	}
 
 	if err := h.fbSvc.SendTextMessage(senderID,
-		fmt.Sprintf(constants.AccountSwitchedMessage,
+		i18n.T(lang, "profile.switched",
 			primaryProfile.Student.FirstName,
 			primaryProfile.Student.LastName,
 		),
@@ -218,24 +249,26 @@ func (h *AccountHandler) HandleSelectCurrentProfile(senderID string) error {
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return h.fbSvc.SendTextMessage(senderID, "Sorry, we couldn't complete your request. Please try again.")
+		return h.fbSvc.SendTextMessage(senderID, i18n.T(i18n.Default, "error.request"))
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	profiles, err := h.linkRepo.GetUserLinks(int(user.ID))
 	if err != nil || len(profiles) == 0 {
 		log.Printf("Error getting user links or no profiles found: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Sorry, we couldn't find any linked profiles. Please try again.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.not_found"))
 	}
 
 	if len(profiles) == 1 {
 		err = h.linkRepo.UpdatePrimaryStatus(int(user.ID), profiles[0].StudentID, profiles[0].SchoolID)
 		if err != nil {
 			log.Printf("Error setting primary status: %v", err)
-			return h.utils.SendResponseWithQuickReplies(senderID, "Sorry, we couldn't update your profile. Please try again.")
+			return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.update_failed"))
 		}
 	}
 
@@ -248,7 +281,7 @@ func (h *AccountHandler) HandleSelectCurrentProfile(senderID string) error {
 	}
 
 	if primaryProfile != nil && primaryProfile.Student != nil {
-		message := fmt.Sprintf(constants.ProfileConfirmationMessage,
+		message := i18n.T(lang, "profile.set",
 			primaryProfile.Student.FirstName,
 			primaryProfile.Student.LastName,
 			primaryProfile.Student.StudentID,
@@ -262,23 +295,23 @@ func (h *AccountHandler) HandleSelectCurrentProfile(senderID string) error {
 	return nil
 }
 
-func (h *AccountHandler) ShowAccountList(senderID string, profiles []models.UserLinkWithStudent, message string) error {
+func (h *AccountHandler) ShowAccountList(senderID string, lang i18n.Lang, profiles []models.UserLinkWithStudent, message string) error {
 	var sb strings.Builder
 
 	// Use message param if provided, otherwise fallback
 	if message != "" {
 		sb.WriteString(message + "\n\n")
 	} else {
-		sb.WriteString("Your Linked Profiles:\n")
+		sb.WriteString(i18n.T(lang, "profile.list_header") + "\n")
 	}
 
 	for i, profile := range profiles {
 		sb.WriteString(fmt.Sprintf("[%d] ", i+1))
 
 		if profile.IsPrimary {
-			sb.WriteString(fmt.Sprintf("%s %s (Primary)\n",
+			sb.WriteString(i18n.T(lang, "profile.list_primary",
 				safeString(&profile.Student.FirstName),
-				safeString(&profile.Student.LastName)))
+				safeString(&profile.Student.LastName)) + "\n")
 		} else {
 			sb.WriteString(fmt.Sprintf("%s %s\n",
 				safeString(&profile.Student.FirstName),
@@ -286,14 +319,14 @@ func (h *AccountHandler) ShowAccountList(senderID string, profiles []models.User
 		}
 
 		if profile.Student != nil {
-			sb.WriteString(fmt.Sprintf("Student ID: %s\n", safeString(&profile.Student.StudentID)))
+			sb.WriteString(i18n.T(lang, "profile.list_student_id", safeString(&profile.Student.StudentID)) + "\n")
 
 			if profile.Student.School != nil {
-				sb.WriteString(fmt.Sprintf("School: %s\n", safeString(&profile.Student.School.SchoolName)))
+				sb.WriteString(i18n.T(lang, "profile.list_school", safeString(&profile.Student.School.SchoolName)) + "\n")
 			}
 
 			if profile.Student.YearLevel != "" {
-				sb.WriteString(fmt.Sprintf("Year: %s", safeString(&profile.Student.YearLevel)))
+				sb.WriteString(i18n.T(lang, "profile.list_year", safeString(&profile.Student.YearLevel)))
 				if profile.Student.Course != "" {
 					sb.WriteString(fmt.Sprintf(" - %s", safeString(&profile.Student.Course)))
 				}
@@ -303,7 +336,7 @@ func (h *AccountHandler) ShowAccountList(senderID string, profiles []models.User
 		sb.WriteString("\n")
 	}
 
-	quickReplies := helpers.GetBack()
+	quickReplies := helpers.GetBack(lang)
 	return h.fbSvc.SendQuickReplies(senderID, sb.String(), quickReplies)
 }
 
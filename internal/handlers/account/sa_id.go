@@ -0,0 +1,24 @@
+package account
+
+import (
+	"fmt"
+
+	"school-assistant-wh/internal/services/facebook"
+	"school-assistant-wh/internal/utils"
+)
+
+// codeQR uploads the SA-ID as a QR code image for admin tooling to scan. The
+// image is optional, so the SA-ID is still sent when Messenger rejects it.
+func (h *AccountHandler) codeQR(code string) (facebook.Message, error) {
+	png, err := utils.QRCodePNG(code)
+	if err != nil {
+		return facebook.Message{}, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	attachmentID, err := h.fbSvc.UploadImage(code+".png", png)
+	if err != nil {
+		return facebook.Message{}, err
+	}
+	msg := facebook.UploadedImageMessage(attachmentID)
+	msg.Optional = true
+	return msg, nil
+}
@@ -0,0 +1,382 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/repositories"
+)
+
+// adminUserLimit caps how many users a lookup returns
+const adminUserLimit = 50
+
+// adminUser is a user as the admin API shows them
+type adminUser struct {
+	ID            uint         `json:"id"`
+	PSID          string       `json:"psid"`
+	Name          string       `json:"name"`
+	SaID          string       `json:"sa_id,omitempty"`
+	SaIDExpiresAt *time.Time   `json:"sa_id_expires_at,omitempty"`
+	Active        bool         `json:"active"`
+	Language      string       `json:"language"`
+	CreatedAt     time.Time    `json:"created_at"`
+	LastLoginAt   *time.Time   `json:"last_login_at,omitempty"`
+	Links         *[]adminLink `json:"links,omitempty"`
+}
+
+// adminLink is a user's link to a student as the admin API shows it
+type adminLink struct {
+	ID        int    `json:"id"`
+	SchoolID  string `json:"school_id"`
+	StudentID string `json:"student_id"`
+	Role      string `json:"role"`
+	Active    bool   `json:"active"`
+	Primary   bool   `json:"primary"`
+	CreatedAt string `json:"created_at"`
+}
+
+func adminUserOf(user models.User) adminUser {
+	view := adminUser{
+		ID:            user.ID,
+		PSID:          user.PSID,
+		Name:          user.FBName,
+		SaIDExpiresAt: user.CodeExpiresAt,
+		Active:        user.IsActive,
+		Language:      user.Language,
+		CreatedAt:     user.CreatedAt,
+		LastLoginAt:   user.LastLoginAt,
+	}
+	if user.Code != nil {
+		view.SaID = *user.Code
+	}
+	return view
+}
+
+func adminLinkOf(link models.UserLink) adminLink {
+	return adminLink{
+		ID:        link.ID,
+		SchoolID:  link.SchoolID,
+		StudentID: link.StudentID,
+		Role:      link.LinkRole(),
+		Active:    link.IsActive,
+		Primary:   link.IsPrimary,
+		CreatedAt: link.CreatedAt,
+	}
+}
+
+// AdminFindUsers looks users up by ?psid=, ?sa_id= or ?name=. A school's key
+// only finds users linked to the school, except by SA-ID, which parents hand
+// to the school so it can link them.
+func (h *Handler) AdminFindUsers(c *gin.Context) {
+	q := repositories.UserQuery{
+		PSID:  strings.TrimSpace(c.Query("psid")),
+		Code:  strings.ToUpper(strings.TrimSpace(c.Query("sa_id"))),
+		Name:  strings.TrimSpace(c.Query("name")),
+		Limit: adminUserLimit,
+	}
+	if q.PSID == "" && q.Code == "" && q.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "psid, sa_id or name is required"})
+		return
+	}
+	if q.Code == "" {
+		q.SchoolID = adminSchool(c)
+	}
+
+	users, err := h.repo.FindUsers(q)
+	if err != nil {
+		log.Printf("Error finding users: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find users"})
+		return
+	}
+
+	views := make([]adminUser, 0, len(users))
+	for _, user := range users {
+		views = append(views, adminUserOf(user))
+	}
+	c.JSON(http.StatusOK, gin.H{"users": views})
+}
+
+// AdminGetUser returns a user with every link they have had. A school's key
+// only sees the school's links, and only users actively linked to it.
+func (h *Handler) AdminGetUser(c *gin.Context) {
+	user, ok := h.adminUserParam(c)
+	if !ok {
+		return
+	}
+
+	links, err := h.linkRepo.ListAllLinks(int(user.ID))
+	if err != nil {
+		log.Printf("Error listing links of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list links"})
+		return
+	}
+
+	school := adminSchool(c)
+	visible := school == ""
+	views := make([]adminLink, 0, len(links))
+	for _, link := range links {
+		if school != "" && link.SchoolID != school {
+			continue
+		}
+		visible = visible || link.IsActive
+		views = append(views, adminLinkOf(link))
+	}
+	if !visible {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return
+	}
+
+	view := adminUserOf(*user)
+	view.Links = &views
+	c.JSON(http.StatusOK, gin.H{"user": view})
+}
+
+// AdminSetUserActive activates or deactivates a user. Accounts span schools, so
+// only the admin API key may do this.
+func (h *Handler) AdminSetUserActive(active bool) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if adminSchool(c) != "" {
+			c.JSON(http.StatusForbidden, gin.H{"error": "a school key cannot change accounts"})
+			return
+		}
+		user, ok := h.adminUserParam(c)
+		if !ok {
+			return
+		}
+
+		if err := h.repo.SetActive(user, active); err != nil {
+			log.Printf("Error setting user %d active=%t: %v", user.ID, active, err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update user"})
+			return
+		}
+
+		log.Printf("Admin audit: user %d set active=%t from %s", user.ID, active, c.ClientIP())
+		c.JSON(http.StatusOK, gin.H{"user": adminUserOf(*user)})
+	}
+}
+
+// adminLinkRequest names the user by SA-ID and the student to link them to
+type adminLinkRequest struct {
+	SaID      string `json:"sa_id" binding:"required"`
+	SchoolID  string `json:"school_id" binding:"required"`
+	StudentID string `json:"student_id" binding:"required"`
+	Role      string `json:"role"`
+}
+
+// AdminCreateLink links the user with the SA-ID to the student. The user is
+// told about the link the next time link notices are sent.
+func (h *Handler) AdminCreateLink(c *gin.Context) {
+	req, user, ok := h.adminLinkTarget(c)
+	if !ok {
+		return
+	}
+
+	role := strings.ToLower(strings.TrimSpace(req.Role))
+	if role == "" {
+		role = models.LinkRoleGuardian
+	}
+	if !knownLinkRole(role) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("role must be one of %s", strings.Join(models.LinkRoles, ", "))})
+		return
+	}
+
+	linked, err := h.linkRepo.HasActiveLink(int(user.ID), req.SchoolID, req.StudentID)
+	if err != nil {
+		log.Printf("Error checking link of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check link"})
+		return
+	}
+	if linked {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is already linked to the student"})
+		return
+	}
+
+	link, err := h.linkRepo.AdminLinkStudent(int(user.ID), req.SchoolID, req.StudentID, role)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error linking user %d to %s/%s: %v", user.ID, req.SchoolID, req.StudentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to link student"})
+		return
+	}
+	h.repo.InvalidateCache(user.PSID)
+
+	h.auditAdminLink(c, user, req, models.LinkEventLinked, role)
+	c.JSON(http.StatusCreated, gin.H{"link": adminLinkOf(link.UserLink)})
+}
+
+// AdminRemoveLink unlinks the user with the SA-ID from the student. When it was
+// their primary link, the new primary link is returned.
+func (h *Handler) AdminRemoveLink(c *gin.Context) {
+	req, user, ok := h.adminLinkTarget(c)
+	if !ok {
+		return
+	}
+
+	primary, err := h.linkRepo.UnlinkStudent(int(user.ID), req.SchoolID, req.StudentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
+		return
+	}
+	if err != nil {
+		log.Printf("Error unlinking user %d from %s/%s: %v", user.ID, req.SchoolID, req.StudentID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to unlink student"})
+		return
+	}
+	h.repo.InvalidateCache(user.PSID)
+
+	h.auditAdminLink(c, user, req, models.LinkEventUnlinked, "")
+	resp := gin.H{"removed": true}
+	if primary != nil {
+		resp["primary"] = adminLinkOf(primary.UserLink)
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AdminSetPrimaryLink makes the user's link to the student their active profile
+func (h *Handler) AdminSetPrimaryLink(c *gin.Context) {
+	req, user, ok := h.adminLinkTarget(c)
+	if !ok {
+		return
+	}
+
+	linked, err := h.linkRepo.HasActiveLink(int(user.ID), req.SchoolID, req.StudentID)
+	if err != nil {
+		log.Printf("Error checking link of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check link"})
+		return
+	}
+	if !linked {
+		c.JSON(http.StatusNotFound, gin.H{"error": "link not found"})
+		return
+	}
+
+	if err := h.linkRepo.UpdatePrimaryStatus(int(user.ID), req.StudentID, req.SchoolID); err != nil {
+		log.Printf("Error setting primary link of user %d: %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to set primary link"})
+		return
+	}
+	h.repo.InvalidateCache(user.PSID)
+
+	h.auditAdminLink(c, user, req, models.LinkEventPrimarySet, "")
+	primary, err := h.linkRepo.GetPrimaryLink(int(user.ID))
+	if err != nil {
+		log.Printf("Error getting primary link of user %d: %v", user.ID, err)
+		c.JSON(http.StatusOK, gin.H{"updated": true})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"updated": true, "primary": adminLinkOf(primary.UserLink)})
+}
+
+// AdminListSchools lists the schools the key may manage
+func (h *Handler) AdminListSchools(c *gin.Context) {
+	schools, err := h.schoolRepo.ListSchools()
+	if err != nil {
+		log.Printf("Error listing schools: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list schools"})
+		return
+	}
+
+	school := adminSchool(c)
+	visible := make([]models.School, 0, len(schools))
+	for _, s := range schools {
+		if school == "" || s.SchoolID == school {
+			visible = append(visible, s)
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"schools": visible})
+}
+
+// adminUserParam loads the user named by the :id path parameter, responding
+// with an error when there is none
+func (h *Handler) adminUserParam(c *gin.Context) (*models.User, bool) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid user id"})
+		return nil, false
+	}
+
+	user, err := h.repo.GetUserByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
+		return nil, false
+	}
+	if err != nil {
+		log.Printf("Error getting user %d: %v", id, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to get user"})
+		return nil, false
+	}
+	return user, true
+}
+
+// adminLinkTarget binds a link request and loads the user with its SA-ID,
+// responding with an error when the key may not manage the school or the
+// SA-ID does not name an active user
+func (h *Handler) adminLinkTarget(c *gin.Context) (*adminLinkRequest, *models.User, bool) {
+	var req adminLinkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sa_id, school_id and student_id are required"})
+		return nil, nil, false
+	}
+	req.SaID = strings.ToUpper(strings.TrimSpace(req.SaID))
+
+	if school := adminSchool(c); school != "" && school != req.SchoolID {
+		c.JSON(http.StatusForbidden, gin.H{"error": "the key cannot manage this school"})
+		return nil, nil, false
+	}
+
+	users, err := h.repo.FindUsers(repositories.UserQuery{Code: req.SaID, Limit: 1})
+	if err != nil {
+		log.Printf("Error finding user by SA-ID: %v", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to find user"})
+		return nil, nil, false
+	}
+	if len(users) == 0 || (users[0].CodeExpiresAt != nil && time.Now().After(*users[0].CodeExpiresAt)) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no user has this SA-ID, or it has expired"})
+		return nil, nil, false
+	}
+	if !users[0].IsActive {
+		c.JSON(http.StatusConflict, gin.H{"error": "user is deactivated"})
+		return nil, nil, false
+	}
+	return &req, &users[0], true
+}
+
+// auditAdminLink records a link change made through the admin API
+func (h *Handler) auditAdminLink(c *gin.Context, user *models.User, req *adminLinkRequest, event, detail string) {
+	log.Printf("Admin audit: %s user %d %s/%s from %s", event, user.ID, req.SchoolID, req.StudentID, c.ClientIP())
+
+	err := h.auditRepo.Record(models.LinkAudit{
+		PSID:      user.PSID,
+		UserID:    int(user.ID),
+		SchoolID:  req.SchoolID,
+		StudentID: req.StudentID,
+		Method:    models.LinkMethodAdmin,
+		Event:     event,
+		Detail:    detail,
+	})
+	if err != nil {
+		log.Printf("Error auditing admin %s: %v", event, err)
+	}
+}
+
+// knownLinkRole reports whether role is one of models.LinkRoles
+func knownLinkRole(role string) bool {
+	for _, r := range models.LinkRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,147 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"school-assistant-wh/internal/config"
+)
+
+func TestAdminSchoolKeysStayInTheirSchool(t *testing.T) {
+	const (
+		adminKey  = "admin-key"
+		ownKey    = "sch1-key"
+		otherKey  = "sch2-key"
+		linkS1    = `{"sa_id":"SA-ABC123","school_id":"sch1","student_id":"S1"}`
+		linkOther = `{"sa_id":"SA-ABC123","school_id":"sch2","student_id":"S9"}`
+	)
+
+	tests := []struct {
+		name   string
+		key    string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"get user linked to the school", ownKey, http.MethodGet, "/admin/v1/users/1", "", http.StatusOK},
+		{"get user linked to another school", otherKey, http.MethodGet, "/admin/v1/users/1", "", http.StatusNotFound},
+		{"get user with the admin key", adminKey, http.MethodGet, "/admin/v1/users/1", "", http.StatusOK},
+		{"deactivate user", ownKey, http.MethodPost, "/admin/v1/users/1/deactivate", "", http.StatusForbidden},
+		{"activate user", otherKey, http.MethodPost, "/admin/v1/users/1/activate", "", http.StatusForbidden},
+		{"create link in another school", ownKey, http.MethodPost, "/admin/v1/links", linkOther, http.StatusForbidden},
+		{"remove link in another school", otherKey, http.MethodDelete, "/admin/v1/links", linkS1, http.StatusForbidden},
+		{"set primary link in another school", otherKey, http.MethodPost, "/admin/v1/links/primary", linkS1, http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := linkedUserTables()
+			// A past link to sch2 neither shows to sch1 nor lets sch2 see the user
+			tables[1].rows = append(tables[1].rows, []driver.Value{int64(2), false, int64(1), "S9", "sch2", "guardian", false, false})
+			wt := newWebhookTest(t, "page-token", tables...)
+			r := gin.New()
+			admin := r.Group("/admin/v1")
+			admin.Use(AdminScopeMiddleware(config.AdminConfig{
+				APIKey:     adminKey,
+				SchoolKeys: map[string]string{"sch1": ownKey, "sch2": otherKey},
+			}))
+			admin.GET("/users/:id", wt.h.AdminGetUser)
+			admin.POST("/users/:id/activate", wt.h.AdminSetUserActive(true))
+			admin.POST("/users/:id/deactivate", wt.h.AdminSetUserActive(false))
+			admin.POST("/links", wt.h.AdminCreateLink)
+			admin.DELETE("/links", wt.h.AdminRemoveLink)
+			admin.POST("/links/primary", wt.h.AdminSetPrimaryLink)
+
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			req.Header.Set(adminKeyHeader, tt.key)
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want != http.StatusOK {
+				for _, write := range []string{"INSERT", "UPDATE", "DELETE"} {
+					if wt.db.ran(write) {
+						t.Fatalf("refused request ran an %s", write)
+					}
+				}
+			}
+			if tt.want == http.StatusOK && (tt.key == ownKey) == strings.Contains(w.Body.String(), "sch2") {
+				t.Fatalf("with key %s got %s, want only the admin key to see the sch2 link", tt.key, w.Body)
+			}
+		})
+	}
+}
+
+func TestAdminFindUsersMatchesWildcardsLiterally(t *testing.T) {
+	wt := newWebhookTest(t, "page-token", linkedUserTables()...)
+	r := gin.New()
+	r.GET("/admin/v1/users", wt.h.AdminFindUsers)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/v1/users?name="+url.QueryEscape(`50%_off\`), nil)
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !wt.db.ranWith("LIKE", `%50\%\_off\\%`) {
+		t.Fatal("the name search did not escape LIKE wildcards")
+	}
+}
+
+func TestAdminCreateLinkSetsCreatedAt(t *testing.T) {
+	tests := []struct {
+		name    string
+		existed bool
+		write   string
+	}{
+		{"new link", false, "INSERT INTO `gk_miniapps`.`school_link_user` (`CreatedAt`"},
+		{"reactivated link", true, "`CreatedAt`=?"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tables := linkedUserTables()
+			tables[1].rows = [][]driver.Value{{int64(1), false, int64(1), "S1", "sch1", "guardian", false, false}}
+			// The inactive link shows once it exists, and never to lookups of
+			// active links
+			exists := tt.existed
+			tables[1].match = func(row []driver.Value, args []driver.Value) bool {
+				for _, arg := range args {
+					if active, ok := arg.(bool); ok && row[1] != active {
+						return false
+					}
+				}
+				return exists
+			}
+			wt := newWebhookTest(t, "page-token", tables...)
+			wt.db.onExec = func(query string, args []driver.Value) error {
+				exists = exists || strings.HasPrefix(query, "INSERT INTO `gk_miniapps`.`school_link_user`")
+				return nil
+			}
+			r := gin.New()
+			r.POST("/admin/v1/links", wt.h.AdminCreateLink)
+
+			body := `{"sa_id":"SA-ABC123","school_id":"sch1","student_id":"S1"}`
+			req := httptest.NewRequest(http.MethodPost, "/admin/v1/links", strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != http.StatusCreated {
+				t.Fatalf("status = %d, want %d: %s", w.Code, http.StatusCreated, w.Body)
+			}
+			// Stale notices are cleared by CreatedAt, so it cannot be left to
+			// a column default
+			if !wt.db.ran(tt.write) {
+				t.Fatal("the announced link was stored without a CreatedAt")
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// globalCommand handles a command that works the same way in every conversation
+// state, including states that otherwise take free-form text
+type globalCommand func(senderID string, current state.State) error
+
+// registerCommands declares the commands honored before any state handling
+func (h *Handler) registerCommands() {
+	h.commands = map[string]globalCommand{
+		helpers.PayloadMenu: func(senderID string, _ state.State) error {
+			return h.enterState(senderID, state.StateMainMenu)
+		},
+		helpers.PayloadHome: func(senderID string, _ state.State) error {
+			return h.goHome(senderID)
+		},
+		helpers.PayloadHelp:   h.handleHelp,
+		helpers.PayloadCancel: h.handleCancel,
+		helpers.PayloadSwitchProfile: func(senderID string, _ state.State) error {
+			return h.accountHdlr.HandleSwitchProfile(senderID)
+		},
+		helpers.PayloadMySaID: func(senderID string, _ state.State) error {
+			return h.accountHdlr.HandleViewSaID(senderID)
+		},
+		helpers.PayloadRegenerateSaID: func(senderID string, _ state.State) error {
+			return h.showConfirmRegenerateSaID(senderID)
+		},
+		helpers.PayloadMyData: func(senderID string, _ state.State) error {
+			return h.showMyData(senderID)
+		},
+		helpers.PayloadTalkToHuman: func(senderID string, _ state.State) error {
+			return h.handleTalkToHuman(senderID)
+		},
+		helpers.PayloadLanguage: func(senderID string, _ state.State) error {
+			return h.handleLanguage(senderID)
+		},
+		helpers.PayloadLinkStudent: func(senderID string, _ state.State) error {
+			return h.handleLinkStudent(senderID)
+		},
+	}
+}
+
+// handleGlobalCommand runs command when it is one of the commands honored in
+// every state and reports whether it did
+func (h *Handler) handleGlobalCommand(senderID, command string) (bool, error) {
+	if lang, ok := helpers.ParseLanguagePayload(command); ok {
+		return true, h.handleSetLanguage(senderID, lang)
+	}
+	if linkID, ok := helpers.ParseMakePrimaryPayload(command); ok {
+		return true, h.handleMakePrimary(senderID, linkID)
+	}
+
+	cmd, ok := h.commands[command]
+	if !ok {
+		return false, nil
+	}
+	current, _ := h.stateManager.GetState(senderID)
+	return true, cmd(senderID, current)
+}
+
+// handleHelp lists what the user can do on the current screen, followed by the
+// commands available everywhere
+func (h *Handler) handleHelp(senderID string, current state.State) error {
+	lang := h.utils.Lang(senderID)
+	def, inFlow := h.states[current]
+	if !inFlow {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "help", i18n.T(lang, "help.default")))
+	}
+
+	options := def.help
+	if options == "" {
+		options = "help.buttons"
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "help", i18n.T(lang, options)), def.invalidReplies(lang))
+}
+
+// handleCancel abandons the current flow, forgetting its state and history
+func (h *Handler) handleCancel(senderID string, _ state.State) error {
+	if err := h.stateManager.ClearState(senderID); err != nil {
+		return fmt.Errorf("failed to clear state: %w", err)
+	}
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "cancelled"))
+}
+
+// handleLanguage offers the supported languages, each named in itself
+func (h *Handler) handleLanguage(senderID string) error {
+	lang := h.utils.Lang(senderID)
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "language.prompt"), helpers.GetLanguageReplies())
+}
+
+// handleSetLanguage stores the language picked by the user and confirms it in
+// that language
+func (h *Handler) handleSetLanguage(senderID string, lang i18n.Lang) error {
+	if err := h.repo.SetLanguage(senderID, lang); err != nil {
+		return err
+	}
+
+	if _, err := h.repo.GetUserByPSID(senderID); errors.Is(err, gorm.ErrRecordNotFound) {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "language.register_first"))
+	}
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "language.set"))
+}
@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// fakeDB is a database/sql driver serving canned rows. A query returns the rows
+// of the first table it matches and nothing otherwise; writes succeed unless
+// onExec fails them. Every statement is recorded so tests can check what the
+// bot stored.
+type fakeDB struct {
+	mu         sync.Mutex
+	tables     []fakeTable
+	statements []fakeStatement
+
+	// onExec, when set, sees every write and fails it by returning an error
+	onExec func(query string, args []driver.Value) error
+}
+
+// fakeStatement is a statement the bot ran, with its arguments
+type fakeStatement struct {
+	text string
+	args []driver.Value
+}
+
+// fakeTable answers the queries mentioning name. When match is set, only rows
+// it accepts for the query's arguments are returned.
+type fakeTable struct {
+	name    string
+	columns []string
+	rows    [][]driver.Value
+	match   func(row []driver.Value, args []driver.Value) bool
+}
+
+var (
+	fakeDBs       sync.Map
+	fakeDBCount   int
+	fakeDBCountMu sync.Mutex
+	registerFake  sync.Once
+)
+
+// newFakeDB opens a gorm connection backed by a fakeDB serving tables
+func newFakeDB(t *testing.T, tables ...fakeTable) (*gorm.DB, *fakeDB) {
+	t.Helper()
+	registerFake.Do(func() { sql.Register("fakedb", fakeDriver{}) })
+
+	fakeDBCountMu.Lock()
+	fakeDBCount++
+	dsn := fmt.Sprintf("fakedb-%d", fakeDBCount)
+	fakeDBCountMu.Unlock()
+
+	fake := &fakeDB{tables: tables}
+	fakeDBs.Store(dsn, fake)
+	t.Cleanup(func() { fakeDBs.Delete(dsn) })
+
+	sqlDB, err := sql.Open("fakedb", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{Logger: logger.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, fake
+}
+
+// ran reports whether any statement mentioning text was run
+func (f *fakeDB) ran(text string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.statements {
+		if strings.Contains(s.text, text) {
+			return true
+		}
+	}
+	return false
+}
+
+// ranWith reports whether any statement mentioning text was run with arg among
+// its arguments
+func (f *fakeDB) ranWith(text string, arg driver.Value) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, s := range f.statements {
+		if !strings.Contains(s.text, text) {
+			continue
+		}
+		for _, a := range s.args {
+			if a == arg {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (f *fakeDB) record(text string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, fakeStatement{text: text})
+}
+
+// endOfTx returns how the transaction running the first statement mentioning
+// text ended, COMMIT or ROLLBACK, or "" when no such statement ran
+func (f *fakeDB) endOfTx(text string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	found := false
+	for _, s := range f.statements {
+		if !found {
+			found = strings.Contains(s.text, text)
+			continue
+		}
+		if s.text == "COMMIT" || s.text == "ROLLBACK" {
+			return s.text
+		}
+	}
+	return ""
+}
+
+func (f *fakeDB) query(query string, args []driver.Value) driver.Rows {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, fakeStatement{query, args})
+
+	for _, table := range f.tables {
+		if !strings.Contains(query, table.name) {
+			continue
+		}
+		rows := &fakeRows{columns: table.columns}
+		for _, row := range table.rows {
+			if table.match == nil || table.match(row, args) {
+				rows.rows = append(rows.rows, row)
+			}
+		}
+		return rows
+	}
+	return &fakeRows{}
+}
+
+func (f *fakeDB) exec(query string, args []driver.Value) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.statements = append(f.statements, fakeStatement{query, args})
+	if f.onExec != nil {
+		return f.onExec(query, args)
+	}
+	return nil
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	db, ok := fakeDBs.Load(dsn)
+	if !ok {
+		return nil, fmt.Errorf("no fake database %q", dsn)
+	}
+	return fakeConn{db.(*fakeDB)}, nil
+}
+
+type fakeConn struct{ db *fakeDB }
+
+func (c fakeConn) Prepare(query string) (driver.Stmt, error) { return fakeStmt{c.db, query}, nil }
+func (fakeConn) Close() error                                { return nil }
+func (c fakeConn) Begin() (driver.Tx, error)                 { return fakeTx{c.db}, nil }
+
+// fakeTx records the end of a transaction as a COMMIT or ROLLBACK statement
+type fakeTx struct{ db *fakeDB }
+
+func (tx fakeTx) Commit() error {
+	tx.db.record("COMMIT")
+	return nil
+}
+
+func (tx fakeTx) Rollback() error {
+	tx.db.record("ROLLBACK")
+	return nil
+}
+
+type fakeStmt struct {
+	db    *fakeDB
+	query string
+}
+
+func (fakeStmt) Close() error  { return nil }
+func (fakeStmt) NumInput() int { return -1 }
+
+func (s fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+	if err := s.db.exec(s.query, args); err != nil {
+		return nil, err
+	}
+	return fakeResult{}, nil
+}
+
+func (s fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+	return s.db.query(s.query, args), nil
+}
+
+// fakeResult reports one affected row with ID 1
+type fakeResult struct{}
+
+func (fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+type fakeRows struct {
+	columns []string
+	rows    [][]driver.Value
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if len(r.rows) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.rows[0])
+	r.rows = r.rows[1:]
+	return nil
+}
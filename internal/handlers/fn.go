@@ -1,77 +1,131 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"log"
 
 	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
 )
 
-// handleProfileSelection handles the profile selection logic
-func (h *Handler) handleProfileSelection(senderID, message string, stateData map[string]any) error {
-	if profileMap, ok := stateData[state.KeyProfileMap].(map[string]map[string]any); ok {
-		if selectedProfile, exists := profileMap[message]; exists {
-			if err := h.accountHdlr.HandleProfileSelection(senderID, selectedProfile); err != nil {
-				return err
-			}
-			if err := h.fbSvc.SendTextMessage(senderID, constants.WelcomeMessageAboard); err != nil {
-				return fmt.Errorf("failed to send welcome message: %w", err)
-			}
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			err := h.menuHdlr.ShowMainMenu(senderID)
-			if err != nil {
-				return err
-			}
-			return nil
+// withTyping marks the conversation seen and shows the typing indicator while
+// handle runs, so users get feedback during slow lookups. Failing to send an
+// indicator never stops the reply itself.
+func (h *Handler) withTyping(senderID string, handle func()) {
+	for _, action := range []facebook.SenderAction{facebook.MarkSeen, facebook.TypingOn} {
+		if err := h.fbSvc.SendSenderAction(senderID, action); err != nil {
+			log.Printf("Error sending %s to %s: %v", action, senderID, err)
 		}
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID, "Invalid selection. Please try again.", quickReplies)
 	}
-	return nil
+
+	defer func() {
+		if err := h.fbSvc.SendSenderAction(senderID, facebook.TypingOff); err != nil {
+			log.Printf("Error sending %s to %s: %v", facebook.TypingOff, senderID, err)
+		}
+	}()
+
+	handle()
 }
 
-// handleContinue processes the CONTINUE action
-func (h *Handler) handleContinue(senderID string) error {
-	err := h.accountHdlr.HandleSelectCurrentProfile(senderID)
+// handleProfileSelection switches to the profile picked from the numbered list
+func (h *Handler) handleProfileSelection(senderID string, in stateInput) error {
+	profiles, err := state.Get[state.ProfileChoices](in.data)
 	if err != nil {
 		return err
 	}
 
-	if err := h.fbSvc.SendTextMessage(senderID, constants.WelcomeMessageAboard); err != nil {
-		return fmt.Errorf("failed to send welcome message: %w", err)
+	selectedProfile, exists := profiles[in.command]
+	if !exists {
+		return errInvalidInput
 	}
-	if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-		log.Printf("Error resetting state: %v", err)
+
+	if err := h.accountHdlr.HandleProfileSelection(senderID, selectedProfile); err != nil {
+		return err
+	}
+	if err := h.fbSvc.SendTextMessage(senderID, i18n.T(h.utils.Lang(senderID), "welcome.aboard")); err != nil {
+		return fmt.Errorf("failed to send welcome message: %w", err)
 	}
-	err = h.menuHdlr.ShowMainMenu(senderID)
+	return h.enterState(senderID, state.StateMainMenu)
+}
+
+// handleContinue processes the CONTINUE action
+func (h *Handler) handleContinue(senderID string) error {
+	err := h.accountHdlr.HandleSelectCurrentProfile(senderID)
 	if err != nil {
 		return err
 	}
-	return nil
+
+	if err := h.fbSvc.SendTextMessage(senderID, i18n.T(h.utils.Lang(senderID), "welcome.aboard")); err != nil {
+		return fmt.Errorf("failed to send welcome message: %w", err)
+	}
+	return h.enterState(senderID, state.StateMainMenu)
 }
 
 // handleNo processes the NO action
 func (h *Handler) handleNo(senderID string) error {
-	return h.utils.SendResponseWithQuickReplies(senderID, "No problem! You can always access your profile later by typing 'View Profile'.")
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "profile.later"))
 }
 
 // handleAboutUs shows the about us information
 func (h *Handler) handleAboutUs(senderID string) error {
-	return h.utils.SendResponseWithQuickReplies(senderID, constants.AboutUsMessage)
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "about_us"))
 }
 
 // handleTalkToHuman provides contact information for human assistance
 func (h *Handler) handleTalkToHuman(senderID string) error {
-	return h.utils.SendResponseWithQuickReplies(senderID, constants.TalkToHumanMessage)
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "talk_to_human"))
+}
+
+// menuShortcuts maps persistent menu payloads to the main menu option they open
+var menuShortcuts = map[string]string{
+	helpers.PayloadViewGrades:     "1",
+	helpers.PayloadViewFees:       "2",
+	helpers.PayloadViewBulletin:   "3",
+	helpers.PayloadViewAttendance: "4",
+	helpers.PayloadSupport:        "6",
+}
+
+// handleMenuShortcut opens a main menu option directly from the persistent menu
+// or an ice breaker, provided the user has a profile to show it for
+func (h *Handler) handleMenuShortcut(senderID, payload string) error {
+	if ok, err := h.requireActiveProfile(senderID); !ok {
+		return err
+	}
+	return h.menuHdlr.MenuHandler(senderID, menuShortcuts[payload])
+}
+
+// requireActiveProfile checks that the user is registered, active and has a
+// primary profile, replying with what to do next when they are not. On success
+// the conversation restarts from the main menu, so BACK leads there.
+func (h *Handler) requireActiveProfile(senderID string) (bool, error) {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return false, h.handleGetStarted(senderID)
+	}
+
+	lang := i18n.Of(user.Language)
+
+	if user.IsActive == false {
+		return false, h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
+	}
+
+	if link, err := h.linkRepo.GetPrimaryLink(int(user.ID)); err != nil || link == nil {
+		return false, h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
+	}
+
+	if err := h.stateManager.SetState(senderID, state.StateMainMenu); err != nil {
+		log.Printf("Error resetting state: %v", err)
+	}
+	return true, nil
 }
 
 // handleGetStarted shows the initial welcome message
 func (h *Handler) handleGetStarted(senderID string) error {
-	return h.utils.SendResponseWithQuickReplies(senderID, constants.WelcomeMessage)
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "welcome"))
 }
 
 // handleDefault provides a fallback response for unrecognized messages
@@ -80,18 +134,15 @@ func (h *Handler) handleDefault(senderID string) error {
 	if err != nil {
 		return h.sendDefaultMessage(senderID)
 	}
-	fmt.Println(user)
 	if user.IsActive == false {
-		quickReplies := helpers.GetQuickReplies(constants.AccountDeactivatedMessage)
-		return h.fbSvc.SendQuickReplies(senderID, constants.AccountDeactivatedMessage, quickReplies)
+		lang := i18n.Of(user.Language)
+		quickReplies := helpers.GetQuickReplies(lang, constants.UserStatusDeactivated)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "account.deactivated"), quickReplies)
 	}
 
 	_, err = h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err == nil {
-		if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-			log.Printf("Error resetting state: %v", err)
-		}
-		return h.menuHdlr.ShowMainMenu(senderID)
+		return h.enterState(senderID, state.StateMainMenu)
 	}
 
 	return h.sendDefaultMessage(senderID)
@@ -99,68 +150,82 @@ func (h *Handler) handleDefault(senderID string) error {
 
 // sendDefaultMessage sends the default "I'm not sure I understand" message
 func (h *Handler) sendDefaultMessage(senderID string) error {
-	defaultMsg := "I'm not sure I understand. Here are the available options:"
+	lang := h.utils.Lang(senderID)
+	defaultMsg := i18n.T(lang, "default.unknown")
 	if err := h.fbSvc.SendTextMessage(senderID, defaultMsg); err != nil {
 		log.Printf("Error sending default message: %v", err)
 	}
-	return h.utils.SendResponseWithQuickReplies(senderID, "How can I help you today?")
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "default.prompt"))
 }
 
 // handleSchoolYearSelection handles the school year selection for viewing grades
-func (h *Handler) handleSchoolYearSelection(senderID, message string, stateData map[string]any) error {
-	if yearMap, ok := stateData[state.KeySchoolYearMap].(map[string]string); ok {
-		if year, exists := yearMap[message]; exists {
-			return h.menuHdlr.HandleViewGradesByYear(senderID, year)
-		}
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID,
-			" Invalid selection. Please choose a valid school year from the options above.",
-			quickReplies,
-		)
+func (h *Handler) handleSchoolYearSelection(senderID string, in stateInput) error {
+	yearMap, err := state.Get[state.YearChoices](in.data)
+	if err != nil {
+		return err
 	}
-	if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-		log.Printf("Error resetting state: %v", err)
+
+	year, exists := yearMap[in.command]
+	if !exists {
+		return errInvalidInput
 	}
-	return h.menuHdlr.ShowMainMenu(senderID)
+	return h.menuHdlr.HandleViewGradesByYear(senderID, year)
 }
 
 // handleSubjectByYearSelection handles the school year selection for viewing subjects
-func (h *Handler) handleSubjectByYearSelection(senderID, message string, stateData map[string]any) error {
-	if yearMap, ok := stateData[state.KeySchoolYearMap].(map[string]string); ok {
-		if year, exists := yearMap[message]; exists {
-			return h.menuHdlr.HandleViewSubjectsByYear(senderID, year)
-		}
-
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID,
-			" Invalid selection. Please choose a valid school year from the options above.",
-			quickReplies,
-		)
+func (h *Handler) handleSubjectByYearSelection(senderID string, in stateInput) error {
+	yearMap, err := state.Get[state.YearChoices](in.data)
+	if err != nil {
+		return err
 	}
 
-	// If we get here, there was an issue with the state data
-	if err := h.stateManager.SetState(senderID, state.StateProfileMenu, nil); err != nil {
-		log.Printf("Error resetting state: %v", err)
+	year, exists := yearMap[in.command]
+	if !exists {
+		return errInvalidInput
 	}
-	return h.menuHdlr.ShowProfileMenu(senderID)
+	return h.menuHdlr.HandleViewSubjectsByYear(senderID, year)
 }
 
 // handleSupportTicketSelection handles the support ticket selection
-func (h *Handler) handleSupportTicketSelection(senderID, message string, stateData map[string]any) error {
-	if ticketMap, ok := stateData[state.KeyTicketMap].(map[string]string); ok {
-		if ticketID, exists := ticketMap[message]; exists {
-			return h.menuHdlr.HandleSupportTicketSelection(senderID, ticketID)
-		}
+func (h *Handler) handleSupportTicketSelection(senderID string, in stateInput) error {
+	ticketMap, err := state.Get[state.TicketChoices](in.data)
+	if err != nil {
+		return err
+	}
 
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID,
-			"Invalid selection. Please choose a valid ticket from the options above.",
-			quickReplies,
-		)
+	ticketID, exists := ticketMap[in.command]
+	if !exists {
+		return errInvalidInput
 	}
-	// If we get here, there was an issue with the state data
-	if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-		log.Printf("Error resetting state: %v", err)
+	return h.menuHdlr.HandleSupportTicketSelection(senderID, ticketID)
+}
+
+// handleEventError logs an error from handling an event and reacts to Graph API
+// failures that mean further replies to the user would fail too
+func (h *Handler) handleEventError(senderID, action string, err error) {
+	switch {
+	case errors.Is(err, facebook.ErrUserUnavailable), errors.Is(err, facebook.ErrInvalidRecipient):
+		log.Printf("Error %s: %s can no longer be messaged, clearing conversation state: %v", action, senderID, err)
+		if err := h.stateManager.ClearState(senderID); err != nil {
+			log.Printf("Error clearing state for %s: %v", senderID, err)
+		}
+	case errors.Is(err, facebook.ErrOutsideWindow):
+		log.Printf("Error %s: %s is outside the messaging window: %v", action, senderID, err)
+	case errors.Is(err, facebook.ErrRateLimited):
+		log.Printf("Error %s: still rate limited after retries for %s: %v", action, senderID, err)
+	case errors.Is(err, facebook.ErrInvalidToken):
+		log.Printf("Error %s: page access token rejected, check PAGE_ACCESS_TOKEN: %v", action, err)
+	default:
+		log.Printf("Error %s: %v", action, err)
 	}
-	return h.menuHdlr.ShowMainMenu(senderID)
+}
+
+// canRecover reports whether it is worth replying to the user again after err.
+// When the user cannot be messaged at all, falling back to another reply would
+// only fail the same way.
+func canRecover(err error) bool {
+	return !errors.Is(err, facebook.ErrUserUnavailable) &&
+		!errors.Is(err, facebook.ErrInvalidRecipient) &&
+		!errors.Is(err, facebook.ErrOutsideWindow) &&
+		!errors.Is(err, facebook.ErrInvalidToken)
 }
@@ -10,10 +10,17 @@ import (
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/cache"
 	"school-assistant-wh/internal/config"
+	"school-assistant-wh/internal/dispatcher"
 	"school-assistant-wh/internal/handlers/account"
 	"school-assistant-wh/internal/handlers/menu"
 	"school-assistant-wh/internal/handlers/utils"
+	"school-assistant-wh/internal/intent"
+	"school-assistant-wh/internal/linktoken"
+	"school-assistant-wh/internal/notify"
+	"school-assistant-wh/internal/privacy"
 	"school-assistant-wh/internal/repositories"
 	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
@@ -21,35 +28,60 @@ import (
 )
 
 type Handler struct {
-	repo           repositories.UserRepository
-	linkRepo       repositories.UserLinkRepository
-	gradeRepo      repositories.GradeRepository
-	bulletinRepo   *repositories.BulletinRepository
-	payableRepo    *repositories.StudentPayableRepository
-	paymentLogRepo *repositories.PaymentLogRepository
-	dtrRepo        *repositories.DTRRepository
-	supportRepo    *repositories.SupportRepository
-	fbSvc          *facebook.Service
-	accountHdlr    *account.AccountHandler
-	menuHdlr       *menu.MenuHandler
-	utils          *utils.ResponseUtils
-	stateManager   *state.StateManager
+	db              *gorm.DB
+	repo            repositories.UserRepository
+	linkRepo        repositories.UserLinkRepository
+	gradeRepo       repositories.GradeRepository
+	bulletinRepo    *repositories.BulletinRepository
+	payableRepo     *repositories.StudentPayableRepository
+	paymentLogRepo  *repositories.PaymentLogRepository
+	dtrRepo         *repositories.DTRRepository
+	supportRepo     *repositories.SupportRepository
+	schoolRepo      *repositories.SchoolRepository
+	studentRepo     *repositories.StudentProfileRepository
+	auditRepo       *repositories.LinkAuditRepository
+	messageRepo     *repositories.MessageRepository
+	fbSvc           *facebook.Service
+	accountHdlr     *account.AccountHandler
+	menuHdlr        *menu.MenuHandler
+	utils           *utils.ResponseUtils
+	stateManager    state.StateManager
+	dispatcher      *dispatcher.Dispatcher
+	events          cache.EventStore
+	states          map[state.State]stateHandler
+	commands        map[string]globalCommand
+	intents         *intent.Matcher
+	linkTokens      *linktoken.Signer
+	linkCfg         config.LinkConfig
+	notifier        notify.Notifier
+	deferredNotices *deferredNotices
+	exportTokens    *privacy.Signer
+	privacyCfg      config.PrivacyConfig
 }
 
-func NewHandler(db *gorm.DB, fbSvc *facebook.Service) *Handler {
+func NewHandler(db *gorm.DB, fbSvc *facebook.Service, disp *dispatcher.Dispatcher, events cache.EventStore, stateManager state.StateManager, notifier notify.Notifier, policy *access.Policy) *Handler {
 	repo := repositories.NewUserRepository(db, fbSvc)
-	linkRepo := repositories.NewUserLinkRepository(db, repositories.NewStudentProfileRepository(db))
+	studentRepo := repositories.NewStudentProfileRepository(db)
+	linkRepo := repositories.NewUserLinkRepository(db, studentRepo)
 	gradeRepo := repositories.NewGradeRepository(db)
 	bulletinRepo := repositories.NewBulletinRepository(db)
 	payableRepo := repositories.NewStudentPayableRepository(db)
 	paymentLogRepo := repositories.NewPaymentLogRepository(db)
 	dtrRepo := repositories.NewDTRRepository(db)
 	supportRepo := repositories.NewSupportRepository(db)
-	stateManager := state.NewStateManager()
+
+	linkCfg := config.LoadLinkConfig()
+	if linkCfg.TokenSecret == "" {
+		log.Println("Warning: LINK_TOKEN_SECRET is not set, m.me link tokens will be rejected")
+	}
+	privacyCfg := config.LoadPrivacyConfig()
+	if privacyCfg.ExportSecret == "" || privacyCfg.PublicBaseURL == "" {
+		log.Println("Warning: PRIVACY_EXPORT_SECRET or PUBLIC_BASE_URL is not set, data exports are disabled")
+	}
 
 	// Create account handler with state manager
-	accountHdlr := account.NewAccountHandler(*repo, *linkRepo, fbSvc, stateManager)
-	menuHdlr := menu.NewMenuHandler(*repo, *linkRepo, *gradeRepo, *bulletinRepo, *payableRepo, *paymentLogRepo, *dtrRepo, *supportRepo, fbSvc, stateManager)
+	accountHdlr := account.NewAccountHandler(*repo, *linkRepo, fbSvc, stateManager, linkCfg.SaIDTTL)
+	menuHdlr := menu.NewMenuHandler(*repo, *linkRepo, *gradeRepo, *bulletinRepo, *payableRepo, *paymentLogRepo, *dtrRepo, *supportRepo, fbSvc, stateManager, policy)
 
 	// Preload active users into cache
 	if err := repo.PreloadActiveUsers(); err != nil {
@@ -57,24 +89,48 @@ func NewHandler(db *gorm.DB, fbSvc *facebook.Service) *Handler {
 	}
 
 	// Start state cleanup goroutine
+	stateTTL := config.LoadStateConfig().TTL
 	go func() {
 		ticker := time.NewTicker(30 * time.Minute)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			stateManager.CleanupInactive(24 * time.Hour)
+			if err := stateManager.CleanupInactive(stateTTL); err != nil {
+				log.Printf("Error cleaning up states: %v", err)
+			}
 		}
 	}()
 
-	return &Handler{
+	h := &Handler{
+		db:           db,
 		repo:         *repo,
 		linkRepo:     *linkRepo,
+		schoolRepo:   repositories.NewSchoolRepository(db),
+		studentRepo:  studentRepo,
+		auditRepo:    repositories.NewLinkAuditRepository(db),
+		messageRepo:  repositories.NewMessageRepository(db),
+		supportRepo:  supportRepo,
 		fbSvc:        fbSvc,
 		accountHdlr:  accountHdlr,
 		menuHdlr:     menuHdlr,
 		utils:        utils.NewResponseUtils(*repo, *linkRepo, fbSvc),
 		stateManager: stateManager,
+		dispatcher:   disp,
+		events:       events,
+		states:       make(map[state.State]stateHandler),
+		intents:      intent.NewMatcher(),
+		linkTokens:   linktoken.NewSigner(linkCfg.TokenSecret),
+		linkCfg:      linkCfg,
+		notifier:     notifier,
+
+		deferredNotices: newDeferredNotices(),
+		exportTokens:    privacy.NewSigner(privacyCfg.ExportSecret),
+		privacyCfg:      privacyCfg,
 	}
+	h.registerStates()
+	h.registerCommands()
+
+	return h
 }
 
 func (h *Handler) VerifyWebhook(c *gin.Context) {
@@ -87,19 +143,41 @@ func (h *Handler) VerifyWebhook(c *gin.Context) {
 	c.JSON(http.StatusForbidden, gin.H{"error": "Invalid verification token"})
 }
 
-func (h *Handler) SetupMessengerProfile() error {
-	if err := h.fbSvc.SetGetStartedButton("GET_STARTED"); err != nil {
-		return err
+// SetupMessengerProfile applies the configured Get Started button, greetings,
+// persistent menu and ice breakers, returning the fields that had to change
+func (h *Handler) SetupMessengerProfile() ([]string, error) {
+	cfg, err := config.LoadMessengerProfileConfig()
+	if err != nil {
+		return nil, err
 	}
 
-	greeting := "Hello! I'm your school assistant. How can I help yoqu today?"
-	if err := h.fbSvc.SetGreetingText(greeting); err != nil {
-		return err
+	profile, err := facebook.ProfileFromConfig(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("invalid messenger profile: %w", err)
+	}
+
+	return h.fbSvc.ApplyMessengerProfile(profile)
+}
+
+// ApplyMessengerProfile re-applies the Messenger profile definition so menu
+// changes take effect without a restart
+func (h *Handler) ApplyMessengerProfile(c *gin.Context) {
+	updated, err := h.SetupMessengerProfile()
+	if err != nil {
+		log.Printf("Error applying messenger profile: %v", err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
 	}
 
-	return nil
+	if updated == nil {
+		updated = []string{}
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "updated": updated})
 }
 
+// HandleWebhook acknowledges a webhook delivery right away and queues each
+// messaging event for processing. Events from the same sender are processed in
+// order; events from different senders are processed in parallel.
 func (h *Handler) HandleWebhook(c *gin.Context) {
 	var request facebook.WebhookRequest
 
@@ -113,243 +191,178 @@ func (h *Handler) HandleWebhook(c *gin.Context) {
 		return
 	}
 
+	rejected := 0
 	for _, entry := range request.Entry {
 		for _, messaging := range entry.Messaging {
-			senderID := messaging.Sender.ID
-
-			if messaging.Postback != nil && messaging.Postback.Payload != "" {
-				if err := h.handleMessagePayload(senderID, messaging.Postback.Payload); err != nil {
-					log.Printf("Error handling message payload: %v", err)
+			event := messaging
+			key := event.DedupKey()
+			if key != "" {
+				seen, err := h.events.MarkSeen(key)
+				if err != nil {
+					log.Printf("Error checking event %s: %v", key, err)
+				} else if seen {
+					log.Printf("Skipping redelivered event %s", key)
+					continue
 				}
 			}
 
-			if messaging.Message != nil && messaging.Message.Text != "" {
-				message := strings.TrimSpace(strings.ToUpper(messaging.Message.Text))
-				currentState, stateData := h.stateManager.GetState(senderID)
-				if currentState != "" && currentState != state.StateInitial && !helpers.IsQuickReplyPayload(message) {
-					if err := h.handleStateMessage(senderID, message, currentState, stateData); err != nil {
-						log.Printf("Error handling state message: %v", err)
-					} else {
-						return
+			if err := h.dispatcher.Submit(event.Sender.ID, func() { h.processEvent(event) }); err != nil {
+				log.Printf("Error queueing event from %s: %v", event.Sender.ID, err)
+				rejected++
+				// The redelivery of an event we could not queue must not be skipped
+				if key != "" {
+					if err := h.events.Forget(key); err != nil {
+						log.Printf("Error forgetting event %s: %v", key, err)
 					}
 				}
-
-				if err := h.handleMessage(senderID, message); err != nil {
-					log.Printf("Error handling message: %v", err)
-				}
 			}
 		}
 	}
 
+	// Let Facebook redeliver the batch later instead of silently dropping events
+	if rejected > 0 {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"status": "busy"})
+		return
+	}
+
 	c.JSON(http.StatusOK, gin.H{"status": "ok"})
 }
 
-func (h *Handler) handleMessagePayload(senderID string, payload string) error {
-	switch strings.ToUpper(payload) {
-	case "GET_STARTED":
-		return h.handleGetStarted(senderID)
-	}
-
-	return nil
+// QueueStats reports the webhook processing queue metrics
+func (h *Handler) QueueStats(c *gin.Context) {
+	c.JSON(http.StatusOK, h.dispatcher.Stats())
 }
 
-func (h *Handler) handleStateMessage(senderID, message string, currentState state.State, stateData map[string]any) error {
-	switch currentState {
-	case state.StateProfileSwitch:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateInitial, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.accountHdlr.HandleViewProfile(senderID)
-		}
-		return h.handleProfileSelection(senderID, message, stateData)
+// processEvent handles a single messaging event on a dispatcher worker
+func (h *Handler) processEvent(event facebook.MessagingEvent) {
+	senderID := event.Sender.ID
 
-	case state.StateProfileView:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateInitial, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.handleGetStarted(senderID)
-		}
-		return h.handleProfileSelection(senderID, message, stateData)
-	case state.StateMainMenu:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
+	// Anything the user sends opens a new messaging window for deferred notices
+	if event.Message != nil || event.Postback != nil || event.Referral != nil {
+		h.deferredNotices.resume(senderID)
+	}
 
-			return h.menuHdlr.ShowMainMenu(senderID)
-		}
-		return h.menuHdlr.MenuHandler(senderID, message)
-	case state.StateViewGrades:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
+	switch {
+	case event.Message != nil:
+		h.processMessage(senderID, event.Message)
+	case event.Postback != nil && event.Postback.Payload != "":
+		h.withTyping(senderID, func() {
+			if err := h.handleMessagePayload(senderID, event.Postback.Payload, event.Postback.Referral); err != nil {
+				h.handleEventError(senderID, "handling message payload", err)
 			}
-			return h.menuHdlr.ShowMainMenu(senderID)
-		}
-		return h.handleSchoolYearSelection(senderID, message, stateData)
-	case state.StateViewGradesDetails:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateViewGrades, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
+		})
+	case event.Referral != nil:
+		// Sent when a user already talking to the page opens an m.me link
+		log.Printf("Referral from %s: ref=%q source=%q", senderID, event.Referral.Ref, event.Referral.Source)
+		h.withTyping(senderID, func() {
+			if _, err := h.handleReferral(senderID, event.Referral.Ref); err != nil {
+				h.handleEventError(senderID, "handling referral", err)
 			}
-			return h.menuHdlr.HandleViewGrades(senderID)
-		}
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID,
-			"Invalid selection. Go back to view grades by School Year.",
-			quickReplies,
-		)
-
-	case state.StateViewBulletin:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
-		}
+		})
+	case event.Optin != nil:
+		log.Printf("Opt-in from %s: type=%q ref=%q", senderID, event.Optin.Type, event.Optin.Ref)
+	case event.Delivery != nil, event.Read != nil:
+		// Delivery and read receipts need no reply
+	}
+}
 
-		if message == "VIEW MORE" {
-			if p, ok := stateData[state.KeyPaginationPage].(int); ok {
-				nextPageNum := p + 1
-				return h.menuHdlr.HandleViewBulletin(senderID, nextPageNum)
-			}
-		}
-		quickReplies := helpers.GetViewMoreReplies()
-		return h.fbSvc.SendQuickReplies(senderID,
-			" Invalid selection. Go back to main menu or view more.",
-			quickReplies,
-		)
-
-	case state.StateViewPayables:
-		switch message {
-		case "BACK":
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
-		case "PAYMENT LOGS":
-			return h.menuHdlr.HandleViewPaymentLogs(senderID)
-		default:
-			quickReplies := helpers.GetPaymentReplies()
-			return h.fbSvc.SendQuickReplies(senderID,
-				"Invalid selection. Go back to main menu or view payment logs.",
-				quickReplies,
-			)
-		}
-	case state.StateViewDTR:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
-		}
-		if message == "VIEW MORE" {
-			if p, ok := stateData[state.KeyPaginationPage].(int); ok {
-				nextPageNum := p + 1
-				return h.menuHdlr.HandleViewDTR(senderID, nextPageNum)
-			}
-		}
-		quickReplies := helpers.GetViewMoreReplies()
-		return h.fbSvc.SendQuickReplies(senderID,
-			" Invalid selection. Go back to main menu or view more.",
-			quickReplies,
-		)
-	case state.StateProfileMenu:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
-		}
-		return h.menuHdlr.HandleProfileMenuSelection(senderID, message)
-	case state.StateConfirmProfileSwitch:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateProfileMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
+// processMessage routes a message by its quick reply payload when the user tapped
+// one, and by the typed text otherwise
+func (h *Handler) processMessage(senderID string, msg *facebook.IncomingMessage) {
+	// Echoes are copies of messages the page itself sent
+	if msg.IsEcho {
+		return
+	}
+
+	var command string
+	switch {
+	case msg.QuickReply != nil && msg.QuickReply.Payload != "":
+		command = helpers.CommandFromPayload(msg.QuickReply.Payload)
+	case msg.Text != "":
+		command = helpers.CommandFromText(msg.Text)
+	default:
+		log.Printf("Ignoring message %s from %s with %d attachment(s) and no text", msg.Mid, senderID, len(msg.Attachments))
+		return
+	}
+
+	h.withTyping(senderID, func() {
+		// Commands like MENU, HELP and CANCEL must work even in states that
+		// treat any other text as input
+		if handled, err := h.handleGlobalCommand(senderID, command); handled {
+			if err != nil {
+				h.handleEventError(senderID, "handling global command", err)
 			}
-			return h.menuHdlr.ShowProfileMenu(senderID)
-		}
-		if message == "PROCEED" {
-			return h.accountHdlr.HandleSwitchProfile(senderID)
+			return
 		}
-		quickReplies := helpers.GetConfirmProfileSwitch()
-		return h.fbSvc.SendQuickReplies(senderID,
-			"Invalid selection. Go back to profile menu or proceed.",
-			quickReplies,
-		)
-	case state.StateViewSubjects:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateProfileMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
+
+		currentState, stateData := h.stateManager.GetState(senderID)
+		if def, inFlow := h.states[currentState]; inFlow && !def.allows(command) {
+			err := h.handleStateMessage(senderID, currentState, stateInput{
+				command: command,
+				text:    strings.TrimSpace(msg.Text),
+				data:    stateData,
+			})
+			if err == nil {
+				return
 			}
-			return h.menuHdlr.ShowProfileMenu(senderID)
-		}
-		return h.handleSubjectByYearSelection(senderID, message, stateData)
-	case state.StateSelectSubject:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateProfileMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
+			h.handleEventError(senderID, "handling state message", err)
+			if !canRecover(err) {
+				return
 			}
-			return h.menuHdlr.ShowProfileMenu(senderID)
 		}
-		return h.handleSubjectByYearSelection(senderID, message, stateData)
-	case state.StateAskSupport:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
-		}
-		if message == "VIEW TICKETS" {
-			return h.menuHdlr.ListSupportTickets(senderID)
+
+		if err := h.handleMessage(senderID, command); err != nil {
+			h.handleEventError(senderID, "handling message", err)
 		}
-		return h.menuHdlr.AddSupportMessage(senderID, message, "")
-	case state.StateViewTickets:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
+	})
+}
+
+// handleMessagePayload handles a postback. A Get Started postback from a user who
+// opened an m.me link carries the link's referral, which replaces the welcome.
+func (h *Handler) handleMessagePayload(senderID string, payload string, referral *facebook.Referral) error {
+	command := helpers.CommandFromPayload(payload)
+	if command == helpers.PayloadGetStarted && referral != nil && referral.Ref != "" {
+		log.Printf("Get Started from %s with referral ref=%q source=%q", senderID, referral.Ref, referral.Source)
+		if handled, err := h.handleReferral(senderID, referral.Ref); handled {
+			return err
 		}
-		return h.menuHdlr.ListSupportTickets(senderID)
-	case state.StateSelectSupportTicket:
-		if message == "BACK" {
-			if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-				log.Printf("Error resetting state: %v", err)
-			}
-			return h.menuHdlr.ShowMainMenu(senderID)
+	}
+
+	if bulletinID, ok := helpers.ParseBulletinPayload(command); ok {
+		if ok, err := h.menuHdlr.RequireFeature(senderID, access.Bulletin); !ok {
+			return err
 		}
-		return h.handleSupportTicketSelection(senderID, message, stateData)
+		return h.menuHdlr.HandleBulletinDetails(senderID, bulletinID)
+	}
+
+	switch command {
+	case helpers.PayloadGetStarted:
+		return h.handleGetStarted(senderID)
 	default:
-		return h.handleDefault(senderID)
+		// Persistent menu and ice breaker postbacks carry the same payloads as quick replies
+		return h.handleMessage(senderID, command)
 	}
 }
 
 func (h *Handler) handleMessage(senderID string, message string) error {
-	switch {
-	case message == "REGISTER":
+	if handled, err := h.handleGlobalCommand(senderID, message); handled {
+		return err
+	}
+
+	switch message {
+	case helpers.PayloadRegister:
 		return h.accountHdlr.HandleRegistration(senderID)
-	case message == "MENU":
-		if err := h.stateManager.SetState(senderID, state.StateMainMenu, nil); err != nil {
-			log.Printf("Error resetting state: %v", err)
-		}
-		return h.menuHdlr.ShowMainMenu(senderID)
-	case message == "MY SA-ID":
-		return h.accountHdlr.HandleViewSaID(senderID)
-	case message == "VIEW PROFILE":
+	case helpers.PayloadViewGrades, helpers.PayloadViewFees, helpers.PayloadViewBulletin,
+		helpers.PayloadViewAttendance, helpers.PayloadSupport:
+		return h.handleMenuShortcut(senderID, message)
+	case helpers.PayloadViewProfile:
 		return h.accountHdlr.HandleViewProfile(senderID)
-	case message == "SWITCH PROFILE":
-		return h.accountHdlr.HandleSwitchProfile(senderID)
-	case message == "CONTINUE":
+	case helpers.PayloadContinue:
 		return h.handleContinue(senderID)
-	case message == "NO":
+	case helpers.PayloadNo:
 		return h.handleNo(senderID)
-	case message == "ABOUT US":
+	case helpers.PayloadAboutUs:
 		return h.handleAboutUs(senderID)
-	case message == "TALK TO HUMAN":
-		return h.handleTalkToHuman(senderID)
 	default:
 		exists, err := h.repo.UserExists(senderID)
 		if err != nil {
@@ -359,6 +372,10 @@ func (h *Handler) handleMessage(senderID string, message string) error {
 			return h.handleGetStarted(senderID)
 		}
 
+		if handled, err := h.handleIntent(senderID, message); handled {
+			return err
+		}
+
 		return h.handleDefault(senderID)
 	}
 }
@@ -0,0 +1,412 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/cache"
+	"school-assistant-wh/internal/config"
+	"school-assistant-wh/internal/dispatcher"
+	"school-assistant-wh/internal/fakegraph"
+	"school-assistant-wh/internal/notify"
+	"school-assistant-wh/internal/services/facebook"
+	"school-assistant-wh/internal/state"
+)
+
+const testAppSecret = "app-secret"
+
+// graphRecorder counts the Send API requests reaching the fake, including the
+// ones it fails
+type graphRecorder struct {
+	next http.Handler
+
+	mu    sync.Mutex
+	sends map[string]int
+}
+
+func (g *graphRecorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if strings.HasSuffix(r.URL.Path, "/me/messages") {
+		body, _ := io.ReadAll(r.Body)
+		r.Body = io.NopCloser(strings.NewReader(string(body)))
+		g.mu.Lock()
+		g.sends[string(body)]++
+		g.mu.Unlock()
+	}
+	g.next.ServeHTTP(w, r)
+}
+
+// attempts returns how many times each distinct send was attempted
+func (g *graphRecorder) attempts() []int {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	var counts []int
+	for _, n := range g.sends {
+		counts = append(counts, n)
+	}
+	return counts
+}
+
+type webhookTest struct {
+	h        *Handler
+	db       *fakeDB
+	graph    *fakegraph.Server
+	recorder *graphRecorder
+	disp     *dispatcher.Dispatcher
+	webhook  *httptest.Server
+}
+
+const testMaxRetries = 2
+
+// newWebhookTest wires a Handler over the tables to a fake Graph API, behind the
+// same signature check as the real webhook route
+func newWebhookTest(t *testing.T, pageAccessToken string, tables ...fakeTable) *webhookTest {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	db, fake := newFakeDB(t, tables...)
+
+	wt := &webhookTest{db: fake, disp: dispatcher.New(2, 16)}
+	t.Cleanup(func() { wt.disp.Shutdown(context.Background()) })
+	wt.recorder = &graphRecorder{sends: make(map[string]int)}
+	graphSrv := httptest.NewServer(wt.recorder)
+	t.Cleanup(graphSrv.Close)
+
+	fbSvc := facebook.NewService(config.FacebookConfig{
+		PageAccessToken: pageAccessToken,
+		GraphBaseURL:    graphSrv.URL,
+		GraphAPIVersion: "v18.0",
+		HTTPTimeout:     5 * time.Second,
+		MaxRetries:      testMaxRetries,
+	})
+	policy, err := access.NewPolicy(config.DefaultRolePolicy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wt.h = NewHandler(db, fbSvc, wt.disp, cache.NewEventCache(time.Hour), state.NewMemoryStateManager(), notify.LogNotifier{}, policy)
+
+	r := gin.New()
+	r.POST("/webhook", VerifySignatureMiddleware(testAppSecret), wt.h.HandleWebhook)
+	wt.webhook = httptest.NewServer(r)
+	t.Cleanup(wt.webhook.Close)
+
+	wt.graph = fakegraph.New(fakegraph.Options{WebhookURL: wt.webhook.URL + "/webhook", AppSecret: testAppSecret})
+	wt.recorder.next = wt.graph
+	return wt
+}
+
+// deliver sends the event and waits until the bot has finished with it
+func (wt *webhookTest) deliver(t *testing.T, in fakegraph.Inbound) {
+	t.Helper()
+	if err := wt.graph.Deliver(in); err != nil {
+		t.Fatalf("Deliver: %v", err)
+	}
+	deadline := time.Now().Add(30 * time.Second)
+	for stats := wt.disp.Stats(); stats.Processed < stats.Accepted; stats = wt.disp.Stats() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for the event to be processed")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// messages returns the texts of the messages the user received
+func (wt *webhookTest) messages(psid string) []string {
+	var texts []string
+	for _, record := range wt.graph.Transcript(psid) {
+		if record.Message == nil {
+			continue
+		}
+		var msg struct {
+			Text string `json:"text"`
+		}
+		json.Unmarshal(record.Message, &msg)
+		texts = append(texts, msg.Text)
+	}
+	return texts
+}
+
+func TestWebhookReplies(t *testing.T) {
+	wt := newWebhookTest(t, "page-token")
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "hello"})
+
+	if len(wt.messages("psid-1")) == 0 {
+		t.Fatal("no reply was sent")
+	}
+	for _, n := range wt.recorder.attempts() {
+		if n != 1 {
+			t.Fatalf("a send was attempted %d times, want 1", n)
+		}
+	}
+}
+
+func TestWebhookRetriesServerErrors(t *testing.T) {
+	wt := newWebhookTest(t, "page-token")
+	fault, _ := fakegraph.FaultKind("server_error")
+	fault.Path, fault.Times = "/me/messages", 1
+	wt.graph.InjectFault(fault)
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "hello"})
+
+	if len(wt.messages("psid-1")) == 0 {
+		t.Fatal("no reply was sent after the server error")
+	}
+	retried := false
+	for _, n := range wt.recorder.attempts() {
+		if n == 2 {
+			retried = true
+		}
+	}
+	if !retried {
+		t.Fatalf("the failed send was not retried: attempts %v", wt.recorder.attempts())
+	}
+}
+
+func TestWebhookGivesUpWhenRateLimited(t *testing.T) {
+	wt := newWebhookTest(t, "page-token")
+	fault, _ := fakegraph.FaultKind("rate_limit")
+	fault.Path = "/me/messages"
+	wt.graph.InjectFault(fault)
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "hello"})
+
+	if msgs := wt.messages("psid-1"); len(msgs) != 0 {
+		t.Fatalf("messages were delivered while rate limited: %q", msgs)
+	}
+	attempts := wt.recorder.attempts()
+	if len(attempts) == 0 {
+		t.Fatal("nothing was sent")
+	}
+	for _, n := range attempts {
+		if n != testMaxRetries+1 {
+			t.Fatalf("a rate limited send was attempted %d times, want %d", n, testMaxRetries+1)
+		}
+	}
+}
+
+func TestWebhookDoesNotRetryInvalidToken(t *testing.T) {
+	wt := newWebhookTest(t, "")
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "hello"})
+
+	if msgs := wt.messages("psid-1"); len(msgs) != 0 {
+		t.Fatalf("messages were delivered without a token: %q", msgs)
+	}
+	attempts := wt.recorder.attempts()
+	if len(attempts) == 0 {
+		t.Fatal("nothing was sent")
+	}
+	for _, n := range attempts {
+		if n != 1 {
+			t.Fatalf("a send rejected for its token was attempted %d times, want 1", n)
+		}
+	}
+}
+
+func TestMySaIDSendsQRCode(t *testing.T) {
+	users := fakeTable{
+		name:    "school_messenger_users",
+		columns: []string{"ID", "IsActive", "Code", "PSID", "FBName", "Language"},
+		rows:    [][]driver.Value{{int64(1), true, "SA-ABC123", "psid-1", "Test User", "en"}},
+	}
+	wt := newWebhookTest(t, "page-token", users)
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "MY SA-ID"})
+
+	var image bool
+	for _, record := range wt.graph.Transcript("psid-1") {
+		var msg struct {
+			Attachment struct {
+				Type    string `json:"type"`
+				Payload struct {
+					AttachmentID string `json:"attachment_id"`
+				} `json:"payload"`
+			} `json:"attachment"`
+		}
+		json.Unmarshal(record.Message, &msg)
+		if msg.Attachment.Type == "image" && msg.Attachment.Payload.AttachmentID != "" {
+			image = true
+		}
+	}
+	if !image {
+		t.Fatal("the SA-ID QR code image was not sent")
+	}
+
+	msgs := wt.messages("psid-1")
+	if len(msgs) == 0 || !strings.HasPrefix(msgs[len(msgs)-1], "SA-ABC123") {
+		t.Fatalf("messages = %q, want the SA-ID last", msgs)
+	}
+}
+
+func TestDeleteAccountNeedsTwoConfirmations(t *testing.T) {
+	users := fakeTable{
+		name:    "school_messenger_users",
+		columns: []string{"ID", "IsActive", "Code", "PSID", "FBName", "Language"},
+		rows:    [][]driver.Value{{int64(1), true, "SA-ABC123", "psid-1", "Test User", "en"}},
+	}
+	wt := newWebhookTest(t, "page-token", users)
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "MY DATA"})
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Delete Account"})
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Yes, Delete"})
+	if wt.db.ran("DELETE FROM") {
+		t.Fatal("links were removed before the second confirmation")
+	}
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Delete Forever"})
+	for _, statement := range []string{"DELETE FROM `gk_miniapps`.`school_link_user`", "school_messenger_code_revocations", "UPDATE `school_messenger_users`", "school_messenger_link_audit"} {
+		if !wt.db.ran(statement) {
+			t.Errorf("no statement mentioning %s was run", statement)
+		}
+	}
+}
+
+func TestPayloadsRouteLikeTheirTitles(t *testing.T) {
+	users := fakeTable{
+		name:    "school_messenger_users",
+		columns: []string{"ID", "IsActive", "Code", "PSID", "FBName", "Language"},
+		rows:    [][]driver.Value{{int64(1), true, "SA-ABC123", "psid-1", "Test User", "en"}},
+	}
+	wt := newWebhookTest(t, "page-token", users)
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-text", Text: "My SA-ID"})
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-postback", Postback: "MY_SA_ID"})
+	// The payload wins over the text shown on the tapped quick reply
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-quick-reply", Text: "something else", QuickReply: "MY_SA_ID"})
+
+	want := wt.messages("psid-text")
+	if len(want) == 0 || !strings.HasPrefix(want[len(want)-1], "SA-ABC123") {
+		t.Fatalf("typed title replies = %q, want the SA-ID", want)
+	}
+	for _, psid := range []string{"psid-postback", "psid-quick-reply"} {
+		if got := wt.messages(psid); strings.Join(got, "\n") != strings.Join(want, "\n") {
+			t.Errorf("%s replies = %q, want %q", psid, got, want)
+		}
+	}
+}
+
+func TestTypingIndicatorsWrapTheReply(t *testing.T) {
+	wt := newWebhookTest(t, "page-token")
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "hello"})
+
+	var got []string
+	for _, record := range wt.graph.Transcript("psid-1") {
+		if record.SenderAction != "" {
+			got = append(got, record.SenderAction)
+		} else if len(got) == 0 || got[len(got)-1] != "message" {
+			got = append(got, "message")
+		}
+	}
+
+	want := []string{string(facebook.MarkSeen), string(facebook.TypingOn), "message", string(facebook.TypingOff)}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("sent %q, want %q", got, want)
+	}
+}
+
+// linkedUserTables serves psid-1 as an active user whose primary profile is
+// student S1 of school sch1
+func linkedUserTables() []fakeTable {
+	return []fakeTable{
+		{
+			name:    "school_messenger_users",
+			columns: []string{"ID", "IsActive", "Code", "PSID", "FBName", "Language"},
+			rows:    [][]driver.Value{{int64(1), true, "SA-ABC123", "psid-1", "Test User", "en"}},
+		},
+		{
+			name:    "school_link_user",
+			columns: []string{"ID", "IsActive", "UserID", "StudentID", "SchoolID", "Role", "IsNewlyLink", "IsPrimary"},
+			rows:    [][]driver.Value{{int64(1), true, int64(1), "S1", "sch1", "guardian", false, true}},
+		},
+		{
+			name:    "school_sch1_students",
+			columns: []string{"ID", "StudentID", "FirstName", "LastName", "Status"},
+			rows:    [][]driver.Value{{int64(1), "S1", "Juan", "Dela Cruz", "Active"}},
+		},
+		{
+			name:    "`gk_miniapps`.`school`",
+			columns: []string{"ID", "SchoolID", "SchoolName"},
+			rows:    [][]driver.Value{{int64(1), "sch1", "Test School"}},
+		},
+	}
+}
+
+func TestBackWithoutHistoryShowsParentScreen(t *testing.T) {
+	wt := newWebhookTest(t, "page-token", linkedUserTables()...)
+	// A state entered without recording any navigation history
+	if err := wt.h.stateManager.SetState("psid-1", state.StateViewBulletin); err != nil {
+		t.Fatal(err)
+	}
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Back"})
+
+	if current, _ := wt.h.stateManager.GetState("psid-1"); current != state.StateMainMenu {
+		t.Fatalf("state after BACK = %s, want %s", current, state.StateMainMenu)
+	}
+	msgs := wt.messages("psid-1")
+	if len(msgs) == 0 || !strings.Contains(msgs[len(msgs)-1], "Juan Dela Cruz") {
+		t.Fatalf("messages = %q, want the main menu for the primary profile", msgs)
+	}
+}
+
+func TestBackRestoresListPage(t *testing.T) {
+	wt := newWebhookTest(t, "page-token", linkedUserTables()...)
+	history := []state.Screen{
+		{State: state.StateMainMenu},
+		{State: state.StateViewBulletin, Params: map[string]string{state.ParamPage: "3"}},
+		{State: state.StateViewBulletinDetails, Params: map[string]string{state.ParamID: "42"}},
+	}
+	for _, screen := range history {
+		if err := state.EnterScreen(wt.h.stateManager, "psid-1", screen); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Back"})
+
+	// The third page of bulletins starts after two pages of three
+	if !wt.db.ranWith("OFFSET", int64(6)) {
+		t.Fatal("BACK did not reload the bulletin list at the recorded page")
+	}
+}
+
+func TestGlobalCommandsLeaveAskSupport(t *testing.T) {
+	tests := []struct {
+		text  string
+		want  state.State
+		saved bool
+	}{
+		{"Menu", state.StateMainMenu, false},
+		{"Help", state.StateAskSupport, false},
+		{"Cancel", state.StateInitial, false},
+		// Anything else is the inquiry, which ends the flow once sent
+		{"My child's ID card is lost", state.StateInitial, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			wt := newWebhookTest(t, "page-token", linkedUserTables()...)
+			for _, s := range []state.State{state.StateMainMenu, state.StateAskSupport} {
+				if err := state.EnterScreen(wt.h.stateManager, "psid-1", state.Screen{State: s}); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: tt.text})
+
+			if saved := wt.db.ran("support_conversation"); saved != tt.saved {
+				t.Fatalf("support message saved = %v for %q, want %v", saved, tt.text, tt.saved)
+			}
+			if current, _ := wt.h.stateManager.GetState("psid-1"); current != tt.want {
+				t.Fatalf("state after %q = %s, want %s", tt.text, current, tt.want)
+			}
+		})
+	}
+}
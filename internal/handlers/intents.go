@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"log"
+	"time"
+
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/intent"
+)
+
+// intentOptions maps intents without slot handling to their main menu option
+var intentOptions = map[intent.Name]string{
+	intent.Grades:     "1",
+	intent.Fees:       "2",
+	intent.Bulletin:   "3",
+	intent.Attendance: "4",
+	intent.Profile:    "5",
+	intent.Support:    "6",
+}
+
+// intentFeatures maps intents that open a screen directly, without going through
+// the main menu, to the feature they need
+var intentFeatures = map[intent.Name]access.Feature{
+	intent.Grades:      access.Grades,
+	intent.Subjects:    access.Grades,
+	intent.PaymentLogs: access.Fees,
+	intent.Attendance:  access.Attendance,
+}
+
+// handleIntent recognizes free text such as "grades 2023-2024" or "how much do
+// I owe" and opens the matching screen. It reports false when nothing matched.
+func (h *Handler) handleIntent(senderID, text string) (bool, error) {
+	result, ok := h.intents.Match(text)
+	if !ok {
+		return false, nil
+	}
+	log.Printf("Matched intent %s (%s, score %.2f) for %s", result.Intent, result.Language, result.Score, senderID)
+
+	if ok, err := h.requireActiveProfile(senderID); !ok {
+		return true, err
+	}
+	if feature, ok := intentFeatures[result.Intent]; ok {
+		if ok, err := h.menuHdlr.RequireFeature(senderID, feature); !ok {
+			return true, err
+		}
+	}
+
+	switch result.Intent {
+	case intent.Grades:
+		if result.SchoolYear != "" {
+			return true, h.menuHdlr.HandleViewGradesByYear(senderID, result.SchoolYear)
+		}
+	case intent.Subjects:
+		if result.SchoolYear != "" {
+			return true, h.menuHdlr.HandleViewSubjectsByYear(senderID, result.SchoolYear)
+		}
+		return true, h.menuHdlr.HandleViewSubjects(senderID)
+	case intent.PaymentLogs:
+		return true, h.menuHdlr.HandleViewPaymentLogs(senderID)
+	case intent.Attendance:
+		if period, ok := result.Period(time.Now()); ok {
+			return true, h.menuHdlr.HandleViewDTRForMonth(senderID, period, 1)
+		}
+	}
+	return true, h.menuHdlr.MenuHandler(senderID, intentOptions[result.Intent])
+}
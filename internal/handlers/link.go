@@ -0,0 +1,526 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/notify"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// Self-service linking: the user picks a school, says whether they are the
+// student or a parent/guardian, enters a student ID and proves they know the
+// student with the birthdate on file and a one-time code sent to the student's
+// mobile number or email. Sponsors are linked by the school.
+
+const (
+	// linkCodeDigits is the length of the one-time code
+	linkCodeDigits = 6
+	// maxListedSchools keeps the school list within a single message; other
+	// schools can still be picked by typing their ID
+	maxListedSchools = 20
+)
+
+// birthdateLayouts are the formats accepted for birthdates, both typed by users
+// and stored by schools
+var birthdateLayouts = []string{
+	"2006-01-02",
+	"01/02/2006",
+	"1/2/2006",
+	"January 2, 2006",
+	"Jan 2, 2006",
+	"January 2 2006",
+	"Jan 2 2006",
+}
+
+// handleLinkStudent starts the link flow, registering the user first if needed.
+// Users who came from a school's m.me link skip picking the school.
+func (h *Handler) handleLinkStudent(senderID string) error {
+	user, err := h.repo.RegisterUser(senderID)
+	if err != nil {
+		log.Printf("Error registering user: %v", err)
+		return h.fbSvc.SendTextMessage(senderID, i18n.T(h.utils.Lang(senderID), "registration.failed"))
+	}
+	lang := i18n.Of(user.Language)
+
+	locked, err := h.linkLocked(int(user.ID))
+	if err != nil {
+		return err
+	}
+	if locked {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "link.locked"))
+	}
+
+	h.auditLink(senderID, user, state.LinkRequest{}, models.LinkEventStarted, "")
+
+	_, data := h.stateManager.GetState(senderID)
+	if school, err := state.Get[state.ReferredSchool](data); err == nil {
+		return h.askLinkRole(senderID, lang, state.LinkRequest{SchoolID: school.SchoolID, SchoolName: school.SchoolName})
+	}
+	return h.showLinkSchools(senderID)
+}
+
+// showLinkSchools lists the schools the user can link a student from
+func (h *Handler) showLinkSchools(senderID string) error {
+	lang := h.utils.Lang(senderID)
+
+	schools, err := h.schoolRepo.ListActiveSchools()
+	if err != nil {
+		return fmt.Errorf("failed to list schools: %w", err)
+	}
+	if len(schools) == 0 {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "link.no_schools"))
+	}
+	if len(schools) > maxListedSchools {
+		schools = schools[:maxListedSchools]
+	}
+
+	choices := make(state.SchoolChoices)
+	var sb strings.Builder
+	for i, school := range schools {
+		choices[strconv.Itoa(i+1)] = state.SchoolChoice{SchoolID: school.SchoolID, SchoolName: school.SchoolName}
+		sb.WriteString(i18n.T(lang, "link.school_item", i+1, school.SchoolName, school.SchoolID))
+	}
+
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateLinkSelectSchool}, choices); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.select_school", sb.String()), helpers.GetBack(lang))
+}
+
+// handleLinkSchoolSelection accepts a number from the school list or a typed
+// school ID
+func (h *Handler) handleLinkSchoolSelection(senderID string, in stateInput) error {
+	choices, err := state.Get[state.SchoolChoices](in.data)
+	if err != nil {
+		return err
+	}
+
+	choice, ok := choices[in.command]
+	if !ok {
+		if in.text == "" {
+			return errInvalidInput
+		}
+		school, err := h.schoolRepo.GetSchool(in.text)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errInvalidInput
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get school: %w", err)
+		}
+		if !strings.EqualFold(school.Status, "Active") {
+			return errInvalidInput
+		}
+		choice = state.SchoolChoice{SchoolID: school.SchoolID, SchoolName: school.SchoolName}
+	}
+
+	return h.askLinkRole(senderID, h.utils.Lang(senderID), state.LinkRequest{SchoolID: choice.SchoolID, SchoolName: choice.SchoolName})
+}
+
+// linkRoles maps the role quick replies to the link roles users may pick
+// themselves
+var linkRoles = map[string]string{
+	helpers.PayloadRoleStudent:  models.LinkRoleStudent,
+	helpers.PayloadRoleGuardian: models.LinkRoleGuardian,
+}
+
+// askLinkRole asks who the user is to the student they are linking
+func (h *Handler) askLinkRole(senderID string, lang i18n.Lang, req state.LinkRequest) error {
+	req = state.LinkRequest{SchoolID: req.SchoolID, SchoolName: req.SchoolName}
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateLinkSelectRole}, req); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.select_role"), helpers.GetLinkRoleReplies(lang))
+}
+
+// showLinkRole asks for the role again, or for the school when the link request
+// is gone
+func (h *Handler) showLinkRole(senderID string, _ map[string]string) error {
+	_, data := h.stateManager.GetState(senderID)
+	req, err := state.Get[state.LinkRequest](data)
+	if err != nil {
+		return h.showLinkSchools(senderID)
+	}
+	return h.askLinkRole(senderID, h.utils.Lang(senderID), req)
+}
+
+// handleLinkRole records who the user is to the student and asks for the
+// student ID
+func (h *Handler) handleLinkRole(senderID string, in stateInput) error {
+	req, err := state.Get[state.LinkRequest](in.data)
+	if err != nil {
+		return err
+	}
+	role, ok := linkRoles[in.command]
+	if !ok {
+		return errInvalidInput
+	}
+	req.Role = role
+	return h.askStudentID(senderID, h.utils.Lang(senderID), req)
+}
+
+// askStudentID asks for the ID of the student to link from the chosen school
+func (h *Handler) askStudentID(senderID string, lang i18n.Lang, req state.LinkRequest) error {
+	req = state.LinkRequest{SchoolID: req.SchoolID, SchoolName: req.SchoolName, Role: req.Role}
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateLinkEnterStudentID}, req); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.enter_student_id", req.SchoolName), helpers.GetBack(lang))
+}
+
+// showLinkStudentID asks for the student ID again, or for the school or role
+// when the link request lacks them
+func (h *Handler) showLinkStudentID(senderID string, _ map[string]string) error {
+	_, data := h.stateManager.GetState(senderID)
+	req, err := state.Get[state.LinkRequest](data)
+	if err != nil {
+		return h.showLinkSchools(senderID)
+	}
+	lang := h.utils.Lang(senderID)
+	if req.Role == "" {
+		return h.askLinkRole(senderID, lang, req)
+	}
+	return h.askStudentID(senderID, lang, req)
+}
+
+// handleLinkStudentID looks up the student and asks for their birthdate
+func (h *Handler) handleLinkStudentID(senderID string, in stateInput) error {
+	req, err := state.Get[state.LinkRequest](in.data)
+	if err != nil {
+		return err
+	}
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	studentID := strings.TrimSpace(in.text)
+	if studentID == "" {
+		return errInvalidInput
+	}
+	req.StudentID = studentID
+
+	linked, err := h.linkRepo.HasActiveLink(int(user.ID), req.SchoolID, studentID)
+	if err != nil {
+		return fmt.Errorf("failed to check existing link: %w", err)
+	}
+	if linked {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "link.already_linked"))
+	}
+
+	student, err := h.studentRepo.GetStudentProfile(req.SchoolID, studentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if h.failLinkAttempt(senderID, user, req, models.LinkEventStudentNotFound) {
+			return h.leaveLinkFlow(senderID, i18n.T(lang, "link.locked"))
+		}
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.student_not_found", studentID, req.SchoolName), helpers.GetBack(lang))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+
+	if _, ok := parseBirthdate(student.Birthdate); !ok || (student.MobileNumber == "" && student.EmailAddress == "") {
+		log.Printf("Student %s/%s has no birthdate or contact to verify against", req.SchoolID, studentID)
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "link.cannot_verify"))
+	}
+
+	return h.askBirthdate(senderID, lang, req)
+}
+
+// askBirthdate asks for the birthdate of the student being linked
+func (h *Handler) askBirthdate(senderID string, lang i18n.Lang, req state.LinkRequest) error {
+	req = state.LinkRequest{SchoolID: req.SchoolID, SchoolName: req.SchoolName, Role: req.Role, StudentID: req.StudentID}
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateLinkEnterBirthdate}, req); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.enter_birthdate"), helpers.GetBack(lang))
+}
+
+// showLinkBirthdate asks for the birthdate again, or for the student ID when the
+// link request has none
+func (h *Handler) showLinkBirthdate(senderID string, _ map[string]string) error {
+	_, data := h.stateManager.GetState(senderID)
+	req, err := state.Get[state.LinkRequest](data)
+	if err != nil {
+		return h.showLinkSchools(senderID)
+	}
+	lang := h.utils.Lang(senderID)
+	if req.StudentID == "" {
+		return h.askStudentID(senderID, lang, req)
+	}
+	return h.askBirthdate(senderID, lang, req)
+}
+
+// handleLinkBirthdate checks the birthdate against the school's records and
+// sends the one-time code when it matches
+func (h *Handler) handleLinkBirthdate(senderID string, in stateInput) error {
+	req, err := state.Get[state.LinkRequest](in.data)
+	if err != nil {
+		return err
+	}
+	if req.StudentID == "" {
+		return fmt.Errorf("%w: link request has no student", state.ErrStalePayload)
+	}
+
+	birthdate, ok := parseBirthdate(in.text)
+	if !ok {
+		return errInvalidInput
+	}
+
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	student, err := h.studentRepo.GetStudentProfile(req.SchoolID, req.StudentID)
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+
+	onFile, ok := parseBirthdate(student.Birthdate)
+	if !ok {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "link.cannot_verify"))
+	}
+	if !birthdate.Equal(onFile) {
+		if h.failLinkAttempt(senderID, user, req, models.LinkEventBirthdateMismatch) {
+			return h.leaveLinkFlow(senderID, i18n.T(lang, "link.locked"))
+		}
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.birthdate_mismatch"), helpers.GetBack(lang))
+	}
+
+	return h.sendLinkCode(senderID, user, req, student)
+}
+
+// sendLinkCode sends a new one-time code to the student's mobile number, or to
+// their email when there is no number on file
+func (h *Handler) sendLinkCode(senderID string, user *models.User, req state.LinkRequest, student *models.StudentProfile) error {
+	lang := i18n.Of(user.Language)
+
+	sent, err := h.auditRepo.CountEvents(int(user.ID), []string{models.LinkEventCodeSent}, time.Now().Add(-h.linkCfg.LockoutWindow))
+	if err != nil {
+		return fmt.Errorf("failed to count codes sent: %w", err)
+	}
+	if sent >= int64(h.linkCfg.MaxCodes) {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "link.code_limit"))
+	}
+
+	code, err := newLinkCode()
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+
+	minutes := int(h.linkCfg.CodeTTL.Minutes())
+	if minutes < 1 {
+		minutes = 1
+	}
+	msg := notify.Message{
+		Channel: notify.SMS,
+		To:      student.MobileNumber,
+		Subject: i18n.T(lang, "link.code_subject"),
+		Body:    i18n.Plural(lang, "link.code_message", minutes, code),
+	}
+	req.Destination = maskPhone(student.MobileNumber)
+	if student.MobileNumber == "" {
+		msg.Channel, msg.To = notify.Email, student.EmailAddress
+		req.Destination = maskEmail(student.EmailAddress)
+	}
+
+	if err := h.notifier.Notify(msg); err != nil {
+		log.Printf("Error sending link code for %s/%s: %v", req.SchoolID, req.StudentID, err)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.code_failed"), helpers.GetBack(lang))
+	}
+	h.auditLink(senderID, user, req, models.LinkEventCodeSent, string(msg.Channel))
+
+	req.CodeHash = hashLinkCode(senderID, code)
+	req.CodeExpires = time.Now().Add(h.linkCfg.CodeTTL)
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateLinkEnterCode}, req); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.Plural(lang, "link.code_sent", minutes, req.Destination), helpers.GetResendCodeReplies(lang))
+}
+
+// handleLinkCode checks the one-time code and links the student when it matches
+func (h *Handler) handleLinkCode(senderID string, in stateInput) error {
+	req, err := state.Get[state.LinkRequest](in.data)
+	if err != nil {
+		return err
+	}
+	if req.StudentID == "" || req.CodeHash == "" {
+		return fmt.Errorf("%w: link request has no code", state.ErrStalePayload)
+	}
+
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	if in.command == helpers.PayloadResendCode {
+		student, err := h.studentRepo.GetStudentProfile(req.SchoolID, req.StudentID)
+		if err != nil {
+			return fmt.Errorf("failed to get student: %w", err)
+		}
+		return h.sendLinkCode(senderID, user, req, student)
+	}
+
+	code := strings.ReplaceAll(strings.TrimSpace(in.text), " ", "")
+	if len(code) != linkCodeDigits || strings.Trim(code, "0123456789") != "" {
+		return errInvalidInput
+	}
+
+	if time.Now().After(req.CodeExpires) {
+		h.auditLink(senderID, user, req, models.LinkEventCodeExpired, "")
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.code_expired"), helpers.GetResendCodeReplies(lang))
+	}
+	if subtle.ConstantTimeCompare([]byte(hashLinkCode(senderID, code)), []byte(req.CodeHash)) != 1 {
+		if h.failLinkAttempt(senderID, user, req, models.LinkEventCodeMismatch) {
+			return h.leaveLinkFlow(senderID, i18n.T(lang, "link.locked"))
+		}
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "link.code_mismatch"), helpers.GetResendCodeReplies(lang))
+	}
+
+	// Requests stored before roles were asked for link as a guardian
+	role := req.Role
+	if role == "" {
+		role = models.LinkRoleGuardian
+	}
+	link, err := h.linkRepo.LinkStudent(int(user.ID), req.SchoolID, req.StudentID, role)
+	if err != nil {
+		return fmt.Errorf("failed to link student: %w", err)
+	}
+	h.auditLink(senderID, user, req, models.LinkEventLinked, role)
+
+	return h.finishLink(senderID, lang, link)
+}
+
+// finishLink welcomes the user to the newly linked profile and opens the main
+// menu when it became their primary profile
+func (h *Handler) finishLink(senderID string, lang i18n.Lang, link *models.UserLinkWithStudent) error {
+	schoolName := link.SchoolID
+	if link.Student.School != nil {
+		schoolName = link.Student.School.SchoolName
+	}
+	welcome := i18n.T(lang, "link.welcome", link.Student.FirstName, link.Student.LastName, schoolName)
+	if err := h.fbSvc.SendTextMessage(senderID, welcome); err != nil {
+		return fmt.Errorf("failed to send link welcome: %w", err)
+	}
+
+	if !link.IsPrimary {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "link.added"))
+	}
+	return h.enterState(senderID, state.StateMainMenu)
+}
+
+// leaveLinkFlow ends the link or unlink flow with a message and the user's usual options
+func (h *Handler) leaveLinkFlow(senderID, message string) error {
+	if err := h.stateManager.SetState(senderID, state.StateInitial, state.History{}); err != nil {
+		log.Printf("Error resetting state: %v", err)
+	}
+	return h.utils.SendResponseWithQuickReplies(senderID, message)
+}
+
+// linkLocked reports whether the user failed verification too often recently
+func (h *Handler) linkLocked(userID int) (bool, error) {
+	failures, err := h.auditRepo.CountEvents(userID, models.LinkFailureEvents, time.Now().Add(-h.linkCfg.LockoutWindow))
+	if err != nil {
+		return false, fmt.Errorf("failed to count link failures: %w", err)
+	}
+	return failures >= int64(h.linkCfg.MaxFailures), nil
+}
+
+// failLinkAttempt records a failed verification and reports whether it locked
+// the user out
+func (h *Handler) failLinkAttempt(senderID string, user *models.User, req state.LinkRequest, event string) bool {
+	h.auditLink(senderID, user, req, event, "")
+
+	locked, err := h.linkLocked(int(user.ID))
+	if err != nil {
+		log.Printf("Error checking link attempts: %v", err)
+		return false
+	}
+	if locked {
+		log.Printf("Locking %s out of self-service linking", senderID)
+		h.auditLink(senderID, user, req, models.LinkEventLocked, "")
+	}
+	return locked
+}
+
+// auditLink records a step of the self-service link flow
+func (h *Handler) auditLink(senderID string, user *models.User, req state.LinkRequest, event, detail string) {
+	err := h.auditRepo.Record(models.LinkAudit{
+		PSID:      senderID,
+		UserID:    int(user.ID),
+		SchoolID:  req.SchoolID,
+		StudentID: req.StudentID,
+		Method:    models.LinkMethodSelfService,
+		Event:     event,
+		Detail:    detail,
+	})
+	if err != nil {
+		log.Printf("Error auditing link: %v", err)
+	}
+}
+
+// parseBirthdate reads a date in one of birthdateLayouts, ignoring any time of
+// day stored with it
+func parseBirthdate(s string) (time.Time, bool) {
+	s = strings.TrimSpace(s)
+	if len(s) > len("2006-01-02") && s[4] == '-' && (s[10] == 'T' || s[10] == ' ') {
+		s = s[:10]
+	}
+	for _, layout := range birthdateLayouts {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// newLinkCode returns a random one-time code of linkCodeDigits digits
+func newLinkCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// hashLinkCode hashes a code for the user it was sent to, so codes are never
+// kept in the state store
+func hashLinkCode(senderID, code string) string {
+	sum := sha256.Sum256([]byte(senderID + ":" + code))
+	return hex.EncodeToString(sum[:])
+}
+
+// maskPhone hides all but the last four digits of a mobile number
+func maskPhone(number string) string {
+	if len(number) <= 4 {
+		return number
+	}
+	return strings.Repeat("*", len(number)-4) + number[len(number)-4:]
+}
+
+// maskEmail hides all but the first letter of an email's local part
+func maskEmail(address string) string {
+	local, domain, ok := strings.Cut(address, "@")
+	if !ok || local == "" {
+		return address
+	}
+	return local[:1] + "***@" + domain
+}
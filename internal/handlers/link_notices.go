@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/services/facebook"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// linkNoticeBatch caps the links announced in one run
+const linkNoticeBatch = 50
+
+// deferredNotices holds the users whose link notices wait for them to message
+// the page again, because Messenger refused the notice outside the messaging
+// window even with a message tag
+type deferredNotices struct {
+	mu    sync.Mutex
+	users map[string]int // PSID to user ID
+}
+
+func newDeferredNotices() *deferredNotices {
+	return &deferredNotices{users: make(map[string]int)}
+}
+
+func (d *deferredNotices) hold(psid string, userID int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.users[psid] = userID
+}
+
+// resume lets the next run announce the user's links again
+func (d *deferredNotices) resume(psid string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.users, psid)
+}
+
+func (d *deferredNotices) userIDs() []int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ids := make([]int, 0, len(d.users))
+	for _, id := range d.users {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// RunLinkNotices tells users about students a school admin linked to their
+// account, every interval until ctx is done
+func (h *Handler) RunLinkNotices(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		h.sendLinkNotices()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// sendLinkNotices announces the links flagged IsNewlyLink and clears the flag
+// of each link announced or impossible to announce
+func (h *Handler) sendLinkNotices() {
+	stale, err := h.linkRepo.ClearStaleNewlyLinked(time.Now().Add(-h.linkCfg.NoticeMaxAge))
+	if err != nil {
+		log.Printf("Error clearing stale link notices: %v", err)
+	} else if stale > 0 {
+		log.Printf("Skipped %d link notice(s) older than %s", stale, h.linkCfg.NoticeMaxAge)
+	}
+
+	links, err := h.linkRepo.ListNewlyLinked(h.deferredNotices.userIDs(), linkNoticeBatch)
+	if err != nil {
+		log.Printf("Error listing new links: %v", err)
+		return
+	}
+
+	for _, link := range links {
+		user, err := h.repo.GetUserByID(link.UserID)
+		if err != nil {
+			log.Printf("Error getting user %d for link %d: %v", link.UserID, link.ID, err)
+			continue
+		}
+
+		err = h.sendLinkNotice(user, link)
+		switch {
+		case errors.Is(err, facebook.ErrOutsideWindow):
+			log.Printf("Deferring link notice %d until %s messages the page", link.ID, user.PSID)
+			h.deferredNotices.hold(user.PSID, link.UserID)
+			continue
+		case errors.Is(err, facebook.ErrUserUnavailable), errors.Is(err, facebook.ErrInvalidRecipient):
+			log.Printf("Dropping link notice %d, %s cannot be messaged: %v", link.ID, user.PSID, err)
+		case err != nil:
+			log.Printf("Error sending link notice %d to %s: %v", link.ID, user.PSID, err)
+			continue
+		}
+
+		if err := h.linkRepo.ClearNewlyLinked(link.ID); err != nil {
+			log.Printf("Error clearing link notice %d: %v", link.ID, err)
+		}
+	}
+}
+
+// sendLinkNotice welcomes the user to the linked student, offering to make it
+// their active profile when it is not. Users outside the standard messaging
+// window get the notice as an account update.
+func (h *Handler) sendLinkNotice(user *models.User, link models.UserLink) error {
+	lang := i18n.Of(user.Language)
+
+	student, err := h.studentRepo.GetStudentProfile(link.SchoolID, link.StudentID)
+	if err != nil {
+		return fmt.Errorf("failed to get student: %w", err)
+	}
+	schoolName := link.SchoolID
+	if student.School != nil {
+		schoolName = student.School.SchoolName
+	}
+
+	text := i18n.T(lang, "link.welcome", student.FirstName, student.LastName, schoolName)
+	replies := helpers.GetMenuReplies(lang)
+	if !link.IsPrimary {
+		text += "\n\n" + i18n.T(lang, "link.make_primary", student.FirstName)
+		replies = helpers.GetMakePrimaryReplies(lang, link.ID)
+	}
+	msg := facebook.QuickRepliesMessage(text, replies)
+
+	err = h.fbSvc.Send(user.PSID, msg)
+	if errors.Is(err, facebook.ErrOutsideWindow) {
+		err = h.fbSvc.SendTagged(user.PSID, facebook.AccountUpdate, msg)
+	}
+	return err
+}
+
+// handleMakePrimary makes the link offered in a link notice the user's active
+// profile
+func (h *Handler) handleMakePrimary(senderID string, linkID int) error {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return h.handleGetStarted(senderID)
+	}
+	lang := i18n.Of(user.Language)
+
+	primary, err := h.linkRepo.MakePrimary(int(user.ID), linkID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.not_found"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to make link %d primary: %w", linkID, err)
+	}
+
+	if primary != nil && primary.Student != nil {
+		if err := h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "profile.switched", primary.Student.FirstName, primary.Student.LastName)); err != nil {
+			return fmt.Errorf("failed to send switched message: %w", err)
+		}
+	}
+	if err := state.ClearHistory(h.stateManager, senderID); err != nil {
+		log.Printf("Error clearing history: %v", err)
+	}
+	return h.enterState(senderID, state.StateMainMenu)
+}
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"strings"
+	"testing"
+
+	"school-assistant-wh/internal/fakegraph"
+)
+
+func TestLinkNoticeOutsideWindowIsDeferred(t *testing.T) {
+	tables := linkedUserTables()
+	// The link is new, and the link query joins the users table, so it must be
+	// served before that table
+	links := tables[1]
+	links.rows[0][6] = true
+	tables[0], tables[1] = links, tables[0]
+	wt := newWebhookTest(t, "page-token", tables...)
+
+	outside, _ := fakegraph.FaultKind("outside_window")
+	outside.Path = "/me/messages"
+	outside.Recipient = "psid-1"
+	wt.graph.InjectFault(outside)
+
+	wt.h.sendLinkNotices()
+
+	if msgs := wt.messages("psid-1"); len(msgs) != 0 {
+		t.Fatalf("messages = %q, want none outside the messaging window", msgs)
+	}
+	tagged := false
+	wt.recorder.mu.Lock()
+	for body := range wt.recorder.sends {
+		tagged = tagged || strings.Contains(body, `"tag":"ACCOUNT_UPDATE"`)
+	}
+	wt.recorder.mu.Unlock()
+	if !tagged {
+		t.Fatal("the notice was not retried as an account update")
+	}
+	if wt.db.ranWith("SET `IsNewlyLink`=? WHERE ID = ?", int64(1)) {
+		t.Fatal("the deferred notice was cleared")
+	}
+	if ids := wt.h.deferredNotices.userIDs(); len(ids) != 1 || ids[0] != 1 {
+		t.Fatalf("deferred users = %v, want [1]", ids)
+	}
+
+	// Later runs skip the user until they message the page
+	wt.h.sendLinkNotices()
+	if !wt.db.ranWith("NOT IN", int64(1)) {
+		t.Fatal("the next run did not leave out the deferred user")
+	}
+
+	wt.graph.ClearFaults()
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Hi"})
+	if ids := wt.h.deferredNotices.userIDs(); len(ids) != 0 {
+		t.Fatalf("deferred users after a message = %v, want none", ids)
+	}
+
+	wt.h.sendLinkNotices()
+	msgs := strings.Join(wt.messages("psid-1"), "\n")
+	if !strings.Contains(msgs, "Welcome, Juan Dela Cruz from Test School!") {
+		t.Fatalf("messages = %q, want the link notice once the user is back", msgs)
+	}
+	if !wt.db.ranWith("SET `IsNewlyLink`=? WHERE ID = ?", int64(1)) {
+		t.Fatal("the notice was not cleared once sent")
+	}
+}
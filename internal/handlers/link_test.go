@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseBirthdate(t *testing.T) {
+	want := time.Date(2010, time.March, 5, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		in   string
+		ok   bool
+	}{
+		{"iso", "2010-03-05", true},
+		{"stored datetime", "2010-03-05 00:00:00", true},
+		{"stored rfc3339", "2010-03-05T00:00:00+08:00", true},
+		{"slashes", "03/05/2010", true},
+		{"slashes unpadded", "3/5/2010", true},
+		{"month name", "March 5, 2010", true},
+		{"short month no comma", " Mar 5 2010 ", true},
+		{"empty", "", false},
+		{"garbage", "yesterday", false},
+		{"out of range", "2010-02-30", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseBirthdate(tt.in)
+			if ok != tt.ok {
+				t.Fatalf("parseBirthdate(%q) ok = %v, want %v", tt.in, ok, tt.ok)
+			}
+			if ok && !got.Equal(want) {
+				t.Fatalf("parseBirthdate(%q) = %v, want %v", tt.in, got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package menu
+
+import (
+	"fmt"
+	"strings"
+
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/services/helpers"
+)
+
+// mainMenuOptions are the main menu entries by number. Options with a feature
+// are only listed and opened for profiles whose role may use it; the numbers
+// stay the same so typed numbers and shortcuts keep working.
+var mainMenuOptions = []struct {
+	number  string
+	label   string
+	feature access.Feature
+}{
+	{"1", "menu.option.grades", access.Grades},
+	{"2", "menu.option.fees", access.Fees},
+	{"3", "menu.option.bulletin", access.Bulletin},
+	{"4", "menu.option.attendance", access.Attendance},
+	{"5", "menu.option.account", ""},
+	{"6", "menu.option.support", access.Support},
+}
+
+// optionFeature returns the feature the main menu option needs, or "" when
+// every role may open it
+func optionFeature(number string) access.Feature {
+	for _, option := range mainMenuOptions {
+		if option.number == number {
+			return option.feature
+		}
+	}
+	return ""
+}
+
+// mainMenuList lists the main menu options the link's role may use
+func (h *MenuHandler) mainMenuList(lang i18n.Lang, link models.UserLink) string {
+	var lines []string
+	for _, option := range mainMenuOptions {
+		if option.feature == "" || h.policy.Allows(link, option.feature) {
+			lines = append(lines, i18n.T(lang, option.label))
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Permits reports whether the user's primary profile may use the feature. Users
+// without a primary profile are let through, so the feature itself can tell them
+// to pick one.
+func (h *MenuHandler) Permits(senderID string, f access.Feature) (bool, error) {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
+	}
+	link, err := h.linkRepo.GetPrimaryLink(int(user.ID))
+	if err != nil {
+		return false, fmt.Errorf("failed to get primary link: %w", err)
+	}
+	if link == nil {
+		return true, nil
+	}
+	return h.policy.Allows(link.UserLink, f), nil
+}
+
+// RequireFeature checks that the user's primary profile may use the feature,
+// telling them when it may not
+func (h *MenuHandler) RequireFeature(senderID string, f access.Feature) (bool, error) {
+	allowed, err := h.Permits(senderID, f)
+	if err != nil || allowed {
+		return allowed, err
+	}
+	lang := h.utils.Lang(senderID)
+	return false, h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "menu.unavailable"), helpers.GetBack(lang))
+}
@@ -3,10 +3,11 @@ package menu
 import (
 	"fmt"
 	"log"
+	"strconv"
 	"strings"
-	"time"
 
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
 	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
@@ -16,7 +17,8 @@ const (
 	bulletinsPerPage = 3
 )
 
-// HandleViewBulletin handles viewing bulletins with pagination
+// HandleViewBulletin handles viewing bulletins with pagination. Each page is sent
+// as a single carousel with one card per bulletin.
 func (h *MenuHandler) HandleViewBulletin(senderID string, pageNum int) error {
 	if pageNum < 1 {
 		pageNum = 1
@@ -27,8 +29,10 @@ func (h *MenuHandler) HandleViewBulletin(senderID string, pageNum int) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
@@ -58,83 +62,22 @@ func (h *MenuHandler) HandleViewBulletin(senderID string, pageNum int) error {
 
 	if len(bulletins) == 0 {
 		if offset == 0 {
-			quickReplies := helpers.GetBack()
-			return h.fbSvc.SendQuickReplies(senderID, "No active bulletins found.", quickReplies)
+			quickReplies := helpers.GetBack(lang)
+			return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "bulletin.none"), quickReplies)
 		} else {
-			quickReplies := helpers.GetBack()
-			return h.fbSvc.SendQuickReplies(senderID, "No more bulletins to show.", quickReplies)
+			quickReplies := helpers.GetBack(lang)
+			return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "bulletin.no_more"), quickReplies)
 		}
 	}
 
-	// Send header message
-	headerMessage := "📰 *Bulletin Board*\n\nStay informed with the latest school updates."
-	if err := h.fbSvc.SendTextMessage(senderID, headerMessage); err != nil {
-		return fmt.Errorf("failed to send header message: %w", err)
+	elements := make([]facebook.GenericElement, 0, len(bulletins))
+	for _, bulletin := range bulletins {
+		elements = append(elements, bulletinElement(lang, bulletin))
 	}
 
-	// Send each bulletin as a separate message
-	for i, bulletin := range bulletins {
-		var message strings.Builder
-
-		// Add bulletin title with label
-		message.WriteString(fmt.Sprintf("*Title:* %s\n", bulletin.Title))
-
-		// Add date with label if available and not the Unix epoch
-		isEpoch := bulletin.PeriodStart.Year() == 1970 &&
-			bulletin.PeriodStart.Month() == 1 &&
-			bulletin.PeriodStart.Day() == 1 &&
-			!bulletin.PeriodStart.IsZero()
-
-		if !bulletin.PeriodStart.IsZero() && !isEpoch {
-			message.WriteString(fmt.Sprintf("*Date:* %s\n",
-				bulletin.PeriodStart.Format("January 02, 2006")))
-		}
-
-		// Send image first if available
-		if bulletin.ImageURL != "" && bulletin.ImageURL != "." {
-			if err := h.fbSvc.SendImage(senderID, bulletin.ImageURL); err != nil {
-				log.Printf("Failed to send image (URL: %s): %v", bulletin.ImageURL, err)
-			}
-		}
-
-		// Add description with label if available
-		if bulletin.Description != nil && *bulletin.Description != "" {
-			desc := *bulletin.Description
-			if len(desc) > 200 { // Truncate long descriptions
-				desc = desc[:200] + "..."
-			}
-			message.WriteString(fmt.Sprintf("\n*Description:*\n%s\n", desc))
-		}
-
-		// Add link with label if available in Notes1
-		if bulletin.Notes1 != nil && *bulletin.Notes1 != "" {
-			notes := strings.TrimSpace(*bulletin.Notes1)
-			startTag := "<redirectionlink>"
-			endTag := "</redirectionlink>"
-			startIdx := strings.Index(notes, startTag)
-			endIdx := strings.Index(notes, endTag)
-
-			if startIdx != -1 && endIdx != -1 && endIdx > startIdx {
-				link := strings.TrimSpace(notes[startIdx+len(startTag) : endIdx])
-				if link != "" {
-					message.WriteString(fmt.Sprintf("\n*Link:* %s\n", link))
-				}
-			}
-		}
-
-		// Add separator between bulletins
-		if i < len(bulletins)-1 {
-			message.WriteString("\n━━━━━━━━━━━━━━━━━━\n")
-		}
-
-		// Send the bulletin message
-		if message.Len() > 0 {
-			if err := h.fbSvc.SendTextMessage(senderID, message.String()); err != nil {
-				log.Printf("Failed to send bulletin message: %v", err)
-			}
-		}
-
-		time.Sleep(100 * time.Millisecond) // Small delay between messages
+	carousel, err := facebook.GenericTemplateMessage(elements)
+	if err != nil {
+		return fmt.Errorf("failed to build bulletin carousel: %w", err)
 	}
 
 	// Calculate pagination details
@@ -145,28 +88,157 @@ func (h *MenuHandler) HandleViewBulletin(senderID string, pageNum int) error {
 	}
 
 	// Update state with comprehensive pagination details
-	if err := h.stateManager.SetState(senderID, state.StateViewBulletin, map[string]interface{}{
-		state.KeyPageOffset:      offset,
-		state.KeyPaginationItems: len(bulletins),
-		state.KeyPaginationTotal: int(totalCount),
-		state.KeyPaginationPage:  currentPage,
-		state.KeyPaginationSize:  bulletinsPerPage,
-		state.KeyPaginationPages: totalPages,
+	screen := state.Screen{State: state.StateViewBulletin, Params: map[string]string{
+		state.ParamPage: strconv.Itoa(currentPage),
+	}}
+	if err := state.EnterScreen(h.stateManager, senderID, screen, state.Pagination{
+		Offset: offset,
+		Items:  len(bulletins),
+		Total:  int(totalCount),
+		Page:   currentPage,
+		Size:   bulletinsPerPage,
+		Pages:  totalPages,
 	}); err != nil {
 		log.Printf("Error updating state: %v", err)
 	}
 
-	// Send pagination info to user
-	messageText := fmt.Sprintf("_Page %d of %d_", currentPage, totalPages)
+	messageText := i18n.T(lang, "bulletin.page", currentPage, totalPages)
 
 	// Prepare and send quick replies
 	var quickReplies []facebook.QuickReply
 	hasMore := int64(offset+bulletinsPerPage) < totalCount
 	if hasMore {
-		quickReplies = helpers.GetViewMoreReplies()
+		quickReplies = helpers.GetViewMoreReplies(lang)
 	} else {
-		quickReplies = helpers.GetBack()
+		quickReplies = helpers.GetBack(lang)
+	}
+
+	return h.fbSvc.SendSequence(senderID, []facebook.Message{
+		carousel,
+		facebook.QuickRepliesMessage(messageText, quickReplies),
+	})
+}
+
+// HandleBulletinDetails sends the full text of a bulletin after the user taps
+// its "Read more" button
+func (h *MenuHandler) HandleBulletinDetails(senderID string, bulletinID int) error {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+
+	lang := i18n.Of(user.Language)
+
+	if !user.IsActive {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
+	}
+
+	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
+	if err != nil || currentProfileData == nil || currentProfileData.Student == nil {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
+	}
+
+	bulletin, err := h.bulletinRepo.GetBulletinByID(currentProfileData.Student.School.SchoolID, bulletinID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch bulletin %d: %w", bulletinID, err)
 	}
+	if bulletin == nil {
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "bulletin.unavailable"), helpers.GetBack(lang))
+	}
+
+	screen := state.Screen{State: state.StateViewBulletinDetails, Params: map[string]string{
+		state.ParamID: strconv.Itoa(bulletinID),
+	}}
+	if err := state.EnterScreen(h.stateManager, senderID, screen); err != nil {
+		log.Printf("Error updating state: %v", err)
+	}
+
+	var message strings.Builder
+	message.WriteString(fmt.Sprintf("*%s*\n", bulletin.Title))
+	if date := bulletinDate(lang, *bulletin); date != "" {
+		message.WriteString(fmt.Sprintf("%s\n", date))
+	}
+	if bulletin.Description != nil && *bulletin.Description != "" {
+		message.WriteString(fmt.Sprintf("\n%s\n", *bulletin.Description))
+	}
+	if link := bulletinLink(*bulletin); link != "" {
+		message.WriteString(i18n.T(lang, "bulletin.link", link))
+	}
+
+	// Long bulletins go out as several messages, the last one offering Back
+	parts := facebook.SplitText(message.String(), facebook.MaxTextLength)
+	messages := make([]facebook.Message, len(parts))
+	for i, part := range parts {
+		messages[i] = facebook.TextMessage(part)
+	}
+	messages[len(messages)-1].QuickReplies = helpers.GetBack(lang)
+	return h.fbSvc.SendSequence(senderID, messages)
+}
+
+// bulletinElement renders a bulletin as a carousel card
+func bulletinElement(lang i18n.Lang, bulletin models.Bulletin) facebook.GenericElement {
+	element := facebook.GenericElement{
+		Title: bulletin.Title,
+	}
+
+	var subtitle []string
+	if date := bulletinDate(lang, bulletin); date != "" {
+		subtitle = append(subtitle, date)
+	}
+	if bulletin.Description != nil && *bulletin.Description != "" {
+		subtitle = append(subtitle, strings.TrimSpace(*bulletin.Description))
+	}
+	element.Subtitle = strings.Join(subtitle, "\n")
+
+	if bulletin.ImageURL != "" && bulletin.ImageURL != "." {
+		if err := facebook.ValidateImageURL(bulletin.ImageURL); err != nil {
+			log.Printf("Skipping bulletin image (URL: %s): %v", bulletin.ImageURL, err)
+		} else {
+			element.ImageURL = bulletin.ImageURL
+		}
+	}
+
+	if link := bulletinLink(bulletin); link != "" {
+		element.DefaultAction = facebook.NewURLAction(link)
+		element.Buttons = append(element.Buttons, facebook.URLButton(i18n.T(lang, "button.open_link"), link))
+	}
+	element.Buttons = append(element.Buttons, facebook.PostbackButton(i18n.T(lang, "button.read_more"), helpers.BulletinPayload(bulletin.ID)))
 
-	return h.fbSvc.SendQuickReplies(senderID, messageText, quickReplies)
+	return element
+}
+
+// bulletinDate formats the bulletin's start date, skipping unset and Unix epoch dates
+func bulletinDate(lang i18n.Lang, bulletin models.Bulletin) string {
+	isEpoch := bulletin.PeriodStart.Year() == 1970 &&
+		bulletin.PeriodStart.Month() == 1 &&
+		bulletin.PeriodStart.Day() == 1 &&
+		!bulletin.PeriodStart.IsZero()
+
+	if bulletin.PeriodStart.IsZero() || isEpoch {
+		return ""
+	}
+	return i18n.Date(lang, bulletin.PeriodStart)
+}
+
+// bulletinLink extracts the <redirectionlink> URL from the bulletin's Notes1
+func bulletinLink(bulletin models.Bulletin) string {
+	if bulletin.Notes1 == nil || *bulletin.Notes1 == "" {
+		return ""
+	}
+
+	notes := strings.TrimSpace(*bulletin.Notes1)
+	startTag := "<redirectionlink>"
+	endTag := "</redirectionlink>"
+	startIdx := strings.Index(notes, startTag)
+	endIdx := strings.Index(notes, endTag)
+
+	if startIdx == -1 || endIdx == -1 || endIdx <= startIdx {
+		return ""
+	}
+
+	link := strings.TrimSpace(notes[startIdx+len(startTag) : endIdx])
+	if !strings.HasPrefix(link, "http://") && !strings.HasPrefix(link, "https://") {
+		return ""
+	}
+	return link
 }
@@ -3,8 +3,9 @@ package menu
 import (
 	"fmt"
 	"log"
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/state"
+	"strconv"
 	"strings"
 	"time"
 
@@ -17,8 +18,14 @@ const (
 	dtrPerPage = 10
 )
 
-// HandleViewDTR handles viewing DTR records with pagination
+// HandleViewDTR handles viewing the current month's DTR records with pagination
 func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
+	return h.HandleViewDTRForMonth(senderID, time.Now(), pageNum)
+}
+
+// HandleViewDTRForMonth handles viewing the DTR records of the month containing
+// period, with pagination
+func (h *MenuHandler) HandleViewDTRForMonth(senderID string, period time.Time, pageNum int) error {
 	if pageNum < 1 {
 		pageNum = 1
 	}
@@ -28,8 +35,10 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	profile, err := h.linkRepo.GetPrimaryLink(int(user.ID))
@@ -38,8 +47,8 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 	}
 
 	totalCount, err := h.dtrRepo.GetDTRRecordsCount(
-		time.Now().Year(),
-		time.Now().Month(),
+		period.Year(),
+		period.Month(),
 		profile.Student.School.SchoolID,
 		profile.Student.StudentID,
 	)
@@ -61,8 +70,8 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 		offset = 0
 	}
 	records, err := h.dtrRepo.GetDTRRecords(
-		time.Now().Year(),
-		time.Now().Month(),
+		period.Year(),
+		period.Month(),
 		profile.Student.School.SchoolID,
 		profile.Student.StudentID,
 		offset,
@@ -74,11 +83,11 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 
 	if len(records) == 0 {
 		if pageNum == 1 {
-			quickReplies := helpers.GetBack()
-			return h.fbSvc.SendQuickReplies(senderID, "No attendance records found.", quickReplies)
+			quickReplies := helpers.GetBack(lang)
+			return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "dtr.none"), quickReplies)
 		} else {
-			quickReplies := helpers.GetBack()
-			return h.fbSvc.SendQuickReplies(senderID, "No more records to show.", quickReplies)
+			quickReplies := helpers.GetBack(lang)
+			return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "dtr.no_more"), quickReplies)
 		}
 	}
 
@@ -89,12 +98,11 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 	}
 
 	var sb strings.Builder
-	sb.WriteString("📋 *Your Attendance Records*\n\n")
-	sb.WriteString(fmt.Sprintf("📅 *%s %d*\n\n", time.Now().Month().String(), time.Now().Year()))
+	sb.WriteString(i18n.T(lang, "dtr.title", i18n.MonthYear(lang, period)))
 
 	for date, dateRecords := range recordsByDate {
 		d, _ := time.Parse("2006-01-02", date)
-		sb.WriteString(fmt.Sprintf("📅 *%s, %s*\n", d.Format("Monday"), d.Format("Jan 02, 2006")))
+		sb.WriteString(i18n.T(lang, "dtr.day", i18n.Weekday(lang, d), i18n.Date(lang, d)))
 
 		for _, record := range dateRecords {
 			timeStr := record.DateTimeIN.Format("15:04:05")
@@ -103,16 +111,20 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 		sb.WriteString("\n")
 	}
 
-	sb.WriteString(fmt.Sprintf("_Page %d of %d\n", pageNum, totalPages))
+	sb.WriteString(i18n.T(lang, "dtr.page", pageNum, totalPages))
 
 	// Update state with comprehensive pagination details
-	if err := h.stateManager.SetState(senderID, state.StateViewDTR, map[string]interface{}{
-		state.KeyPageOffset:      offset,
-		state.KeyPaginationItems: len(records),
-		state.KeyPaginationTotal: int(totalCount),
-		state.KeyPaginationPage:  pageNum,
-		state.KeyPaginationSize:  dtrPerPage,
-		state.KeyPaginationPages: totalPages,
+	screen := state.Screen{State: state.StateViewDTR, Params: map[string]string{
+		state.ParamPage:  strconv.Itoa(pageNum),
+		state.ParamMonth: period.Format(state.MonthLayout),
+	}}
+	if err := state.EnterScreen(h.stateManager, senderID, screen, state.Pagination{
+		Offset: offset,
+		Items:  len(records),
+		Total:  int(totalCount),
+		Page:   pageNum,
+		Size:   dtrPerPage,
+		Pages:  totalPages,
 	}); err != nil {
 		log.Printf("Error updating state: %v", err)
 	}
@@ -120,9 +132,9 @@ func (h *MenuHandler) HandleViewDTR(senderID string, pageNum int) error {
 	var quickReplies []facebook.QuickReply
 	hasMore := int64(offset+dtrPerPage) < totalCount
 	if hasMore {
-		quickReplies = helpers.GetViewMoreReplies()
+		quickReplies = helpers.GetViewMoreReplies(lang)
 	} else {
-		quickReplies = helpers.GetBack()
+		quickReplies = helpers.GetBack(lang)
 	}
 
 	return h.fbSvc.SendQuickReplies(senderID, sb.String(), quickReplies)
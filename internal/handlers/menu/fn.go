@@ -3,8 +3,9 @@ package menu
 import (
 	"fmt"
 	"log"
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/access"
 	"school-assistant-wh/internal/handlers/utils"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/repositories"
 	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
@@ -22,7 +23,8 @@ type MenuHandler struct {
 	supportRepo    repositories.SupportRepository
 	fbSvc          *facebook.Service
 	utils          *utils.ResponseUtils
-	stateManager   *state.StateManager
+	stateManager   state.StateManager
+	policy         *access.Policy
 }
 
 func NewMenuHandler(
@@ -35,7 +37,8 @@ func NewMenuHandler(
 	dtrRepo repositories.DTRRepository,
 	supportRepo repositories.SupportRepository,
 	fbSvc *facebook.Service,
-	stateManager *state.StateManager,
+	stateManager state.StateManager,
+	policy *access.Policy,
 ) *MenuHandler {
 	return &MenuHandler{
 		repo:           repo,
@@ -49,6 +52,7 @@ func NewMenuHandler(
 		fbSvc:          fbSvc,
 		utils:          utils.NewResponseUtils(repo, linkRepo, fbSvc),
 		stateManager:   stateManager,
+		policy:         policy,
 	}
 }
 
@@ -58,27 +62,34 @@ func (h *MenuHandler) ShowMainMenu(senderID string) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil || currentProfileData == nil {
-		if err := h.stateManager.SetState(senderID, state.StateInitial, nil); err != nil {
+		if err := h.stateManager.SetState(senderID, state.StateInitial); err != nil {
 			log.Printf("Error resetting state: %v", err)
 		}
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select a View Profiles and choose a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	if currentProfileData.Student == nil {
 		return fmt.Errorf("student data not found for the primary profile")
 	}
 
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateMainMenu}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+
 	fullName := fmt.Sprintf("%s %s", currentProfileData.Student.FirstName, currentProfileData.Student.LastName)
-	menuMessage := fmt.Sprintf(constants.MainMenuTemplate,
+	menuMessage := i18n.T(lang, "menu.main",
 		fullName,
 		currentProfileData.Student.School.SchoolName,
+		h.mainMenuList(lang, currentProfileData.UserLink),
 	)
-	quickreplies := helpers.GetMainMenuReplies()
+	quickreplies := helpers.GetMainMenuReplies(lang)
 	return h.fbSvc.SendQuickReplies(senderID, menuMessage, quickreplies)
 }
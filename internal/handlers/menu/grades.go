@@ -6,7 +6,7 @@ import (
 	"sort"
 	"strings"
 
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/models"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
@@ -30,8 +30,10 @@ func (h *MenuHandler) HandleViewGrades(senderID string) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
@@ -50,17 +52,13 @@ func (h *MenuHandler) HandleViewGrades(senderID string) error {
 	yearMap := groupGradesBySchoolYear(grades)
 
 	if len(yearMap) == 0 {
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID, "No grades found for this student.", quickReplies)
+		quickReplies := helpers.GetBack(lang)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "grades.none"), quickReplies)
 	}
 
 	var years []string
-	yearOptions := make(map[string]string)
-	i := 1
 	for year := range yearMap {
 		years = append(years, year)
-		yearOptions[fmt.Sprint(i)] = year
-		i++
 	}
 
 	sort.Slice(years, func(i, j int) bool {
@@ -68,22 +66,20 @@ func (h *MenuHandler) HandleViewGrades(senderID string) error {
 	})
 
 	var sb strings.Builder
-	sb.WriteString("📚 *View Grades by School Year*\n\n")
-	sb.WriteString("Please select a school year to view grades:\n\n")
+	sb.WriteString(i18n.T(lang, "grades.years"))
 
+	// Number the options in the order they are listed
+	yearOptions := make(state.YearChoices)
 	for i, year := range years {
 		sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, year))
+		yearOptions[fmt.Sprint(i+1)] = year
 	}
 
-	stateData := map[string]any{
-		state.KeySchoolYearMap: yearOptions,
-	}
-
-	if err := h.stateManager.SetState(senderID, state.StateViewGrades, stateData); err != nil {
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateViewGrades}, yearOptions); err != nil {
 		log.Printf("Error setting view grades state: %v", err)
 	}
 
-	return h.fbSvc.SendQuickReplies(senderID, sb.String(), helpers.GetBack())
+	return h.fbSvc.SendQuickReplies(senderID, sb.String(), helpers.GetBack(lang))
 }
 
 // HandleViewGradesByYear displays grades for a specific school year, grouped by semester
@@ -93,8 +89,10 @@ func (h *MenuHandler) HandleViewGradesByYear(senderID, year string) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if user.IsActive == false {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
@@ -110,6 +108,11 @@ func (h *MenuHandler) HandleViewGradesByYear(senderID, year string) error {
 		return fmt.Errorf("failed to fetch grades: %w", err)
 	}
 
+	screen := state.Screen{State: state.StateViewGradesDetails, Params: map[string]string{state.ParamYear: year}}
+	if err := state.EnterScreen(h.stateManager, senderID, screen); err != nil {
+		log.Printf("Error setting view grades state: %v", err)
+	}
+
 	// Filter and group grades by semester
 	semesterGrades := make(map[string][]models.SubjectGrade)
 	for _, grade := range grades {
@@ -119,10 +122,8 @@ func (h *MenuHandler) HandleViewGradesByYear(senderID, year string) error {
 	}
 
 	if len(semesterGrades) == 0 {
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID,
-			fmt.Sprintf("No grades found for school year %s.", year),
-			quickReplies)
+		quickReplies := helpers.GetBack(lang)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "grades.none_for_year", year), quickReplies)
 	}
 
 	// Sort semesters
@@ -141,10 +142,10 @@ func (h *MenuHandler) HandleViewGradesByYear(senderID, year string) error {
 		})
 
 		var gradesList strings.Builder
-		gradesList.WriteString(fmt.Sprintf("📚 *%s - %s*\n\n", sem, year))
+		gradesList.WriteString(i18n.T(lang, "grades.semester", sem, year))
 
 		for _, grade := range semesterGrades {
-			gradesList.WriteString(fmt.Sprintf(constants.GradeItemTemplate,
+			gradesList.WriteString(i18n.T(lang, "grades.item",
 				grade.SubjectDescription,
 				grade.StudentGrade,
 				grade.ExamTerm,
@@ -160,6 +161,6 @@ func (h *MenuHandler) HandleViewGradesByYear(senderID, year string) error {
 		}
 	}
 
-	quickReplies := helpers.GetBack()
-	return h.fbSvc.SendQuickReplies(senderID, "Select another option:", quickReplies)
+	quickReplies := helpers.GetBack(lang)
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "next.select"), quickReplies)
 }
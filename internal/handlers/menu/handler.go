@@ -1,9 +1,18 @@
 package menu
 
-import "school-assistant-wh/internal/services/helpers"
+import (
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/services/helpers"
+)
 
 // MenuHandler processes the user's menu selection
 func (h *MenuHandler) MenuHandler(senderID, selection string) error {
+	if feature := optionFeature(selection); feature != "" {
+		if ok, err := h.RequireFeature(senderID, feature); !ok {
+			return err
+		}
+	}
+
 	switch selection {
 	case "1":
 		return h.HandleViewGrades(senderID)
@@ -18,12 +27,8 @@ func (h *MenuHandler) MenuHandler(senderID, selection string) error {
 	case "6":
 		return h.AskSupport(senderID)
 	default:
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(
-			senderID,
-			"⚠️ Invalid selection. Please choose a valid option from the menu.",
-			quickReplies,
-		)
+		lang := h.utils.Lang(senderID)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "menu.invalid"), helpers.GetBack(lang))
 	}
 
 }
@@ -3,13 +3,13 @@ package menu
 import (
 	"fmt"
 	"log"
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
 	"sort"
 	"strings"
-	"time"
 )
 
 // HandleViewPayables handles the request to view student payables
@@ -19,20 +19,22 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil {
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select a View Profiles and choose a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	if currentProfileData.Student == nil {
 		return fmt.Errorf("student data not found for the primary profile")
 	}
 
-	err = h.stateManager.SetState(senderID, state.StateViewPayables, nil)
+	err = state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateViewPayables})
 	if err != nil {
 		return fmt.Errorf("failed to set state: %w", err)
 	}
@@ -44,18 +46,19 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 	)
 	if err != nil {
 		log.Printf("Error fetching payables: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Failed to fetch payables. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "payables.fetch_failed"))
 	}
 
 	if len(payables) == 0 {
-		return h.fbSvc.SendTextMessage(senderID, "You don't have any active payables at the moment.")
+		return h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "payables.none"))
 	}
 
 	// Group payables by school year and semester
+	notSpecified := i18n.T(lang, "payables.not_specified")
 	payablesByTerm := make(map[string]map[string][]models.StudentPayable)
 	for _, p := range payables {
 		// Use "Not Specified" as default for nil SchoolYear
-		schoolYear := "Not Specified"
+		schoolYear := notSpecified
 		if p.SchoolYear != nil && *p.SchoolYear != "" {
 			schoolYear = *p.SchoolYear
 		}
@@ -63,7 +66,7 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 		// Use "Not Specified" as default for empty Semester
 		semester := p.Semester
 		if semester == "" || semester == "." {
-			semester = "Not Specified"
+			semester = notSpecified
 		}
 
 		// Initialize the maps if they don't exist
@@ -81,10 +84,10 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 	}
 	sort.Slice(schoolYears, func(i, j int) bool {
 		// Put "Not Specified" at the end
-		if schoolYears[i] == "Not Specified" {
+		if schoolYears[i] == notSpecified {
 			return false
 		}
-		if schoolYears[j] == "Not Specified" {
+		if schoolYears[j] == notSpecified {
 			return true
 		}
 		return schoolYears[i] > schoolYears[j] // Sort in descending order
@@ -103,48 +106,35 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 	}
 
 	// Send summary message
-	summaryMsg := fmt.Sprintf(
-		"📋 *Your Active Payables*\n\n"+
-			"👤 *%s %s*\n"+
-			"📝 Student ID: %s\n"+
-			"🏫 %s\n\n"+
-			"*Total Balance: ₱%.2f*\n"+
-			"*%d payment(s) across %d term(s)*\n\n"+
-			"Here are your payables, grouped by school term:",
+	summaryMsg := i18n.T(lang, "payables.summary",
 		currentProfileData.Student.FirstName,
 		currentProfileData.Student.LastName,
 		currentProfileData.Student.StudentID,
 		currentProfileData.Student.School.SchoolName,
-		totalBalance,
-		len(payables),
-		totalTerms,
+		i18n.Amount(lang, totalBalance),
+		i18n.Plural(lang, "payables.count", len(payables)),
+		i18n.Plural(lang, "payables.terms", totalTerms),
 	)
 
-	if err := h.fbSvc.SendTextMessage(senderID, summaryMsg); err != nil {
-		log.Printf("Error sending summary message: %v", err)
-	}
+	// Summary, batches and navigation go out as one ordered sequence
+	messages := []facebook.Message{facebook.TextMessage(summaryMsg)}
 
 	const maxPayablesPerMessage = 5
 	var currentMessage strings.Builder
 	var currentCount int
 
-	// Function to send the current batch of payables
-	sendCurrentBatch := func() error {
+	// Function to queue the current batch of payables
+	appendCurrentBatch := func() {
 		if currentMessage.Len() == 0 {
-			return nil
+			return
 		}
 
 		// Add progress indicator
-		progress := fmt.Sprintf("\n\n_Showing %d of %d payables_", currentCount, len(payables))
+		progress := i18n.Plural(lang, "payables.progress", len(payables), currentCount)
 		currentMessage.WriteString(progress)
 
-		if err := h.fbSvc.SendTextMessage(senderID, currentMessage.String()); err != nil {
-			return fmt.Errorf("error sending payables batch: %w", err)
-		}
-
-		time.Sleep(300 * time.Millisecond) // Small delay between messages
+		messages = append(messages, facebook.TextMessage(currentMessage.String()))
 		currentMessage.Reset()
-		return nil
 	}
 
 	// Process payables by school year and semester
@@ -162,7 +152,7 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 			if sem1 == sem2 {
 				return false
 			}
-			order := map[string]int{"1st Semester": 1, "2nd Semester": 2, "Summer": 3, "Not Specified": 99}
+			order := map[string]int{"1st Semester": 1, "2nd Semester": 2, "Summer": 3, notSpecified: 99}
 			i1, i2 := order[sem1], order[sem2]
 			if i1 == 0 {
 				i1 = 4
@@ -177,38 +167,30 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 			payableGroup := semesters[semester]
 
 			// Add school year and semester header
-			header := fmt.Sprintf("\n📚 *%s* • %s\n", schoolYear, semester)
+			header := i18n.T(lang, "payables.term", schoolYear, semester)
 			if currentMessage.Len()+len(header) > 1800 {
-				if err := sendCurrentBatch(); err != nil {
-					log.Printf("Error sending batch: %v", err)
-				}
+				appendCurrentBatch()
 			}
 			currentMessage.WriteString(header)
 
 			// Add payables for this term
 			for _, p := range payableGroup {
 				// Format payable details
-				details := fmt.Sprintf(
-					"➤ *%s*\n"+
-						"   SOA ID: %s\n"+
-						"   Amount: ₱%.2f\n"+
-						"   Type: %s\n\n",
+				details := i18n.T(lang, "payables.item",
 					p.Particulars,
 					p.SOAID,
-					p.TotalAmountToPay,
+					i18n.Amount(lang, p.TotalAmountToPay),
 					func() string {
 						if p.Type != nil && *p.Type != "" {
 							return *p.Type
 						}
-						return "Not specified"
+						return notSpecified
 					}(),
 				)
 
 				// Check if we need to send the current batch
 				if currentMessage.Len()+len(details) > 1800 {
-					if err := sendCurrentBatch(); err != nil {
-						log.Printf("Error sending batch: %v", err)
-					}
+					appendCurrentBatch()
 				}
 
 				currentMessage.WriteString(details)
@@ -216,22 +198,19 @@ func (h *MenuHandler) HandleViewPayables(senderID string) error {
 
 				// Check if we've reached the max items per message
 				if currentCount%maxPayablesPerMessage == 0 {
-					if err := sendCurrentBatch(); err != nil {
-						log.Printf("Error sending batch: %v", err)
-					}
+					appendCurrentBatch()
 				}
 			}
 		}
 	}
 
-	// Send any remaining payables
+	// Queue any remaining payables
 	if currentMessage.Len() > 0 {
-		if err := sendCurrentBatch(); err != nil {
-			log.Printf("Error sending final batch: %v", err)
-		}
+		appendCurrentBatch()
 	}
 
 	// Send final message with navigation options
-	quickReplies := helpers.GetPaymentReplies()
-	return h.fbSvc.SendQuickReplies(senderID, "What would you like to do next?", quickReplies)
+	quickReplies := helpers.GetPaymentReplies(lang)
+	messages = append(messages, facebook.QuickRepliesMessage(i18n.T(lang, "next.prompt"), quickReplies))
+	return h.fbSvc.SendSequence(senderID, messages)
 }
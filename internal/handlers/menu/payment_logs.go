@@ -3,8 +3,10 @@ package menu
 import (
 	"fmt"
 	"log"
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
 	"sort"
 	"strings"
 	"time"
@@ -17,19 +19,25 @@ func (h *MenuHandler) HandleViewPaymentLogs(senderID string) error {
 		return fmt.Errorf("failed to get user: %w", err)
 	}
 
+	lang := i18n.Of(user.Language)
+
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil {
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select View Profiles and choose a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	if currentProfileData.Student == nil {
 		return fmt.Errorf("student data not found for the primary profile")
 	}
 
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateViewPaymentLogs}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+
 	// Get current year for default log retrieval
 	currentYear := time.Now().Year()
 
@@ -37,12 +45,12 @@ func (h *MenuHandler) HandleViewPaymentLogs(senderID string) error {
 	logs, err := h.paymentLogRepo.GetPaymentLogsByStudentAndSchoolID(currentYear, currentProfileData.Student.StudentID, currentProfileData.Student.School.SchoolID)
 	if err != nil {
 		log.Printf("Error fetching payment logs: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Failed to fetch payment history. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "payments.fetch_failed"))
 	}
 
 	if len(logs) == 0 {
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID, "You don't have any payment history at the moment.", quickReplies)
+		quickReplies := helpers.GetBack(lang)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "payments.none"), quickReplies)
 	}
 
 	// Sort logs by payment date (newest first)
@@ -57,63 +65,43 @@ func (h *MenuHandler) HandleViewPaymentLogs(senderID string) error {
 	}
 
 	// Send summary message
-	summaryMsg := fmt.Sprintf(
-		"💳 *Your Payment History*\n\n"+
-			"👤 *%s %s*\n"+
-			"📝 Student ID: %s\n"+
-			"🏫 %s\n\n"+
-			"*Total Payments: ₱%.2f*\n"+
-			"*%d transaction(s) found*\n\n"+
-			"Here are your payment records:",
+	summaryMsg := i18n.T(lang, "payments.summary",
 		currentProfileData.Student.FirstName,
 		currentProfileData.Student.LastName,
 		currentProfileData.Student.StudentID,
 		currentProfileData.Student.School.SchoolName,
-		totalPaid,
-		len(logs),
+		i18n.Amount(lang, totalPaid),
+		i18n.Plural(lang, "payments.count", len(logs)),
 	)
 
-	if err := h.fbSvc.SendTextMessage(senderID, summaryMsg); err != nil {
-		log.Printf("Error sending summary message: %v", err)
-	}
+	// Summary, batches and navigation go out as one ordered sequence
+	messages := []facebook.Message{facebook.TextMessage(summaryMsg)}
 
 	const maxLogsPerMessage = 5
 	var currentMessage strings.Builder
 	var currentCount int
 
-	// Function to send the current batch of logs
-	sendCurrentBatch := func() error {
+	// Function to queue the current batch of logs
+	appendCurrentBatch := func() {
 		if currentMessage.Len() == 0 {
-			return nil
+			return
 		}
 
 		// Add progress indicator
-		progress := fmt.Sprintf("\n\n_Showing %d of %d transactions_", currentCount, len(logs))
+		progress := i18n.Plural(lang, "payments.progress", len(logs), currentCount)
 		currentMessage.WriteString(progress)
 
-		if err := h.fbSvc.SendTextMessage(senderID, currentMessage.String()); err != nil {
-			return fmt.Errorf("error sending payment logs batch: %w", err)
-		}
-
-		time.Sleep(300 * time.Millisecond) // Small delay between messages
+		messages = append(messages, facebook.TextMessage(currentMessage.String()))
 		currentMessage.Reset()
-		return nil
 	}
 
 	// Process logs
 	for _, paymentLog := range logs {
 		// Format payment log details
-		details := fmt.Sprintf(
-			"\n📅 *%s*\n"+
-				"   Transaction ID: %s\n"+
-				"   Amount: ₱%.2f\n"+
-				"   Status: %s\n"+
-				"   Reference: %s\n"+
-				"   Payment Type: %s\n"+
-				"   SOA ID: %s\n",
-			paymentLog.DateTimePaid.Format("January 2, 2006 3:04 PM"),
+		details := i18n.T(lang, "payments.item",
+			i18n.DateTime(lang, paymentLog.DateTimePaid),
 			paymentLog.PaymentTxnID,
-			paymentLog.Amount,
+			i18n.Amount(lang, paymentLog.Amount),
 			paymentLog.Status,
 			paymentLog.ProcessID,
 			paymentLog.PaymentType,
@@ -122,9 +110,7 @@ func (h *MenuHandler) HandleViewPaymentLogs(senderID string) error {
 
 		// Check if we need to send the current batch
 		if currentMessage.Len()+len(details) > 1800 {
-			if err := sendCurrentBatch(); err != nil {
-				log.Printf("Error sending batch: %v", err)
-			}
+			appendCurrentBatch()
 		}
 
 		currentMessage.WriteString(details)
@@ -132,20 +118,17 @@ func (h *MenuHandler) HandleViewPaymentLogs(senderID string) error {
 
 		// Check if we've reached the max items per message
 		if currentCount%maxLogsPerMessage == 0 {
-			if err := sendCurrentBatch(); err != nil {
-				log.Printf("Error sending batch: %v", err)
-			}
+			appendCurrentBatch()
 		}
 	}
 
-	// Send any remaining logs
+	// Queue any remaining logs
 	if currentMessage.Len() > 0 {
-		if err := sendCurrentBatch(); err != nil {
-			log.Printf("Error sending final batch: %v", err)
-		}
+		appendCurrentBatch()
 	}
 
 	// Send final message with navigation options
-	quickReplies := helpers.GetBack()
-	return h.fbSvc.SendQuickReplies(senderID, "What would you like to do next?", quickReplies)
+	quickReplies := helpers.GetBack(lang)
+	messages = append(messages, facebook.QuickRepliesMessage(i18n.T(lang, "next.prompt"), quickReplies))
+	return h.fbSvc.SendSequence(senderID, messages)
 }
@@ -3,7 +3,8 @@ package menu
 import (
 	"fmt"
 	"log"
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
 )
@@ -15,30 +16,31 @@ func (h *MenuHandler) ShowProfileMenu(senderID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get user profile: %w", err)
 	}
+	lang := i18n.Of(user.Language)
+
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil {
 		return fmt.Errorf("failed to get user links: %w", err)
 	}
 
 	// Format the profile details message
-	status := "Active"
+	status := i18n.T(lang, "profile.status_active")
 	if !currentProfileData.IsActive {
-		status = "Inactive"
+		status = i18n.T(lang, "profile.status_inactive")
 	}
 
 	fullName := fmt.Sprintf("%s %s", currentProfileData.Student.FirstName, currentProfileData.Student.LastName)
-	message := fmt.Sprintf(
-		constants.ProfileDetailsTemplate,
+	message := i18n.T(lang, "profile.details",
 		fullName,
 		currentProfileData.Student.Course,
 		currentProfileData.Student.YearLevel,
 		status,
 	)
-	if err := h.stateManager.SetState(senderID, state.StateProfileMenu, nil); err != nil {
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateProfileMenu}); err != nil {
 		log.Printf("Error setting state: %v", err)
 	}
 
-	quickReplies := helpers.GetBack()
+	quickReplies := helpers.GetBack(lang)
 	return h.fbSvc.SendQuickReplies(senderID, message, quickReplies)
 }
 
@@ -46,19 +48,24 @@ func (h *MenuHandler) ShowProfileMenu(senderID string) error {
 func (h *MenuHandler) HandleProfileMenuSelection(senderID, selection string) error {
 	switch selection {
 	case "1": // Subjects Enrolled
+		if ok, err := h.RequireFeature(senderID, access.Grades); !ok {
+			return err
+		}
 		return h.HandleViewSubjects(senderID)
 	case "2": // Switch Accounts
-		if err := h.stateManager.SetState(senderID, state.StateConfirmProfileSwitch, nil); err != nil {
-			log.Printf("Error setting switch profile state: %v", err)
-		}
-		quickReplies := helpers.GetConfirmProfileSwitch()
-		return h.fbSvc.SendQuickReplies(senderID, "Please confirm you want to switch accounts.", quickReplies)
+		return h.ShowConfirmProfileSwitch(senderID)
 	default:
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(
-			senderID,
-			"⚠️ Invalid selection. Please choose a valid option.",
-			quickReplies,
-		)
+		lang := h.utils.Lang(senderID)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "profile.invalid"), helpers.GetBack(lang))
+	}
+}
+
+// ShowConfirmProfileSwitch asks the user to confirm switching accounts
+func (h *MenuHandler) ShowConfirmProfileSwitch(senderID string) error {
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateConfirmProfileSwitch}); err != nil {
+		log.Printf("Error setting switch profile state: %v", err)
 	}
+	lang := h.utils.Lang(senderID)
+	quickReplies := helpers.GetConfirmProfileSwitch(lang)
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "profile.confirm_switch"), quickReplies)
 }
@@ -3,6 +3,7 @@ package menu
 import (
 	"fmt"
 	"log"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
 	"sort"
@@ -16,6 +17,7 @@ func (h *MenuHandler) HandleViewSubjects(senderID string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get user profile: %w", err)
 	}
+	lang := i18n.Of(user.Language)
 
 	profile, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil {
@@ -30,7 +32,7 @@ func (h *MenuHandler) HandleViewSubjects(senderID string) error {
 
 	// Group subjects by school year
 	yearMap := make(map[string]bool)
-	yearOptions := make(map[string]string)
+	yearOptions := make(state.YearChoices)
 	var years []string
 
 	for _, subj := range subjects {
@@ -48,11 +50,10 @@ func (h *MenuHandler) HandleViewSubjects(senderID string) error {
 	// Build the message
 	var message string
 	if len(years) == 0 {
-		message = "*No enrolled subjects found.*"
+		message = i18n.T(lang, "subjects.none")
 	} else {
 		var sb strings.Builder
-		sb.WriteString("*View Subjects by School Year*\n\n")
-		sb.WriteString("Please select a school year to view subjects:\n\n")
+		sb.WriteString(i18n.T(lang, "subjects.years"))
 
 		for i, year := range years {
 			sb.WriteString(fmt.Sprintf("[%d] %s\n", i+1, year))
@@ -62,15 +63,11 @@ func (h *MenuHandler) HandleViewSubjects(senderID string) error {
 		message = sb.String()
 
 	}
-	stateData := map[string]any{
-		state.KeySchoolYearMap: yearOptions,
-	}
-
-	if err := h.stateManager.SetState(senderID, state.StateSelectSubject, stateData); err != nil {
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateSelectSubject}, yearOptions); err != nil {
 		log.Printf("Error setting view subjects state: %v", err)
 	}
 
-	quickReplies := helpers.GetBack()
+	quickReplies := helpers.GetBack(lang)
 	return h.fbSvc.SendQuickReplies(senderID, message, quickReplies)
 }
 
@@ -81,6 +78,7 @@ func (h *MenuHandler) HandleViewSubjectsByYear(senderID, year string) error {
 	if err != nil {
 		return fmt.Errorf("failed to get user profile: %w", err)
 	}
+	lang := i18n.Of(user.Language)
 
 	profile, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil {
@@ -95,10 +93,7 @@ func (h *MenuHandler) HandleViewSubjectsByYear(senderID, year string) error {
 
 	// Build the message
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("📚 *Subjects for School Year %s*\n\n"+
-		"*Name:* %s %s\n"+
-		"*School:* %s\n"+
-		"*Course:* %s\n\n",
+	sb.WriteString(i18n.T(lang, "subjects.header",
 		year,
 		profile.Student.FirstName,
 		profile.Student.LastName,
@@ -107,13 +102,10 @@ func (h *MenuHandler) HandleViewSubjectsByYear(senderID, year string) error {
 	))
 
 	if len(subjects) == 0 {
-		sb.WriteString("No subjects found for this school year.")
+		sb.WriteString(i18n.T(lang, "subjects.none_for_year"))
 	} else {
 		for _, subj := range subjects {
-			sb.WriteString(fmt.Sprintf("• *%s*\n"+
-				"   Subject Unit: %s\n"+
-				"   Schedule: %s\n"+
-				"   Room: %s\n\n",
+			sb.WriteString(i18n.T(lang, "subjects.item",
 				subj.SubjectDescription,
 				subj.SubjectUnit,
 				subj.SubjectSchedule,
@@ -122,10 +114,10 @@ func (h *MenuHandler) HandleViewSubjectsByYear(senderID, year string) error {
 		}
 	}
 
-	// Update state to go back to subjects view
-	if err := h.stateManager.SetState(senderID, state.StateViewSubjects, nil); err != nil {
+	screen := state.Screen{State: state.StateViewSubjects, Params: map[string]string{state.ParamYear: year}}
+	if err := state.EnterScreen(h.stateManager, senderID, screen); err != nil {
 		log.Printf("Error setting state: %v", err)
 	}
 
-	return h.fbSvc.SendQuickReplies(senderID, sb.String(), helpers.GetBack())
+	return h.fbSvc.SendQuickReplies(senderID, sb.String(), helpers.GetBack(lang))
 }
@@ -5,7 +5,7 @@ import (
 	"log"
 	"strings"
 
-	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/models"
 	"school-assistant-wh/internal/services/helpers"
 	"school-assistant-wh/internal/state"
@@ -17,24 +17,27 @@ func (h *MenuHandler) AskSupport(senderID string) error {
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "An error occurred. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "error.later"))
 	}
+	lang := i18n.Of(user.Language)
 
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil || currentProfileData == nil {
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select View Profiles and choose a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	// Set state to expect a support message
-	h.stateManager.SetState(senderID, state.StateAskSupport, nil)
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateAskSupport}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
 
 	// Ask user to type their support message
-	message := "You may now type your inquiry message below or view your support tickets."
-	quickReplies := helpers.GetAskSupportReplies()
+	message := i18n.T(lang, "support.prompt")
+	quickReplies := helpers.GetAskSupportReplies(lang)
 	return h.fbSvc.SendQuickReplies(senderID, message, quickReplies)
 }
 
@@ -43,16 +46,17 @@ func (h *MenuHandler) AddSupportMessage(senderID, message, threadID string) erro
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "An error occurred. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "error.later"))
 	}
+	lang := i18n.Of(user.Language)
 
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil || currentProfileData == nil {
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	if threadID == "" {
@@ -70,15 +74,13 @@ func (h *MenuHandler) AddSupportMessage(senderID, message, threadID string) erro
 		threadID, err = h.supportRepo.CreateThread(thread, currentProfileData.Student.School.SchoolID)
 		if err != nil {
 			log.Printf("Error creating support thread: %v", err)
-			return h.utils.SendResponseWithQuickReplies(senderID, "Failed to create support ticket. Please try again later.")
+			return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.create_failed"))
 		}
 
-		h.stateManager.SetState(senderID, state.StateAskSupport, map[string]any{
-			state.KeyThreadID: threadID,
-		})
+		h.stateManager.SetState(senderID, state.StateAskSupport, state.SupportThread{ThreadID: threadID})
 
 		// Send confirmation that a new ticket was created
-		confirmationMsg := fmt.Sprintf("✅ Support ticket #%s has been created.\n\n", threadID)
+		confirmationMsg := i18n.T(lang, "support.created", threadID)
 		err = h.utils.SendResponseWithQuickReplies(senderID, confirmationMsg)
 		if err != nil {
 			log.Printf("Error sending confirmation message: %v", err)
@@ -96,11 +98,11 @@ func (h *MenuHandler) AddSupportMessage(senderID, message, threadID string) erro
 	err = h.supportRepo.CreateMessage(supportMessage, currentProfileData.Student.School.SchoolID)
 	if err != nil {
 		log.Printf("Error saving support message: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Failed to send your message. Please try again.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.send_failed"))
 	}
 
-	h.stateManager.SetState(senderID, state.StateInitial, nil)
-	return h.utils.SendResponseWithQuickReplies(senderID, "✅ Your message has been sent. Our support team will respond as soon as possible.")
+	h.stateManager.SetState(senderID, state.StateInitial)
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.sent"))
 }
 
 // ListSupportTickets displays the user's support tickets and allows selection
@@ -108,38 +110,39 @@ func (h *MenuHandler) ListSupportTickets(senderID string) error {
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "An error occurred. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "error.later"))
 	}
+	lang := i18n.Of(user.Language)
 
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil || currentProfileData == nil {
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	tickets, err := h.supportRepo.GetThreadsByBorrowerID(currentProfileData.Student.BorrowerID, currentProfileData.Student.School.SchoolID)
 	if err != nil {
 		log.Printf("Error fetching support tickets: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Failed to fetch your support tickets. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.fetch_failed"))
 	}
 
 	if len(tickets) == 0 {
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID, "You don't have any support tickets yet.", quickReplies)
+		quickReplies := helpers.GetBack(lang)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "support.none"), quickReplies)
 	}
 
 	var message strings.Builder
-	message.WriteString("📋 Your Support Tickets:\n\n")
+	message.WriteString(i18n.T(lang, "support.list"))
 
-	ticketMap := make(map[string]string)
+	ticketMap := make(state.TicketChoices)
 
 	for i, ticket := range tickets {
 		status := ""
 		if ticket.Status != nil && *ticket.Status == "CLOSED" {
-			status = " (Closed)"
+			status = i18n.T(lang, "support.list_closed")
 		}
 		ticketNumber := fmt.Sprint(i + 1)
 		ticketMap[ticketNumber] = ticket.ThreadID
@@ -150,13 +153,13 @@ func (h *MenuHandler) ListSupportTickets(senderID string) error {
 		}
 	}
 
-	message.WriteString("\nPlease reply with the number of the ticket you want to view.")
+	message.WriteString(i18n.T(lang, "support.list_prompt"))
 
-	h.stateManager.SetState(senderID, state.StateSelectSupportTicket, map[string]any{
-		state.KeyTicketMap: ticketMap,
-	})
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateSelectSupportTicket}, ticketMap); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
 
-	quickReplies := helpers.GetBack()
+	quickReplies := helpers.GetBack(lang)
 	return h.fbSvc.SendQuickReplies(senderID, message.String(), quickReplies)
 }
 
@@ -165,73 +168,77 @@ func (h *MenuHandler) HandleSupportTicketSelection(senderID, ticketID string) er
 	user, err := h.repo.GetUserByPSID(senderID)
 	if err != nil {
 		log.Printf("Error getting user: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "An error occurred. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(h.utils.Lang(senderID), "error.later"))
 	}
+	lang := i18n.Of(user.Language)
 
 	if !user.IsActive {
-		return h.utils.SendResponseWithQuickReplies(senderID, constants.AccountDeactivatedMessage)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
 	}
 
 	currentProfileData, err := h.linkRepo.GetPrimaryLink(int(user.ID))
 	if err != nil || currentProfileData == nil {
-		return h.utils.SendResponseWithQuickReplies(senderID, "No active profile found. Please select a profile to continue.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
 	}
 
 	// Get the ticket details
 	ticket, err := h.supportRepo.GetThread(ticketID, currentProfileData.Student.School.SchoolID)
 	if err != nil {
 		log.Printf("Error fetching ticket %s: %v", ticketID, err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Could not find the selected ticket. Please try again.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.not_found"))
 	}
 
 	// Get ticket messages
 	messages, err := h.supportRepo.GetMessages(ticketID, currentProfileData.Student.School.SchoolID)
 	if err != nil {
 		log.Printf("Error fetching messages for ticket %s: %v", ticketID, err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Failed to load ticket messages. Please try again.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.messages_failed"))
+	}
+
+	screen := state.Screen{State: state.StateViewTicketDetails, Params: map[string]string{state.ParamTicketID: ticketID}}
+	if err := state.EnterScreen(h.stateManager, senderID, screen); err != nil {
+		log.Printf("Error setting state: %v", err)
 	}
 
 	var message strings.Builder
-	status := "OPEN"
+	status := i18n.T(lang, "support.status_open")
 	if ticket.Status != nil && *ticket.Status == "CLOSED" {
-		status = "CLOSED"
+		status = i18n.T(lang, "support.status_closed")
 	}
 
-	message.WriteString(fmt.Sprintf("📋 Ticket #%s (%s)\n\n", ticket.ThreadID, status))
+	message.WriteString(i18n.T(lang, "support.ticket", ticket.ThreadID, status))
 
 	if ticket.HelpTopic != "" {
-		message.WriteString(fmt.Sprintf("Topic: %s\n", ticket.HelpTopic))
+		message.WriteString(i18n.T(lang, "support.topic", ticket.HelpTopic))
 	}
 
-	message.WriteString("\n💬 Conversation:\n\n")
+	message.WriteString(i18n.T(lang, "support.conversation"))
 
 	// Add messages
 	for _, msg := range messages {
-		timestamp := ""
+		sender := i18n.T(lang, "support.agent")
+		if msg.ThreadType == "0" {
+			sender = i18n.T(lang, "support.you")
+		}
 		if !msg.DateTimeIN.IsZero() {
-			timestamp = msg.DateTimeIN.Format("Jan 2, 3:04 PM") + "\n"
+			sender = fmt.Sprintf("%s %s", sender, i18n.DateTime(lang, msg.DateTimeIN))
 		}
 
-		if msg.ThreadType == "0" {
-			message.WriteString(fmt.Sprintf("%s\n", timestamp, "You:"))
-			message.WriteString(fmt.Sprintf("\n", msg.Message))
-		} else {
-			message.WriteString(fmt.Sprintf("%s\n", timestamp, "Support:"))
-			message.WriteString(fmt.Sprintf("%s\n", msg.Message))
-		}
+		message.WriteString(fmt.Sprintf("%s\n", sender))
+		message.WriteString(fmt.Sprintf("%s\n", msg.Message))
 	}
 
 	if ticket.Status != nil && *ticket.Status == "CLOSED" {
-		quickReplies := helpers.GetBack()
-		return h.fbSvc.SendQuickReplies(senderID, message.String()+"\n\nThis ticket is closed. You can view other tickets or go back.", quickReplies)
+		quickReplies := helpers.GetBack(lang)
+		return h.fbSvc.SendQuickReplies(senderID, message.String()+i18n.T(lang, "support.closed_footer"), quickReplies)
 	}
 
-	err = h.fbSvc.SendTextMessage(senderID, message.String()+"\n\nYou can select another ticket to view its details or go back to the main menu.")
+	err = h.fbSvc.SendTextMessage(senderID, message.String()+i18n.T(lang, "support.open_footer"))
 	if err != nil {
 		log.Printf("Error sending message: %v", err)
-		return h.utils.SendResponseWithQuickReplies(senderID, "Failed to load the conversation. Please try again later.")
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "support.load_failed"))
 	}
 
-	quickReplies := helpers.GetBack()
-	return h.fbSvc.SendQuickReplies(senderID, "What would you like to do next?", quickReplies)
+	quickReplies := helpers.GetBack(lang)
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "next.prompt"), quickReplies)
 }
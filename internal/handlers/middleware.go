@@ -1,18 +1,40 @@
 package handlers
 
 import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"school-assistant-wh/internal/config"
 	"school-assistant-wh/internal/state"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	signatureHeader = "X-Hub-Signature-256"
+	signaturePrefix = "sha256="
+
+	adminKeyHeader = "X-Admin-Key"
+	// adminSchoolKey holds the school an admin request is limited to, "" for all
+	adminSchoolKey = "adminSchool"
+
+	// maxWebhookBodySize caps how much of a webhook body we read before verifying it
+	maxWebhookBodySize = 1 << 20
+)
+
 type StateAwareHandler struct {
-	stateManager *state.StateManager
-	handler      func(c *gin.Context, userID string, currentState state.State, stateData map[string]interface{})
+	stateManager state.StateManager
+	handler      func(c *gin.Context, userID string, currentState state.State, stateData state.Data)
 }
 
-func NewStateAwareHandler(sm *state.StateManager, handler func(c *gin.Context, userID string, currentState state.State, stateData map[string]interface{})) *StateAwareHandler {
+func NewStateAwareHandler(sm state.StateManager, handler func(c *gin.Context, userID string, currentState state.State, stateData state.Data)) *StateAwareHandler {
 	return &StateAwareHandler{
 		stateManager: sm,
 		handler:      handler,
@@ -30,18 +52,20 @@ func (h *StateAwareHandler) Handle(c *gin.Context) {
 	userIDStr := userID.(string)
 	currentState, stateData := h.stateManager.GetState(userIDStr)
 
-	h.stateManager.SetState(userIDStr, currentState, nil)
+	h.stateManager.SetState(userIDStr, currentState)
 
 	h.handler(c, userIDStr, currentState, stateData)
 }
 
-func StateCleanupMiddleware(sm *state.StateManager, cleanupInterval, stateTTL time.Duration) gin.HandlerFunc {
+func StateCleanupMiddleware(sm state.StateManager, cleanupInterval, stateTTL time.Duration) gin.HandlerFunc {
 	go func() {
 		ticker := time.NewTicker(cleanupInterval)
 		defer ticker.Stop()
 
 		for range ticker.C {
-			sm.CleanupInactive(stateTTL)
+			if err := sm.CleanupInactive(stateTTL); err != nil {
+				log.Printf("Error cleaning up states: %v", err)
+			}
 		}
 	}()
 
@@ -49,3 +73,113 @@ func StateCleanupMiddleware(sm *state.StateManager, cleanupInterval, stateTTL ti
 		c.Next()
 	}
 }
+
+// VerifySignatureMiddleware rejects webhook POSTs whose X-Hub-Signature-256 header
+// is not the HMAC-SHA256 of the raw body keyed with the app secret. The body is
+// restored afterwards so the next handler can still bind it.
+func VerifySignatureMiddleware(appSecret string) gin.HandlerFunc {
+	if appSecret == "" {
+		log.Println("Warning: FB_APP_SECRET is not set, all webhook events will be rejected")
+	}
+
+	return func(c *gin.Context) {
+		// Only event deliveries are signed; the GET verification handshake is not
+		if c.Request.Method != http.MethodPost {
+			c.Next()
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxWebhookBodySize))
+		if err != nil {
+			log.Printf("Webhook signature audit: rejected request from %s: failed to read body: %v", c.ClientIP(), err)
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid signature"})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		signature := c.GetHeader(signatureHeader)
+		if !validSignature(appSecret, signature, body) {
+			log.Printf("Webhook signature audit: rejected request from %s (user agent %q, signature present: %t, body size: %d)",
+				c.ClientIP(), c.Request.UserAgent(), signature != "", len(body))
+			c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "Invalid signature"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// validSignature checks a "sha256=<hex>" signature against the expected HMAC of body
+func validSignature(appSecret, signature string, body []byte) bool {
+	if appSecret == "" || !strings.HasPrefix(signature, signaturePrefix) {
+		return false
+	}
+
+	got, err := hex.DecodeString(strings.TrimPrefix(signature, signaturePrefix))
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, []byte(appSecret))
+	mac.Write(body)
+	return hmac.Equal(got, mac.Sum(nil))
+}
+
+// AdminAuthMiddleware only lets through requests carrying the admin API key,
+// either in the X-Admin-Key header or as a bearer token. An empty key rejects
+// every request.
+func AdminAuthMiddleware(apiKey string) gin.HandlerFunc {
+	if apiKey == "" {
+		log.Println("Warning: ADMIN_API_KEY is not set, all admin requests will be rejected")
+	}
+
+	return func(c *gin.Context) {
+		key := adminKey(c)
+		if apiKey == "" || subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) != 1 {
+			log.Printf("Admin audit: rejected %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// AdminScopeMiddleware lets through requests carrying the admin API key, which
+// may manage every school, or a school's key, which may only manage that
+// school. The school is kept for adminSchool.
+func AdminScopeMiddleware(cfg config.AdminConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := adminKey(c)
+
+		if key != "" && cfg.APIKey != "" && subtle.ConstantTimeCompare([]byte(key), []byte(cfg.APIKey)) == 1 {
+			c.Set(adminSchoolKey, "")
+			c.Next()
+			return
+		}
+		for schoolID, schoolKey := range cfg.SchoolKeys {
+			if key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(schoolKey)) == 1 {
+				c.Set(adminSchoolKey, schoolID)
+				c.Next()
+				return
+			}
+		}
+
+		log.Printf("Admin audit: rejected %s %s from %s", c.Request.Method, c.Request.URL.Path, c.ClientIP())
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+	}
+}
+
+// adminSchool returns the school the admin request is limited to, "" when it
+// may manage every school
+func adminSchool(c *gin.Context) string {
+	return c.GetString(adminSchoolKey)
+}
+
+// adminKey returns the key from the X-Admin-Key header or a bearer token
+func adminKey(c *gin.Context) string {
+	if key := c.GetHeader(adminKeyHeader); key != "" {
+		return key
+	}
+	return strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+}
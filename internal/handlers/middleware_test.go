@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"school-assistant-wh/internal/config"
+)
+
+func sign(secret, body string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(body))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifySignatureMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "app-secret"
+	body := `{"object":"page","entry":[]}`
+	large := strings.Repeat("a", maxWebhookBodySize+1)
+
+	tests := []struct {
+		name      string
+		body      string
+		signature string
+		want      int
+	}{
+		{"valid", body, signaturePrefix + sign(secret, body), http.StatusOK},
+		{"bad signature", body, signaturePrefix + sign("other-secret", body), http.StatusForbidden},
+		{"missing header", body, "", http.StatusForbidden},
+		{"wrong prefix", body, "sha1=" + sign(secret, body), http.StatusForbidden},
+		{"body over limit", large, signaturePrefix + sign(secret, large), http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got string
+			r := gin.New()
+			r.POST("/webhook", VerifySignatureMiddleware(secret), func(c *gin.Context) {
+				b, _ := c.GetRawData()
+				got = string(b)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(tt.body))
+			if tt.signature != "" {
+				req.Header.Set(signatureHeader, tt.signature)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusOK && got != tt.body {
+				t.Fatalf("handler read body %q, want %q", got, tt.body)
+			}
+		})
+	}
+}
+
+func TestAdminScopeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	cfg := config.AdminConfig{
+		APIKey:     "admin-key",
+		SchoolKeys: map[string]string{"sch1": "school-key"},
+	}
+
+	tests := []struct {
+		name       string
+		cfg        config.AdminConfig
+		header     string
+		value      string
+		want       int
+		wantSchool string
+	}{
+		{"admin key", cfg, adminKeyHeader, "admin-key", http.StatusOK, ""},
+		{"admin bearer token", cfg, "Authorization", "Bearer admin-key", http.StatusOK, ""},
+		{"school key", cfg, adminKeyHeader, "school-key", http.StatusOK, "sch1"},
+		{"wrong key", cfg, adminKeyHeader, "other-key", http.StatusUnauthorized, ""},
+		{"missing key", cfg, "", "", http.StatusUnauthorized, ""},
+		{"no keys configured", config.AdminConfig{}, "", "", http.StatusUnauthorized, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var school string
+			r := gin.New()
+			r.GET("/admin/v1/schools", AdminScopeMiddleware(tt.cfg), func(c *gin.Context) {
+				school = adminSchool(c)
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/admin/v1/schools", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if school != tt.wantSchool {
+				t.Fatalf("school = %q, want %q", school, tt.wantSchool)
+			}
+		})
+	}
+}
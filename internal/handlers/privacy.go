@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/privacy"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// showMyData tells the user what the bot stores about them and offers to
+// export it or delete their account
+func (h *Handler) showMyData(senderID string) error {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return h.handleGetStarted(senderID)
+	}
+	lang := i18n.Of(user.Language)
+
+	if !user.IsActive {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
+	}
+
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateMyData}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "privacy.menu"), helpers.GetMyDataReplies(lang))
+}
+
+// handleMyData routes the My Data menu options
+func (h *Handler) handleMyData(senderID string, in stateInput) error {
+	switch in.command {
+	case "1", helpers.PayloadExportData:
+		return h.handleExportData(senderID)
+	case "2", helpers.PayloadDeleteAccount:
+		return h.showConfirmDeleteAccount(senderID)
+	default:
+		return errInvalidInput
+	}
+}
+
+// handleExportData sends the user signed links to download their data
+func (h *Handler) handleExportData(senderID string) error {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	expiresAt := time.Now().Add(h.privacyCfg.ExportTTL)
+	token, err := h.exportTokens.Issue(int(user.ID), expiresAt)
+	if err != nil || h.privacyCfg.PublicBaseURL == "" {
+		log.Printf("Error issuing export token for %s: %v", senderID, err)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "privacy.export_unavailable"), helpers.GetMyDataReplies(lang))
+	}
+
+	url := h.privacyCfg.PublicBaseURL + "/privacy/export/" + token
+	message := i18n.T(lang, "privacy.export_ready", expiresAt.Format("Jan 2, 2006 3:04 PM"), url, url+"?format=json")
+	return h.fbSvc.SendQuickReplies(senderID, message, helpers.GetMyDataReplies(lang))
+}
+
+// showConfirmDeleteAccount asks the user to confirm deleting their account
+func (h *Handler) showConfirmDeleteAccount(senderID string) error {
+	lang := h.utils.Lang(senderID)
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateConfirmDeleteAccount}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "privacy.confirm_delete"), helpers.GetConfirmDeleteAccountReplies(lang))
+}
+
+// showConfirmDeleteForever asks the user a second time, as deleting the
+// account cannot be undone
+func (h *Handler) showConfirmDeleteForever(senderID string) error {
+	lang := h.utils.Lang(senderID)
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateConfirmDeleteForever}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "privacy.confirm_delete_final"), helpers.GetDeleteForeverReplies(lang))
+}
+
+// handleDeleteForever deletes the user's account once they confirmed twice:
+// their links and stored messages are removed, their details scrubbed and
+// everything cached or kept in their conversation state forgotten
+func (h *Handler) handleDeleteForever(senderID string, in stateInput) error {
+	if in.command != helpers.PayloadDeleteForever {
+		return errInvalidInput
+	}
+
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	// Deleting is all or nothing, so a failure leaves the account as it was
+	var removed int
+	err = h.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if removed, err = h.linkRepo.WithTx(tx).RemoveAllLinks(int(user.ID)); err != nil {
+			return err
+		}
+		if err := h.messageRepo.WithTx(tx).DeleteBySender(senderID); err != nil {
+			return err
+		}
+		return h.repo.WithTx(tx).ScrubUser(user)
+	})
+	if err != nil {
+		log.Printf("Error deleting account of %s: %v", senderID, err)
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "privacy.delete_failed"))
+	}
+
+	if err := h.stateManager.ClearState(senderID); err != nil {
+		log.Printf("Error clearing state: %v", err)
+	}
+	h.deferredNotices.resume(senderID)
+
+	// The audit entry outlives the account, so it only keeps the user's row ID
+	err = h.auditRepo.Record(models.LinkAudit{
+		UserID: int(user.ID),
+		Method: models.LinkMethodSelfService,
+		Event:  models.LinkEventAccountDeleted,
+		Detail: fmt.Sprintf("%d link(s) removed", removed),
+	})
+	if err != nil {
+		log.Printf("Error auditing account deletion: %v", err)
+	}
+
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "privacy.deleted"), helpers.GetQuickReplies(lang, constants.UserStatusUnregistered))
+}
+
+// DownloadExport serves the data export a signed URL from My Data points at,
+// as an HTML page or as JSON with ?format=json
+func (h *Handler) DownloadExport(c *gin.Context) {
+	userID, err := h.exportTokens.Verify(c.Param("token"), time.Now())
+	if err != nil {
+		c.String(http.StatusNotFound, i18n.T(i18n.Default, "privacy.link_expired"))
+		return
+	}
+
+	// Deleted accounts are deactivated, so their links stop working at once
+	user, err := h.repo.GetUserByID(userID)
+	if err != nil || !user.IsActive {
+		c.String(http.StatusNotFound, i18n.T(i18n.Default, "privacy.link_expired"))
+		return
+	}
+
+	export, err := h.compileExport(user)
+	if err != nil {
+		log.Printf("Error compiling export for user %d: %v", userID, err)
+		c.String(http.StatusInternalServerError, i18n.T(i18n.Of(user.Language), "error.later"))
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	if c.Query("format") == "json" {
+		c.Header("Content-Disposition", `attachment; filename="my-data.json"`)
+		c.Header("Content-Type", "application/json; charset=utf-8")
+		err = privacy.WriteJSON(c.Writer, *export)
+	} else {
+		c.Header("Content-Disposition", `attachment; filename="my-data.html"`)
+		c.Header("Content-Type", "text/html; charset=utf-8")
+		err = privacy.WriteHTML(c.Writer, i18n.Of(user.Language), *export)
+	}
+	if err != nil {
+		log.Printf("Error writing export for user %d: %v", userID, err)
+	}
+}
+
+// compileExport gathers the user's account, every link they have had, their
+// stored messages and the support tickets of the students they are linked to
+func (h *Handler) compileExport(user *models.User) (*privacy.Export, error) {
+	links, err := h.linkRepo.ListAllLinks(int(user.ID))
+	if err != nil {
+		return nil, err
+	}
+	messages, err := h.messageRepo.ListBySender(user.PSID)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &privacy.Export{
+		GeneratedAt:    time.Now(),
+		Account:        privacy.AccountOf(*user),
+		Links:          privacy.LinksOf(links),
+		Messages:       privacy.MessagesOf(messages),
+		SupportTickets: []privacy.SupportTicket{},
+	}
+
+	seen := make(map[string]bool)
+	for _, link := range links {
+		key := link.SchoolID + "/" + link.StudentID
+		if !link.IsActive || seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		student, err := h.studentRepo.GetStudentProfile(link.SchoolID, link.StudentID)
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && student.BorrowerID == "") {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get student %s: %w", key, err)
+		}
+
+		threads, err := h.supportRepo.GetThreadsByBorrowerID(student.BorrowerID, link.SchoolID)
+		if err != nil {
+			return nil, err
+		}
+		for _, thread := range threads {
+			replies, err := h.supportRepo.GetMessages(thread.ThreadID, link.SchoolID)
+			if err != nil {
+				return nil, err
+			}
+			export.SupportTickets = append(export.SupportTickets, privacy.TicketOf(link.SchoolID, *thread, replies))
+		}
+	}
+	return export, nil
+}
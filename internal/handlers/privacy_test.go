@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"errors"
+	"strings"
+	"testing"
+
+	"school-assistant-wh/internal/fakegraph"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+func TestDeleteForeverIsAllOrNothing(t *testing.T) {
+	const removeLinks = "DELETE FROM `gk_miniapps`.`school_link_user`"
+
+	tests := []struct {
+		name      string
+		failScrub bool
+		want      string
+		reply     string
+	}{
+		{"deleted", false, "COMMIT", "Your account was deleted."},
+		{"scrub fails", true, "ROLLBACK", "Sorry, we couldn't delete your account."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			wt := newWebhookTest(t, "page-token", linkedUserTables()...)
+			wt.db.onExec = func(query string, args []driver.Value) error {
+				if tt.failScrub && strings.HasPrefix(query, "UPDATE `school_messenger_users`") && strings.Contains(query, "`PSID`=?") {
+					return errors.New("lock wait timeout exceeded")
+				}
+				return nil
+			}
+			if err := state.EnterScreen(wt.h.stateManager, "psid-1", state.Screen{State: state.StateConfirmDeleteForever}); err != nil {
+				t.Fatal(err)
+			}
+
+			wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Delete Forever", QuickReply: helpers.PayloadDeleteForever})
+
+			if !wt.db.ran(removeLinks) || !wt.db.ran("DELETE FROM `messages`") {
+				t.Fatal("links and messages were not deleted")
+			}
+			if got := wt.db.endOfTx(removeLinks); got != tt.want {
+				t.Fatalf("removing links ended with %q, want %q", got, tt.want)
+			}
+			if tt.failScrub != wt.db.ran("ROLLBACK") {
+				t.Fatalf("rolled back = %v, want %v", !tt.failScrub, tt.failScrub)
+			}
+			if !tt.failScrub && !wt.db.ran("`LastLoginAt`=DEFAULT") {
+				t.Fatal("LastLoginAt was not reset to its default")
+			}
+			if audited := wt.db.ran("school_messenger_link_audit"); audited == tt.failScrub {
+				t.Fatalf("deletion audited = %v, want %v", audited, !tt.failScrub)
+			}
+			msgs := strings.Join(wt.messages("psid-1"), "\n")
+			if !strings.Contains(msgs, tt.reply) {
+				t.Fatalf("messages = %q, want %q", msgs, tt.reply)
+			}
+		})
+	}
+}
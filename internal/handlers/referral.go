@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/linktoken"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/repositories"
+	"school-assistant-wh/internal/state"
+)
+
+// Refs carried by m.me links, e.g. m.me/page?ref=school_CPEU. A school ref
+// pre-selects the school; a link ref holds a token issued by the school that
+// registers the user and links the student in one step.
+const (
+	refSchoolPrefix = "school_"
+	refLinkPrefix   = "link_"
+)
+
+// handleReferral handles the ref of an m.me link the user opened and reports
+// whether the ref was one the bot understands
+func (h *Handler) handleReferral(senderID, ref string) (bool, error) {
+	switch {
+	case strings.HasPrefix(ref, refLinkPrefix):
+		return true, h.handleLinkRef(senderID, strings.TrimPrefix(ref, refLinkPrefix))
+	case strings.HasPrefix(ref, refSchoolPrefix):
+		return true, h.handleSchoolRef(senderID, strings.TrimPrefix(ref, refSchoolPrefix))
+	default:
+		log.Printf("Ignoring unknown referral ref %q from %s", ref, senderID)
+		return false, nil
+	}
+}
+
+// handleSchoolRef welcomes the user on behalf of the school and remembers it, so
+// the registration steps can name it
+func (h *Handler) handleSchoolRef(senderID, schoolID string) error {
+	school, err := h.schoolRepo.GetSchool(schoolID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Referral from %s names unknown school %q", senderID, schoolID)
+		return h.handleGetStarted(senderID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get school %s: %w", schoolID, err)
+	}
+
+	current, _ := h.stateManager.GetState(senderID)
+	referred := state.ReferredSchool{SchoolID: school.SchoolID, SchoolName: school.SchoolName}
+	if err := h.stateManager.SetState(senderID, current, referred); err != nil {
+		log.Printf("Error saving referred school: %v", err)
+	}
+
+	lang := h.utils.Lang(senderID)
+	return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "welcome.school", school.SchoolName))
+}
+
+// handleLinkRef registers the user and links them to the student the school
+// issued the token for
+func (h *Handler) handleLinkRef(senderID, token string) error {
+	claims, err := h.linkTokens.Verify(token, time.Now())
+	if err != nil {
+		log.Printf("Rejected link token from %s: %v", senderID, err)
+		lang := h.utils.Lang(senderID)
+		if errors.Is(err, linktoken.ErrExpired) {
+			return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "link.token_expired"))
+		}
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "link.token_invalid"))
+	}
+
+	user, err := h.repo.RegisterUser(senderID)
+	if err != nil {
+		log.Printf("Error registering user: %v", err)
+		return h.fbSvc.SendTextMessage(senderID, i18n.T(h.utils.Lang(senderID), "registration.failed"))
+	}
+	lang := i18n.Of(user.Language)
+
+	link, err := h.linkRepo.RedeemLinkToken(linktoken.Signature(token), int(user.ID), claims.SchoolID, claims.StudentID)
+	if errors.Is(err, repositories.ErrLinkTokenRedeemed) {
+		log.Printf("Link token for %s/%s reused by %s", claims.SchoolID, claims.StudentID, senderID)
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "link.token_redeemed"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to redeem link token: %w", err)
+	}
+
+	err = h.auditRepo.Record(models.LinkAudit{
+		PSID:      senderID,
+		UserID:    int(user.ID),
+		SchoolID:  claims.SchoolID,
+		StudentID: claims.StudentID,
+		Method:    models.LinkMethodToken,
+		Event:     models.LinkEventLinked,
+	})
+	if err != nil {
+		log.Printf("Error auditing link: %v", err)
+	}
+
+	return h.finishLink(senderID, lang, link)
+}
+
+// issueLinkTokenRequest names the student a link token is issued for. TTL is a
+// Go duration such as "72h" and defaults to LINK_TOKEN_TTL.
+type issueLinkTokenRequest struct {
+	SchoolID  string `json:"school_id" binding:"required"`
+	StudentID string `json:"student_id" binding:"required"`
+	TTL       string `json:"ttl"`
+}
+
+// IssueLinkToken issues the m.me ref a school shares with a parent to link them
+// to a student
+func (h *Handler) IssueLinkToken(c *gin.Context) {
+	var req issueLinkTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "school_id and student_id are required"})
+		return
+	}
+
+	ttl := h.linkCfg.TokenTTL
+	if req.TTL != "" {
+		d, err := time.ParseDuration(req.TTL)
+		if err != nil || d <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "ttl must be a positive duration such as 72h"})
+			return
+		}
+		ttl = d
+	}
+
+	if _, err := h.studentRepo.GetStudentProfile(req.SchoolID, req.StudentID); err != nil {
+		log.Printf("Error issuing link token for %s/%s: %v", req.SchoolID, req.StudentID, err)
+		c.JSON(http.StatusNotFound, gin.H{"error": "student not found"})
+		return
+	}
+
+	expiresAt := time.Now().Add(ttl)
+	token, err := h.linkTokens.Issue(linktoken.Claims{
+		SchoolID:  req.SchoolID,
+		StudentID: req.StudentID,
+		ExpiresAt: expiresAt,
+	})
+	if err != nil {
+		log.Printf("Error issuing link token: %v", err)
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"ref":        refLinkPrefix + token,
+		"expires_at": expiresAt.UTC().Format(time.RFC3339),
+	})
+}
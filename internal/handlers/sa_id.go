@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// showConfirmRegenerateSaID asks the user to confirm replacing their SA-ID,
+// which stops the current one from working
+func (h *Handler) showConfirmRegenerateSaID(senderID string) error {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return h.handleGetStarted(senderID)
+	}
+	lang := i18n.Of(user.Language)
+
+	if !user.IsActive {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "account.deactivated"))
+	}
+	code := ""
+	if user.Code != nil {
+		code = *user.Code
+	}
+
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateConfirmRegenerateSaID}); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "sa_id.confirm_regenerate", code), helpers.GetConfirmRegenerateSaIDReplies(lang))
+}
+
+// handleConfirmRegenerateSaID replaces the user's SA-ID once they confirm and
+// shows the new one
+func (h *Handler) handleConfirmRegenerateSaID(senderID string, in stateInput) error {
+	if in.command != helpers.PayloadConfirmRegenerateSaID {
+		return errInvalidInput
+	}
+
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	oldCode := ""
+	if user.Code != nil {
+		oldCode = *user.Code
+	}
+	if err := h.repo.RegenerateCode(user, models.CodeRevokedRegenerated, h.linkCfg.SaIDTTL); err != nil {
+		log.Printf("Error regenerating SA-ID for %s: %v", senderID, err)
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "sa_id.regenerate_failed"))
+	}
+
+	if err := h.stateManager.SetState(senderID, state.StateInitial, state.History{}); err != nil {
+		log.Printf("Error resetting state: %v", err)
+	}
+	if err := h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "sa_id.regenerated", oldCode)); err != nil {
+		return fmt.Errorf("failed to send regenerated message: %w", err)
+	}
+	return h.accountHdlr.HandleViewSaID(senderID)
+}
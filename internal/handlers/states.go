@@ -0,0 +1,523 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"strconv"
+	"time"
+
+	"school-assistant-wh/internal/access"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/services/facebook"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// errInvalidInput is returned by a state's input handler for input it does not
+// understand, so the state's declared invalid reply is sent
+var errInvalidInput = errors.New("invalid input")
+
+// stateInput is a message received while the user is inside a conversation state
+type stateInput struct {
+	// command is the normalized payload or text used for routing
+	command string
+	// text is what the user actually typed, for states expecting free-form input
+	text string
+	data state.Data
+}
+
+// stateHandler declares how a conversation state reacts to user input
+type stateHandler struct {
+	// parent is the state BACK returns to when the navigation history is empty
+	parent state.State
+	// show renders the state with the parameters it was recorded with
+	show func(senderID string, params map[string]string) error
+	// input handles everything except BACK and the allowed global commands
+	input func(senderID string, in stateInput) error
+	// commands are the global commands that leave the state; nil allows all of
+	// them. MENU, HELP, CANCEL and the other commands in h.commands always apply.
+	commands []string
+	// feature is what the user's role must be allowed to use to be in the state
+	feature access.Feature
+	// help is the catalog ID of the options valid in the state, listed by HELP
+	help string
+	// invalid is the catalog ID of the reply to input the state does not understand
+	invalid        string
+	invalidReplies func(lang i18n.Lang) []facebook.QuickReply
+}
+
+// allows reports whether command should be handled as a global command rather
+// than as input to the state
+func (s stateHandler) allows(command string) bool {
+	if !helpers.IsGlobalCommand(command) {
+		return false
+	}
+	if s.commands == nil {
+		return true
+	}
+	for _, c := range s.commands {
+		if c == command {
+			return true
+		}
+	}
+	return false
+}
+
+// registerState adds the definition of a conversation state
+func (h *Handler) registerState(s state.State, def stateHandler) {
+	if def.invalidReplies == nil {
+		def.invalidReplies = helpers.GetBack
+	}
+	if def.invalid == "" {
+		def.invalid = "invalid.default"
+	}
+	h.states[s] = def
+}
+
+// registerStates declares every conversation state the bot can be in
+func (h *Handler) registerStates() {
+	h.registerState(state.StateMainMenu, stateHandler{
+		parent: state.StateInitial,
+		help:   "help.main_menu",
+		show:   screen(h.menuHdlr.ShowMainMenu),
+		input: func(senderID string, in stateInput) error {
+			if handled, err := h.handleIntent(senderID, in.text); handled {
+				return err
+			}
+			return h.menuHdlr.MenuHandler(senderID, in.command)
+		},
+	})
+
+	h.registerState(state.StateProfileView, stateHandler{
+		parent: state.StateInitial,
+		help:   "help.profile_view",
+		show:   screen(h.accountHdlr.HandleViewProfile),
+		input:  h.handleProfileSelection,
+	})
+	h.registerState(state.StateProfileSwitch, stateHandler{
+		parent: state.StateProfileView,
+		help:   "help.profile_switch",
+		show:   screen(h.accountHdlr.HandleSwitchProfile),
+		input:  h.handleProfileSelection,
+	})
+
+	h.registerState(state.StateViewGrades, stateHandler{
+		parent:  state.StateMainMenu,
+		feature: access.Grades,
+		help:    "help.grades",
+		show:    screen(h.menuHdlr.HandleViewGrades),
+		input:   h.handleSchoolYearSelection,
+		invalid: "invalid.school_year",
+	})
+	h.registerState(state.StateViewGradesDetails, stateHandler{
+		parent:  state.StateViewGrades,
+		feature: access.Grades,
+		help:    "help.grades_details",
+		show: func(senderID string, params map[string]string) error {
+			return h.menuHdlr.HandleViewGradesByYear(senderID, params[state.ParamYear])
+		},
+		input:   h.handleSchoolYearSelection,
+		invalid: "invalid.grades_details",
+	})
+
+	h.registerState(state.StateViewPayables, stateHandler{
+		parent:  state.StateMainMenu,
+		feature: access.Fees,
+		help:    "help.payables",
+		show:    screen(h.menuHdlr.HandleViewPayables),
+		input: func(senderID string, in stateInput) error {
+			if in.command == helpers.PayloadPaymentLogs {
+				return h.menuHdlr.HandleViewPaymentLogs(senderID)
+			}
+			return errInvalidInput
+		},
+		invalid:        "invalid.payables",
+		invalidReplies: helpers.GetPaymentReplies,
+	})
+	h.registerState(state.StateViewPaymentLogs, stateHandler{
+		parent:  state.StateViewPayables,
+		feature: access.Fees,
+		show:    screen(h.menuHdlr.HandleViewPaymentLogs),
+		input: func(senderID string, in stateInput) error {
+			return errInvalidInput
+		},
+		invalid: "invalid.payment_logs",
+	})
+
+	h.registerState(state.StateViewBulletin, stateHandler{
+		parent:         state.StateMainMenu,
+		feature:        access.Bulletin,
+		help:           "help.bulletin",
+		show:           pagedScreen(h.menuHdlr.HandleViewBulletin),
+		input:          h.nextPage(h.menuHdlr.HandleViewBulletin),
+		invalid:        "invalid.view_more",
+		invalidReplies: helpers.GetViewMoreReplies,
+	})
+	h.registerState(state.StateViewBulletinDetails, stateHandler{
+		parent:  state.StateViewBulletin,
+		feature: access.Bulletin,
+		show: func(senderID string, params map[string]string) error {
+			id, err := strconv.Atoi(params[state.ParamID])
+			if err != nil {
+				return h.enterState(senderID, state.StateViewBulletin)
+			}
+			return h.menuHdlr.HandleBulletinDetails(senderID, id)
+		},
+		input: func(senderID string, in stateInput) error {
+			return errInvalidInput
+		},
+		invalid: "invalid.bulletin_details",
+	})
+	h.registerState(state.StateViewDTR, stateHandler{
+		parent:  state.StateMainMenu,
+		feature: access.Attendance,
+		help:    "help.dtr",
+		show:    h.showDTR,
+		input: func(senderID string, in stateInput) error {
+			if in.command != helpers.PayloadViewMore {
+				return errInvalidInput
+			}
+			pagination, err := state.Get[state.Pagination](in.data)
+			if err != nil {
+				return err
+			}
+			params := map[string]string{state.ParamPage: strconv.Itoa(pagination.Page + 1)}
+			if current, ok := state.CurrentScreen(in.data); ok {
+				params[state.ParamMonth] = current.Params[state.ParamMonth]
+			}
+			return h.showDTR(senderID, params)
+		},
+		invalid:        "invalid.view_more",
+		invalidReplies: helpers.GetViewMoreReplies,
+	})
+
+	h.registerState(state.StateProfileMenu, stateHandler{
+		parent: state.StateMainMenu,
+		help:   "help.profile_menu",
+		show:   screen(h.menuHdlr.ShowProfileMenu),
+		input: func(senderID string, in stateInput) error {
+			if in.command == "3" || in.command == helpers.PayloadUnlinkProfile {
+				return h.showConfirmUnlink(senderID)
+			}
+			return h.menuHdlr.HandleProfileMenuSelection(senderID, in.command)
+		},
+	})
+	h.registerState(state.StateConfirmUnlink, stateHandler{
+		parent:         state.StateProfileMenu,
+		help:           "help.confirm_unlink",
+		show:           screen(h.showConfirmUnlink),
+		input:          h.handleConfirmUnlink,
+		invalid:        "invalid.confirm_unlink",
+		invalidReplies: helpers.GetConfirmUnlinkReplies,
+	})
+	h.registerState(state.StateConfirmRegenerateSaID, stateHandler{
+		parent:         state.StateMainMenu,
+		help:           "help.confirm_regenerate_sa_id",
+		show:           screen(h.showConfirmRegenerateSaID),
+		input:          h.handleConfirmRegenerateSaID,
+		invalid:        "invalid.confirm_regenerate_sa_id",
+		invalidReplies: helpers.GetConfirmRegenerateSaIDReplies,
+	})
+	h.registerState(state.StateMyData, stateHandler{
+		parent:         state.StateMainMenu,
+		help:           "help.my_data",
+		show:           screen(h.showMyData),
+		input:          h.handleMyData,
+		invalid:        "invalid.my_data",
+		invalidReplies: helpers.GetMyDataReplies,
+	})
+	h.registerState(state.StateConfirmDeleteAccount, stateHandler{
+		parent: state.StateMyData,
+		help:   "help.confirm_delete_account",
+		show:   screen(h.showConfirmDeleteAccount),
+		input: func(senderID string, in stateInput) error {
+			if in.command != helpers.PayloadConfirmDeleteAccount {
+				return errInvalidInput
+			}
+			return h.showConfirmDeleteForever(senderID)
+		},
+		invalid:        "invalid.confirm_delete_account",
+		invalidReplies: helpers.GetConfirmDeleteAccountReplies,
+	})
+	h.registerState(state.StateConfirmDeleteForever, stateHandler{
+		parent:         state.StateMyData,
+		help:           "help.confirm_delete_final",
+		show:           screen(h.showConfirmDeleteForever),
+		input:          h.handleDeleteForever,
+		invalid:        "invalid.confirm_delete_final",
+		invalidReplies: helpers.GetDeleteForeverReplies,
+	})
+	h.registerState(state.StateConfirmProfileSwitch, stateHandler{
+		parent: state.StateProfileMenu,
+		help:   "help.confirm_switch",
+		show:   screen(h.menuHdlr.ShowConfirmProfileSwitch),
+		input: func(senderID string, in stateInput) error {
+			if in.command == helpers.PayloadProceed {
+				return h.accountHdlr.HandleSwitchProfile(senderID)
+			}
+			return errInvalidInput
+		},
+		invalid:        "invalid.confirm_switch",
+		invalidReplies: helpers.GetConfirmProfileSwitch,
+	})
+	h.registerState(state.StateSelectSubject, stateHandler{
+		parent:  state.StateProfileMenu,
+		feature: access.Grades,
+		help:    "help.subjects",
+		show:    screen(h.menuHdlr.HandleViewSubjects),
+		input:   h.handleSubjectByYearSelection,
+		invalid: "invalid.school_year",
+	})
+	h.registerState(state.StateViewSubjects, stateHandler{
+		parent:  state.StateSelectSubject,
+		feature: access.Grades,
+		help:    "help.subjects_details",
+		show: func(senderID string, params map[string]string) error {
+			return h.menuHdlr.HandleViewSubjectsByYear(senderID, params[state.ParamYear])
+		},
+		input:   h.handleSubjectByYearSelection,
+		invalid: "invalid.school_year",
+	})
+
+	// Anything typed while asking support is the inquiry itself, so only the
+	// commands honored everywhere leave the state
+	h.registerState(state.StateAskSupport, stateHandler{
+		parent:   state.StateMainMenu,
+		feature:  access.Support,
+		help:     "help.support",
+		show:     screen(h.menuHdlr.AskSupport),
+		commands: []string{},
+		input: func(senderID string, in stateInput) error {
+			if in.command == helpers.PayloadViewTickets {
+				return h.menuHdlr.ListSupportTickets(senderID)
+			}
+			return h.menuHdlr.AddSupportMessage(senderID, in.text, "")
+		},
+	})
+	h.registerState(state.StateViewTickets, stateHandler{
+		parent:  state.StateAskSupport,
+		feature: access.Support,
+		show:    screen(h.menuHdlr.ListSupportTickets),
+		input: func(senderID string, in stateInput) error {
+			return h.menuHdlr.ListSupportTickets(senderID)
+		},
+	})
+	h.registerState(state.StateSelectSupportTicket, stateHandler{
+		parent:  state.StateAskSupport,
+		feature: access.Support,
+		help:    "help.tickets",
+		show:    screen(h.menuHdlr.ListSupportTickets),
+		input:   h.handleSupportTicketSelection,
+		invalid: "invalid.ticket",
+	})
+	h.registerState(state.StateViewTicketDetails, stateHandler{
+		parent:  state.StateSelectSupportTicket,
+		feature: access.Support,
+		help:    "help.ticket_details",
+		show: func(senderID string, params map[string]string) error {
+			return h.menuHdlr.HandleSupportTicketSelection(senderID, params[state.ParamTicketID])
+		},
+		input:   h.handleSupportTicketSelection,
+		invalid: "invalid.ticket",
+	})
+
+	// Student IDs, birthdates and codes are typed freely, so only the commands
+	// honored everywhere leave the link flow
+	h.registerState(state.StateLinkSelectSchool, stateHandler{
+		parent:  state.StateInitial,
+		help:    "help.link_school",
+		show:    screen(h.showLinkSchools),
+		input:   h.handleLinkSchoolSelection,
+		invalid: "invalid.link_school",
+	})
+	h.registerState(state.StateLinkSelectRole, stateHandler{
+		parent:         state.StateLinkSelectSchool,
+		help:           "help.link_role",
+		show:           h.showLinkRole,
+		input:          h.handleLinkRole,
+		invalid:        "invalid.link_role",
+		invalidReplies: helpers.GetLinkRoleReplies,
+	})
+	h.registerState(state.StateLinkEnterStudentID, stateHandler{
+		parent:   state.StateLinkSelectRole,
+		help:     "help.link_student_id",
+		show:     h.showLinkStudentID,
+		commands: []string{},
+		input:    h.handleLinkStudentID,
+	})
+	h.registerState(state.StateLinkEnterBirthdate, stateHandler{
+		parent:   state.StateLinkEnterStudentID,
+		help:     "help.link_birthdate",
+		show:     h.showLinkBirthdate,
+		commands: []string{},
+		input:    h.handleLinkBirthdate,
+		invalid:  "invalid.link_birthdate",
+	})
+	h.registerState(state.StateLinkEnterCode, stateHandler{
+		parent:         state.StateLinkEnterBirthdate,
+		help:           "help.link_code",
+		commands:       []string{},
+		input:          h.handleLinkCode,
+		invalid:        "invalid.link_code",
+		invalidReplies: helpers.GetResendCodeReplies,
+	})
+}
+
+// handleStateMessage routes input received inside a conversation state through
+// the state's definition: BACK returns to the previous screen, anything else goes
+// to its input handler
+func (h *Handler) handleStateMessage(senderID string, current state.State, in stateInput) error {
+	def, ok := h.states[current]
+	if !ok {
+		return h.handleDefault(senderID)
+	}
+
+	if in.command == helpers.PayloadBack {
+		return h.goBack(senderID, def.parent)
+	}
+
+	if def.feature != "" {
+		if ok, err := h.menuHdlr.RequireFeature(senderID, def.feature); !ok {
+			return err
+		}
+	}
+
+	err := def.input(senderID, in)
+	switch {
+	case errors.Is(err, errInvalidInput):
+		// Free text the state does not expect may still ask for another screen
+		if handled, err := h.handleIntent(senderID, in.text); handled {
+			return err
+		}
+		lang := h.utils.Lang(senderID)
+		return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, def.invalid), def.invalidReplies(lang))
+	case errors.Is(err, state.ErrStalePayload):
+		log.Printf("Resetting %s in state %s: %v", senderID, current, err)
+		return h.startOver(senderID)
+	}
+	return err
+}
+
+// startOver resets a conversation whose state can no longer be used and takes
+// the user back home
+func (h *Handler) startOver(senderID string) error {
+	if err := h.stateManager.ClearState(senderID); err != nil {
+		log.Printf("Error clearing state for %s: %v", senderID, err)
+	}
+	if err := h.fbSvc.SendTextMessage(senderID, i18n.T(h.utils.Lang(senderID), "start_over")); err != nil {
+		return err
+	}
+	return h.goHome(senderID)
+}
+
+// goBack re-renders the previous screen in the user's navigation history, or
+// the fallback state when there is no history to go back to
+func (h *Handler) goBack(senderID string, fallback state.State) error {
+	for {
+		screen, ok, err := state.Back(h.stateManager, senderID)
+		if err != nil {
+			log.Printf("Error reading navigation history: %v", err)
+		}
+		if !ok {
+			return h.enterState(senderID, fallback)
+		}
+		def, registered := h.states[screen.State]
+		if !registered || def.show == nil {
+			continue
+		}
+		// Skip screens the user's current profile may no longer open
+		if def.feature != "" {
+			allowed, err := h.menuHdlr.Permits(senderID, def.feature)
+			if err != nil {
+				log.Printf("Error checking access to %s: %v", screen.State, err)
+			}
+			if !allowed {
+				continue
+			}
+		}
+		return def.show(senderID, screen.Params)
+	}
+}
+
+// goHome clears the navigation history and shows the main menu, or the welcome
+// message to users without a linked profile
+func (h *Handler) goHome(senderID string) error {
+	if err := state.ClearHistory(h.stateManager, senderID); err != nil {
+		log.Printf("Error clearing navigation history: %v", err)
+	}
+
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return h.enterState(senderID, state.StateInitial)
+	}
+	if link, err := h.linkRepo.GetPrimaryLink(int(user.ID)); err != nil || link == nil || !user.IsActive {
+		return h.enterState(senderID, state.StateInitial)
+	}
+	return h.enterState(senderID, state.StateMainMenu)
+}
+
+// enterState shows the given state without parameters. States that cannot be
+// shown on their own fall back to their parent.
+func (h *Handler) enterState(senderID string, target state.State) error {
+	def, ok := h.states[target]
+	for ok && def.show == nil {
+		target = def.parent
+		def, ok = h.states[target]
+	}
+
+	if !ok {
+		if err := h.stateManager.SetState(senderID, state.StateInitial, state.History{}); err != nil {
+			log.Printf("Error resetting state: %v", err)
+		}
+		return h.handleGetStarted(senderID)
+	}
+
+	return def.show(senderID, nil)
+}
+
+// screen adapts a handler that renders a screen without parameters
+func screen(show func(senderID string) error) func(string, map[string]string) error {
+	return func(senderID string, params map[string]string) error {
+		return show(senderID)
+	}
+}
+
+// pagedScreen adapts a paginated list handler, restoring the recorded page
+func pagedScreen(showPage func(senderID string, pageNum int) error) func(string, map[string]string) error {
+	return func(senderID string, params map[string]string) error {
+		page, err := strconv.Atoi(params[state.ParamPage])
+		if err != nil || page < 1 {
+			page = 1
+		}
+		return showPage(senderID, page)
+	}
+}
+
+// showDTR renders the attendance month and page recorded in params, defaulting
+// to the first page of the current month
+func (h *Handler) showDTR(senderID string, params map[string]string) error {
+	period, err := time.Parse(state.MonthLayout, params[state.ParamMonth])
+	if err != nil {
+		period = time.Now()
+	}
+	page, err := strconv.Atoi(params[state.ParamPage])
+	if err != nil || page < 1 {
+		page = 1
+	}
+	return h.menuHdlr.HandleViewDTRForMonth(senderID, period, page)
+}
+
+// nextPage builds the input handler of a paginated list, showing the page after
+// the current one on VIEW MORE
+func (h *Handler) nextPage(showPage func(senderID string, pageNum int) error) func(string, stateInput) error {
+	return func(senderID string, in stateInput) error {
+		if in.command != helpers.PayloadViewMore {
+			return errInvalidInput
+		}
+		pagination, err := state.Get[state.Pagination](in.data)
+		if err != nil {
+			return err
+		}
+		return showPage(senderID, pagination.Page+1)
+	}
+}
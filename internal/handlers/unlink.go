@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+// showConfirmUnlink asks the user to confirm unlinking the profile shown in the
+// profile menu, which is their primary profile
+func (h *Handler) showConfirmUnlink(senderID string) error {
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	link, err := h.linkRepo.GetPrimaryLink(int(user.ID))
+	if err != nil {
+		return fmt.Errorf("failed to get primary link: %w", err)
+	}
+	if link == nil {
+		return h.utils.SendResponseWithQuickReplies(senderID, i18n.T(lang, "profile.none_active"))
+	}
+
+	req := state.UnlinkRequest{SchoolID: link.SchoolID, StudentID: link.StudentID, StudentName: link.StudentID}
+	if link.Student != nil {
+		req.StudentName = fmt.Sprintf("%s %s", link.Student.FirstName, link.Student.LastName)
+	}
+	if err := state.EnterScreen(h.stateManager, senderID, state.Screen{State: state.StateConfirmUnlink}, req); err != nil {
+		log.Printf("Error setting state: %v", err)
+	}
+	return h.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "profile.confirm_unlink", req.StudentName, req.StudentID), helpers.GetConfirmUnlinkReplies(lang))
+}
+
+// handleConfirmUnlink unlinks the profile once the user confirms
+func (h *Handler) handleConfirmUnlink(senderID string, in stateInput) error {
+	if in.command != helpers.PayloadConfirmUnlink {
+		return errInvalidInput
+	}
+	req, err := state.Get[state.UnlinkRequest](in.data)
+	if err != nil {
+		return err
+	}
+
+	user, err := h.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	lang := i18n.Of(user.Language)
+
+	primary, err := h.linkRepo.UnlinkStudent(int(user.ID), req.SchoolID, req.StudentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "profile.unlink_failed"))
+	}
+	if err != nil {
+		return fmt.Errorf("failed to unlink student: %w", err)
+	}
+	h.repo.InvalidateCache(senderID)
+
+	entry := models.LinkAudit{
+		PSID:      senderID,
+		UserID:    int(user.ID),
+		SchoolID:  req.SchoolID,
+		StudentID: req.StudentID,
+		Method:    models.LinkMethodSelfService,
+		Event:     models.LinkEventUnlinked,
+	}
+	if primary != nil {
+		entry.Detail = fmt.Sprintf("primary moved to %s/%s", primary.SchoolID, primary.StudentID)
+	}
+	if err := h.auditRepo.Record(entry); err != nil {
+		log.Printf("Error auditing unlink: %v", err)
+	}
+
+	if err := h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "profile.unlinked", req.StudentName)); err != nil {
+		return fmt.Errorf("failed to send unlink message: %w", err)
+	}
+
+	if primary != nil && primary.Student != nil {
+		if err := h.fbSvc.SendTextMessage(senderID, i18n.T(lang, "profile.unlinked_primary", primary.Student.FirstName, primary.Student.LastName)); err != nil {
+			return fmt.Errorf("failed to send primary profile message: %w", err)
+		}
+	}
+
+	links, err := h.linkRepo.GetUserLinks(int(user.ID))
+	if err != nil {
+		return fmt.Errorf("failed to get user links: %w", err)
+	}
+	if len(links) == 0 {
+		return h.leaveLinkFlow(senderID, i18n.T(lang, "profile.unlinked_none"))
+	}
+
+	// Screens in the history may show the unlinked profile, so start over
+	if err := state.ClearHistory(h.stateManager, senderID); err != nil {
+		log.Printf("Error clearing history: %v", err)
+	}
+	return h.enterState(senderID, state.StateMainMenu)
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"database/sql/driver"
+	"strings"
+	"testing"
+
+	"school-assistant-wh/internal/fakegraph"
+	"school-assistant-wh/internal/services/helpers"
+	"school-assistant-wh/internal/state"
+)
+
+func TestUnlinkPrimaryPromotesNextLink(t *testing.T) {
+	tables := linkedUserTables()
+	// A second link, to S2, alongside the primary link to S1
+	links := &tables[1]
+	links.rows = append(links.rows, []driver.Value{int64(2), true, int64(1), "S2", "sch1", "guardian", false, false})
+	inactive := make(map[int64]bool)
+	links.match = func(row []driver.Value, args []driver.Value) bool {
+		if inactive[row[0].(int64)] {
+			return false
+		}
+		// Lookups naming a student only see that student's link
+		for _, arg := range args {
+			if id, ok := arg.(string); ok && strings.HasPrefix(id, "S") && id != row[3] {
+				return false
+			}
+		}
+		return true
+	}
+	students := &tables[2]
+	students.rows = append(students.rows, []driver.Value{int64(2), "S2", "Maria", "Santos", "Active"})
+	students.match = func(row []driver.Value, args []driver.Value) bool {
+		for _, arg := range args {
+			if arg == row[1] {
+				return true
+			}
+		}
+		return false
+	}
+
+	wt := newWebhookTest(t, "page-token", tables...)
+	wt.db.onExec = func(query string, args []driver.Value) error {
+		// Deactivating a link sets IsActive and IsPrimary to false by ID
+		if strings.Contains(query, "school_link_user") && strings.Contains(query, "`IsActive`=?") && args[0] == false {
+			inactive[args[len(args)-1].(int64)] = true
+		}
+		return nil
+	}
+	req := state.UnlinkRequest{SchoolID: "sch1", StudentID: "S1", StudentName: "Juan Dela Cruz"}
+	if err := state.EnterScreen(wt.h.stateManager, "psid-1", state.Screen{State: state.StateConfirmUnlink}, req); err != nil {
+		t.Fatal(err)
+	}
+
+	wt.deliver(t, fakegraph.Inbound{PSID: "psid-1", Text: "Yes, Unlink", QuickReply: helpers.PayloadConfirmUnlink})
+
+	if !inactive[1] {
+		t.Fatal("the S1 link was not deactivated")
+	}
+	if !wt.db.ranWith("`IsPrimary`=?", int64(2)) {
+		t.Fatal("the S2 link was not made primary")
+	}
+	msgs := strings.Join(wt.messages("psid-1"), "\n")
+	if !strings.Contains(msgs, "*Maria Santos* is now your active profile") {
+		t.Fatalf("messages = %q, want S2 announced as the active profile", msgs)
+	}
+}
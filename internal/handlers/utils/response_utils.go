@@ -3,6 +3,8 @@ package utils
 import (
 	"fmt"
 	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
 	"school-assistant-wh/internal/repositories"
 	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/services/helpers"
@@ -22,13 +24,28 @@ func NewResponseUtils(repo repositories.UserRepository, linkRepo repositories.Us
 	}
 }
 
+// Lang returns the language the user prefers, or the default language for
+// users who have not registered yet
+func (u *ResponseUtils) Lang(senderID string) i18n.Lang {
+	user, err := u.repo.GetUserByPSID(senderID)
+	if err != nil {
+		return i18n.Default
+	}
+	return i18n.Of(user.Language)
+}
+
 func (u *ResponseUtils) SendResponseWithQuickReplies(senderID, message string) error {
 	user, _ := u.repo.GetUserByPSID(senderID)
-	var currentProfileData interface{}
+	var currentProfileData *models.UserLinkWithStudent
+
+	lang := i18n.Default
+	if user != nil {
+		lang = i18n.Of(user.Language)
+	}
 
 	if user != nil && !user.IsActive {
-		quickReplies := helpers.GetQuickReplies(constants.UserStatusUnregistered)
-		return u.fbSvc.SendQuickReplies(senderID, constants.AccountDeactivatedMessage, quickReplies)
+		quickReplies := helpers.GetQuickReplies(lang, constants.UserStatusUnregistered)
+		return u.fbSvc.SendQuickReplies(senderID, i18n.T(lang, "account.deactivated"), quickReplies)
 	}
 
 	if user != nil {
@@ -45,6 +62,6 @@ func (u *ResponseUtils) SendResponseWithQuickReplies(senderID, message string) e
 	} else if user != nil && user.IsActive {
 		status = constants.UserStatusRegistered
 	}
-	quickReplies := helpers.GetQuickReplies(status)
+	quickReplies := helpers.GetQuickReplies(lang, status)
 	return u.fbSvc.SendQuickReplies(senderID, message, quickReplies)
 }
@@ -0,0 +1,227 @@
+// Package i18n holds the bot's message catalog. Messages are looked up by ID in
+// the user's language and fall back to English when a translation is missing.
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"log"
+	"path"
+	"strings"
+	"time"
+)
+
+//go:embed locales/*.json
+var localeFiles embed.FS
+
+// Lang is a supported language code
+type Lang string
+
+const (
+	English  Lang = "en"
+	Filipino Lang = "fil"
+	Cebuano  Lang = "ceb"
+)
+
+// Default is used when a user has no supported language preference
+const Default = English
+
+// Supported lists the languages with a locale file, in the order offered to users
+var Supported = []Lang{English, Filipino, Cebuano}
+
+// messengerLocales maps each language to the Messenger locale its greeting is
+// registered under
+var messengerLocales = map[Lang]string{
+	English:  "en_US",
+	Filipino: "tl_PH",
+	Cebuano:  "cx_PH",
+}
+
+// message is a catalog entry: either plain text or plural forms keyed by
+// "one" and "other"
+type message struct {
+	text   string
+	plural map[string]string
+}
+
+func (m *message) UnmarshalJSON(data []byte) error {
+	if err := json.Unmarshal(data, &m.text); err == nil {
+		return nil
+	}
+	if err := json.Unmarshal(data, &m.plural); err != nil {
+		return fmt.Errorf("message must be a string or an object of plural forms: %w", err)
+	}
+	if _, ok := m.plural["other"]; !ok {
+		return fmt.Errorf("plural message is missing its \"other\" form")
+	}
+	return nil
+}
+
+var catalog = mustLoad(localeFiles)
+
+// load reads one JSON file per supported language. Every language must only
+// use message IDs the English catalog defines.
+func load(fsys fs.FS) (map[Lang]map[string]message, error) {
+	messages := make(map[Lang]map[string]message, len(Supported))
+	for _, lang := range Supported {
+		data, err := fs.ReadFile(fsys, path.Join("locales", string(lang)+".json"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s locale: %w", lang, err)
+		}
+
+		var entries map[string]message
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse %s locale: %w", lang, err)
+		}
+		messages[lang] = entries
+	}
+
+	for _, lang := range Supported {
+		for id := range messages[lang] {
+			if _, ok := messages[Default][id]; !ok {
+				return nil, fmt.Errorf("%s locale defines unknown message %q", lang, id)
+			}
+		}
+	}
+	return messages, nil
+}
+
+func mustLoad(fsys fs.FS) map[Lang]map[string]message {
+	messages, err := load(fsys)
+	if err != nil {
+		panic(err)
+	}
+	return messages
+}
+
+// Parse returns the supported language for a code such as "fil" or "CEB"
+func Parse(code string) (Lang, bool) {
+	lang := Lang(strings.ToLower(strings.TrimSpace(code)))
+	_, ok := catalog[lang]
+	return lang, ok
+}
+
+// Of returns the language for a stored code, or Default when it is unsupported
+func Of(code string) Lang {
+	if lang, ok := Parse(code); ok {
+		return lang
+	}
+	return Default
+}
+
+// FromLocale picks the language for a Messenger locale such as "tl_PH"
+func FromLocale(locale string) Lang {
+	for lang, l := range messengerLocales {
+		if strings.EqualFold(l, locale) {
+			return lang
+		}
+	}
+	prefix, _, _ := strings.Cut(strings.ToLower(locale), "_")
+	switch prefix {
+	case "tl", "fil":
+		return Filipino
+	case "cx", "ceb":
+		return Cebuano
+	}
+	return Default
+}
+
+// MessengerLocale returns the Messenger locale the language is registered under
+func (l Lang) MessengerLocale() string {
+	return messengerLocales[l]
+}
+
+// Name returns the language's name in itself, as shown on the language picker
+func (l Lang) Name() string {
+	return T(l, "language.name")
+}
+
+func lookup(lang Lang, id string) (message, bool) {
+	if m, ok := catalog[lang][id]; ok {
+		return m, true
+	}
+	if m, ok := catalog[Default][id]; ok {
+		return m, true
+	}
+	log.Printf("Missing message %q", id)
+	return message{}, false
+}
+
+// T returns message id in lang, formatted with args like fmt.Sprintf
+func T(lang Lang, id string, args ...any) string {
+	m, ok := lookup(lang, id)
+	if !ok {
+		return id
+	}
+	text := m.text
+	if m.plural != nil {
+		text = m.plural["other"]
+	}
+	if len(args) == 0 {
+		return text
+	}
+	return fmt.Sprintf(text, args...)
+}
+
+// Plural returns the form of message id matching n. The count is passed as the
+// first formatting argument, followed by args.
+func Plural(lang Lang, id string, n int, args ...any) string {
+	m, ok := lookup(lang, id)
+	if !ok {
+		return id
+	}
+	text := m.text
+	if m.plural != nil {
+		text = m.plural["other"]
+		if one, ok := m.plural["one"]; ok && n == 1 {
+			text = one
+		}
+	}
+	return fmt.Sprintf(text, append([]any{n}, args...)...)
+}
+
+// Amount formats a peso amount with thousands separators, e.g. "₱12,500.00"
+func Amount(lang Lang, amount float64) string {
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+	whole, cents, _ := strings.Cut(fmt.Sprintf("%.2f", amount), ".")
+
+	var grouped strings.Builder
+	for i, digit := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			grouped.WriteString(T(lang, "format.thousands"))
+		}
+		grouped.WriteRune(digit)
+	}
+	return T(lang, "format.amount", sign, grouped.String(), cents)
+}
+
+// Date formats a calendar date, e.g. "August 18, 2026"
+func Date(lang Lang, t time.Time) string {
+	return T(lang, "format.date", Month(lang, t.Month()), t.Day(), t.Year())
+}
+
+// DateTime formats a date with its time of day, e.g. "August 18, 2026 3:04 PM"
+func DateTime(lang Lang, t time.Time) string {
+	return T(lang, "format.datetime", Date(lang, t), t.Format("3:04 PM"))
+}
+
+// MonthYear formats a month of a year, e.g. "August 2026"
+func MonthYear(lang Lang, t time.Time) string {
+	return T(lang, "format.month_year", Month(lang, t.Month()), t.Year())
+}
+
+// Month returns the name of a month
+func Month(lang Lang, m time.Month) string {
+	return T(lang, fmt.Sprintf("month.%d", int(m)))
+}
+
+// Weekday returns the name of the day of the week t falls on
+func Weekday(lang Lang, t time.Time) string {
+	return T(lang, fmt.Sprintf("weekday.%d", int(t.Weekday())))
+}
@@ -0,0 +1,116 @@
+package i18n
+
+import (
+	"regexp"
+	"sort"
+	"testing"
+)
+
+func TestCatalogsAreComplete(t *testing.T) {
+	for _, lang := range Supported {
+		if lang == Default {
+			continue
+		}
+		for id := range catalog[Default] {
+			if _, ok := catalog[lang][id]; !ok {
+				t.Errorf("%s is missing message %q", lang, id)
+			}
+		}
+	}
+}
+
+var verbPattern = regexp.MustCompile(`%(\[\d+\])?[-+# 0]*\d*(\.\d+)?[a-zA-Z%]`)
+
+// verbs returns the formatting verbs of every form of m, sorted
+func verbs(m message) []string {
+	texts := []string{m.text}
+	if m.plural != nil {
+		texts = []string{m.plural["other"]}
+	}
+	var found []string
+	for _, text := range texts {
+		found = append(found, verbPattern.FindAllString(text, -1)...)
+	}
+	sort.Strings(found)
+	return found
+}
+
+func TestTranslationsKeepFormatVerbs(t *testing.T) {
+	for _, lang := range Supported {
+		for id, m := range catalog[lang] {
+			want := verbs(catalog[Default][id])
+			got := verbs(m)
+			if len(got) != len(want) {
+				t.Errorf("%s %q has verbs %v, English has %v", lang, id, got, want)
+				continue
+			}
+			for i := range got {
+				if got[i] != want[i] {
+					t.Errorf("%s %q has verbs %v, English has %v", lang, id, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestPlural(t *testing.T) {
+	tests := []struct {
+		lang Lang
+		n    int
+		want string
+	}{
+		{English, 1, "1 payment"},
+		{English, 0, "0 payments"},
+		{English, 2, "2 payments"},
+	}
+	for _, tt := range tests {
+		if got := Plural(tt.lang, "payables.count", tt.n); got != tt.want {
+			t.Errorf("Plural(%s, %d) = %q, want %q", tt.lang, tt.n, got, tt.want)
+		}
+	}
+	for _, lang := range Supported {
+		if got := Plural(lang, "payables.count", 3); got == "payables.count" {
+			t.Errorf("%s has no payables.count", lang)
+		}
+	}
+}
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		amount float64
+		want   string
+	}{
+		{0, "₱0.00"},
+		{5.5, "₱5.50"},
+		{999.99, "₱999.99"},
+		{1000, "₱1,000.00"},
+		{12500, "₱12,500.00"},
+		{1234567.891, "₱1,234,567.89"},
+		{-250, "-₱250.00"},
+	}
+	for _, tt := range tests {
+		if got := Amount(English, tt.amount); got != tt.want {
+			t.Errorf("Amount(%v) = %q, want %q", tt.amount, got, tt.want)
+		}
+	}
+}
+
+func TestParseAndFromLocale(t *testing.T) {
+	if lang, ok := Parse(" FIL "); !ok || lang != Filipino {
+		t.Errorf("Parse(FIL) = %q, %t", lang, ok)
+	}
+	if _, ok := Parse("jp"); ok {
+		t.Error("Parse accepted an unsupported language")
+	}
+	if Of("jp") != Default {
+		t.Error("Of did not fall back to the default language")
+	}
+
+	locales := map[string]Lang{"en_US": English, "tl_PH": Filipino, "fil_PH": Filipino, "cx_PH": Cebuano, "ja_JP": Default}
+	for locale, want := range locales {
+		if got := FromLocale(locale); got != want {
+			t.Errorf("FromLocale(%s) = %s, want %s", locale, got, want)
+		}
+	}
+}
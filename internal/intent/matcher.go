@@ -0,0 +1,236 @@
+// Package intent recognizes what a user is asking for in free-text messages
+// using keyword and synonym lists with a small edit distance tolerance. It runs
+// entirely offline.
+package intent
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Name identifies a recognized intent
+type Name string
+
+const (
+	Grades      Name = "grades"
+	Fees        Name = "fees"
+	PaymentLogs Name = "payment_logs"
+	Bulletin    Name = "bulletin"
+	Attendance  Name = "attendance"
+	Subjects    Name = "subjects"
+	Profile     Name = "profile"
+	Support     Name = "support"
+)
+
+// order breaks ties between intents matched equally well
+var order = []Name{Grades, Fees, PaymentLogs, Bulletin, Attendance, Subjects, Profile, Support}
+
+// minScore is the lowest similarity, from 0 to 1, accepted for a phrase
+const minScore = 0.75
+
+// Result is a recognized intent with the slots found in the message
+type Result struct {
+	Intent Name
+	// Language is the language of the phrase that matched: en, fil or ceb
+	Language string
+	// Score is how closely the phrase matched, 1 being exact
+	Score float64
+	// SchoolYear is a school year such as "2023-2024", when one was mentioned
+	SchoolYear string
+	// Month is a month mentioned in the message, zero when none was
+	Month time.Month
+	// Year accompanies Month when the message names a calendar year
+	Year int
+}
+
+// Period returns the first day of the month the user asked about. Without an
+// explicit year it is the latest such month not after now.
+func (r Result) Period(now time.Time) (time.Time, bool) {
+	if r.Month == 0 {
+		return time.Time{}, false
+	}
+	year := r.Year
+	if year == 0 {
+		year = now.Year()
+		if r.Month > now.Month() {
+			year--
+		}
+	}
+	return time.Date(year, r.Month, 1, 0, 0, 0, 0, now.Location()), true
+}
+
+type phrase struct {
+	intent   Name
+	language string
+	words    []string
+}
+
+// Matcher maps free text to intents
+type Matcher struct {
+	phrases []phrase
+}
+
+// NewMatcher creates a matcher over the built-in English, Filipino and Cebuano
+// phrase lists
+func NewMatcher() *Matcher {
+	m := &Matcher{}
+	for _, name := range order {
+		for _, language := range []string{"en", "fil", "ceb"} {
+			for _, p := range phrases[name][language] {
+				m.phrases = append(m.phrases, phrase{intent: name, language: language, words: strings.Fields(p)})
+			}
+		}
+	}
+	return m
+}
+
+var (
+	schoolYearPattern = regexp.MustCompile(`\b(20\d{2})\s*(?:-|/|–|to)\s*(20\d{2}|\d{2})\b`)
+	yearPattern       = regexp.MustCompile(`^20\d{2}$`)
+)
+
+// Match returns the intent that best fits text. It reports false when no phrase
+// matches closely enough.
+func (m *Matcher) Match(text string) (Result, bool) {
+	text = strings.ToLower(text)
+
+	var result Result
+	if sy, ok := parseSchoolYear(text); ok {
+		result.SchoolYear = sy
+		text = schoolYearPattern.ReplaceAllString(text, " ")
+	}
+
+	var words []string
+	for _, word := range tokenize(text) {
+		if month, ok := months[word]; ok && result.Month == 0 {
+			result.Month = month
+			continue
+		}
+		if yearPattern.MatchString(word) {
+			result.Year, _ = strconv.Atoi(word)
+			continue
+		}
+		words = append(words, word)
+	}
+	if result.Month == 0 {
+		result.Year = 0
+	}
+
+	best, bestWords := -1, 0
+	for i, p := range m.phrases {
+		score, ok := p.match(words)
+		if !ok || score < minScore {
+			continue
+		}
+		// Longer phrases are more specific, so they win over better single words
+		if best < 0 || len(p.words) > bestWords || (len(p.words) == bestWords && score > result.Score) {
+			best, bestWords = i, len(p.words)
+			result.Score = score
+		}
+	}
+	if best < 0 {
+		return Result{}, false
+	}
+
+	result.Intent = m.phrases[best].intent
+	result.Language = m.phrases[best].language
+	return result, true
+}
+
+// match looks for the phrase's words, in order, among words and returns how
+// similar the matched words are to the phrase
+func (p phrase) match(words []string) (float64, bool) {
+	var edits, length int
+	next := 0
+	for _, want := range p.words {
+		found := false
+		for ; next < len(words); next++ {
+			if d, ok := similar(want, words[next]); ok {
+				edits += d
+				length += len([]rune(want))
+				found = true
+				next++
+				break
+			}
+		}
+		if !found {
+			return 0, false
+		}
+	}
+	return 1 - float64(edits)/float64(length), true
+}
+
+// similar reports whether word is close enough to want to count as a typo of
+// it, along with their edit distance. Short words must match exactly.
+func similar(want, word string) (int, bool) {
+	if want == word {
+		return 0, true
+	}
+	var tolerance int
+	switch n := len([]rune(want)); {
+	case n >= 8:
+		tolerance = 2
+	case n >= 5:
+		tolerance = 1
+	default:
+		return 0, false
+	}
+	d := editDistance(want, word)
+	return d, d <= tolerance
+}
+
+// editDistance returns the Levenshtein distance between a and b, counting a
+// swap of two adjacent letters ("grdaes") as a single edit
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]int, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(rb)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+// tokenize splits text into lower-case words, dropping punctuation
+func tokenize(text string) []string {
+	return strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// parseSchoolYear finds a school year such as "2023-2024" or "2023-24" and
+// returns it in the "2023-2024" form used by the grade records
+func parseSchoolYear(text string) (string, bool) {
+	match := schoolYearPattern.FindStringSubmatch(text)
+	if match == nil {
+		return "", false
+	}
+	start, _ := strconv.Atoi(match[1])
+	end, _ := strconv.Atoi(match[2])
+	if end < 100 {
+		end += start / 100 * 100
+	}
+	if end != start+1 {
+		return "", false
+	}
+	return fmt.Sprintf("%d-%d", start, end), true
+}
@@ -0,0 +1,87 @@
+package intent
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMatch(t *testing.T) {
+	m := NewMatcher()
+	tests := []struct {
+		text     string
+		intent   Name
+		language string
+	}{
+		{"Can I see my grades?", Grades, "en"},
+		{"grdaes", Grades, "en"},
+		{"report card please", Grades, "en"},
+		{"How much do I owe?", Fees, "en"},
+		{"payment history", PaymentLogs, "en"},
+		{"any announcements today", Bulletin, "en"},
+		{"attendance", Attendance, "en"},
+		{"what are his enrolled subjects", Subjects, "en"},
+		{"I have a complaint", Support, "en"},
+		{"Pakita ang mga marka", Grades, "fil"},
+		{"magkano ang bayarin", Fees, "fil"},
+		{"may bagong anunsyo ba", Bulletin, "fil"},
+		{"ilang liban na siya", Attendance, "fil"},
+		{"may tanong ako", Support, "fil"},
+		{"akong grado", Grades, "ceb"},
+		{"pila akong utang", Fees, "ceb"},
+		{"gibayran nako", PaymentLogs, "ceb"},
+		{"naa bay pahibalo", Bulletin, "ceb"},
+		{"pagtambong sa bata", Attendance, "ceb"},
+		{"naa koy pangutana", Support, "ceb"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := m.Match(tt.text)
+			if !ok {
+				t.Fatal("no intent matched")
+			}
+			if got.Intent != tt.intent || got.Language != tt.language {
+				t.Fatalf("got %s (%s), want %s (%s)", got.Intent, got.Language, tt.intent, tt.language)
+			}
+		})
+	}
+}
+
+func TestMatchRejectsUnrelatedText(t *testing.T) {
+	m := NewMatcher()
+	for _, text := range []string{"hello", "salamat po", "maayong buntag", "ok"} {
+		if got, ok := m.Match(text); ok {
+			t.Errorf("%q matched %s", text, got.Intent)
+		}
+	}
+}
+
+func TestMatchSlots(t *testing.T) {
+	m := NewMatcher()
+	now := time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		text       string
+		schoolYear string
+		period     time.Time
+	}{
+		{"grades for 2023-24", "2023-2024", time.Time{}},
+		{"mga marka 2022 to 2023", "2022-2023", time.Time{}},
+		{"attendance for december", "", time.Date(2024, time.December, 1, 0, 0, 0, 0, time.UTC)},
+		{"pagpasok noong Pebrero", "", time.Date(2025, time.February, 1, 0, 0, 0, 0, time.UTC)},
+		{"pagtambong Agosto 2023", "", time.Date(2023, time.August, 1, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, ok := m.Match(tt.text)
+			if !ok {
+				t.Fatal("no intent matched")
+			}
+			if got.SchoolYear != tt.schoolYear {
+				t.Errorf("school year = %q, want %q", got.SchoolYear, tt.schoolYear)
+			}
+			period, _ := got.Period(now)
+			if !period.Equal(tt.period) {
+				t.Errorf("period = %v, want %v", period, tt.period)
+			}
+		})
+	}
+}
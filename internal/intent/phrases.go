@@ -0,0 +1,66 @@
+package intent
+
+import "time"
+
+// phrases lists, per intent and language, the words and phrases that signal it.
+// Entries are lower-case; multi-word phrases match when all their words appear
+// in order.
+var phrases = map[Name]map[string][]string{
+	Grades: {
+		"en":  {"grades", "grade", "my grades", "report card", "marks", "gpa", "scores"},
+		"fil": {"marka", "mga marka", "grado", "kard"},
+		"ceb": {"grado", "akong grado", "marka"},
+	},
+	Fees: {
+		"en":  {"fees", "balance", "payables", "how much do i owe", "owe", "tuition", "bill", "dues", "amount due", "pay"},
+		"fil": {"bayarin", "utang", "magkano", "balanse", "matrikula", "babayaran"},
+		"ceb": {"bayranan", "utang", "pila akong utang", "balanse", "bayran"},
+	},
+	PaymentLogs: {
+		"en":  {"payment history", "payment logs", "payments", "receipts", "paid"},
+		"fil": {"mga bayad", "binayaran", "resibo"},
+		"ceb": {"mga bayad", "gibayran", "resibo"},
+	},
+	Bulletin: {
+		"en":  {"announcements", "announcement", "bulletin", "news", "updates", "events", "memo"},
+		"fil": {"anunsyo", "balita", "paalala"},
+		"ceb": {"pahibalo", "balita", "anunsyo"},
+	},
+	Attendance: {
+		"en":  {"attendance", "dtr", "time in", "time out", "absences", "absent", "tardy"},
+		"fil": {"pagpasok", "liban", "pumasok"},
+		"ceb": {"pagtambong", "pagsulod", "misulod"},
+	},
+	Subjects: {
+		"en":  {"subjects", "subject", "enrolled subjects", "classes", "schedule"},
+		"fil": {"asignatura", "mga subject", "iskedyul"},
+		"ceb": {"asignatura", "mga subject", "iskedyul"},
+	},
+	Profile: {
+		"en":  {"profile", "my profile", "student info", "account"},
+		"fil": {"impormasyon", "aking profile"},
+		"ceb": {"akong profile", "impormasyon"},
+	},
+	Support: {
+		"en":  {"support", "inquiry", "question", "concern", "complaint", "ticket", "tickets"},
+		"fil": {"tanong", "reklamo", "katanungan"},
+		"ceb": {"pangutana", "reklamo"},
+	},
+}
+
+// months maps English, Filipino and Cebuano month names and abbreviations
+var months = map[string]time.Month{
+	"january": time.January, "jan": time.January, "enero": time.January,
+	"february": time.February, "feb": time.February, "pebrero": time.February, "febrero": time.February,
+	"march": time.March, "mar": time.March, "marso": time.March,
+	"april": time.April, "apr": time.April, "abril": time.April,
+	"may": time.May, "mayo": time.May,
+	"june": time.June, "jun": time.June, "hunyo": time.June,
+	"july": time.July, "jul": time.July, "hulyo": time.July,
+	"august": time.August, "aug": time.August, "agosto": time.August,
+	"september": time.September, "sept": time.September, "sep": time.September,
+	"setyembre": time.September, "septiyembre": time.September, "septyembre": time.September,
+	"october": time.October, "oct": time.October, "oktubre": time.October,
+	"november": time.November, "nov": time.November, "nobyembre": time.November, "nobiyembre": time.November,
+	"december": time.December, "dec": time.December, "disyembre": time.December,
+}
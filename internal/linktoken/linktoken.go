@@ -0,0 +1,64 @@
+// Package linktoken issues and verifies the signed tokens schools put in m.me
+// links, so a parent opening the link is registered and linked to a student in
+// one step.
+package linktoken
+
+import (
+	"errors"
+	"time"
+
+	"school-assistant-wh/internal/signedtoken"
+)
+
+// purpose keeps link tokens from being accepted as any other signed token
+const purpose = "link"
+
+var (
+	// ErrMalformed means the token is not a link token
+	ErrMalformed = signedtoken.ErrMalformed
+	// ErrBadSignature means the token was altered or signed with another secret
+	ErrBadSignature = signedtoken.ErrBadSignature
+	// ErrExpired means the token is past its expiry
+	ErrExpired = signedtoken.ErrExpired
+)
+
+// Claims are the student a token links to and when the token stops working
+type Claims struct {
+	SchoolID  string
+	StudentID string
+	ExpiresAt time.Time
+}
+
+// Signer issues and verifies link tokens with a shared secret. A signer without
+// a secret rejects every token.
+type Signer struct {
+	tokens *signedtoken.Signer
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{tokens: signedtoken.NewSigner(purpose, secret)}
+}
+
+// Issue returns a token for the claims. The token only uses characters allowed
+// in an m.me ref parameter.
+func (s *Signer) Issue(c Claims) (string, error) {
+	return s.tokens.Issue(c.ExpiresAt, c.SchoolID, c.StudentID)
+}
+
+// Verify checks the token's signature and expiry and returns its claims
+func (s *Signer) Verify(token string, now time.Time) (Claims, error) {
+	t, err := s.tokens.Verify(token, now)
+	if err != nil && !errors.Is(err, ErrExpired) {
+		return Claims{}, err
+	}
+	if len(t.Fields) != 2 {
+		return Claims{}, ErrMalformed
+	}
+	return Claims{SchoolID: t.Fields[0], StudentID: t.Fields[1], ExpiresAt: t.ExpiresAt}, err
+}
+
+// Signature returns the signature part of a token, which identifies it without
+// exposing the student it links to
+func Signature(token string) string {
+	return signedtoken.Signature(token)
+}
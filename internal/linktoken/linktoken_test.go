@@ -0,0 +1,78 @@
+package linktoken
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueVerifyRoundTrip(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Unix(1_700_000_000, 0)
+	want := Claims{SchoolID: "demo_school", StudentID: "2024-001", ExpiresAt: now.Add(time.Hour)}
+
+	token, err := s.Issue(want)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Verify(token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if got != want {
+		t.Fatalf("claims = %+v, want %+v", got, want)
+	}
+}
+
+func TestVerifyRejectsTamperedToken(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Unix(1_700_000_000, 0)
+	token, err := s.Issue(Claims{SchoolID: "demo_school", StudentID: "2024-001", ExpiresAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sig, _ := strings.Cut(token, ".")
+	forged := base64.RawURLEncoding.EncodeToString([]byte("demo_school|2024-002|1700003600|")) + "." + sig
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"other payload", forged, ErrBadSignature},
+		{"other secret", mustIssue(t, NewSigner("other"), now), ErrBadSignature},
+		{"no signature", strings.Split(token, ".")[0], ErrMalformed},
+		{"not base64", "!!!." + sig, ErrMalformed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.token, now); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsExpiredToken(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Unix(1_700_000_000, 0)
+	token := mustIssue(t, s, now)
+
+	if _, err := s.Verify(token, now.Add(time.Hour)); !errors.Is(err, ErrExpired) {
+		t.Fatalf("at expiry: err = %v, want %v", err, ErrExpired)
+	}
+	if _, err := s.Verify(token, now.Add(time.Hour-time.Second)); err != nil {
+		t.Fatalf("before expiry: %v", err)
+	}
+}
+
+func mustIssue(t *testing.T, s *Signer, now time.Time) string {
+	t.Helper()
+	token, err := s.Issue(Claims{SchoolID: "demo_school", StudentID: "2024-001", ExpiresAt: now.Add(time.Hour)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
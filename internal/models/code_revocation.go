@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Why an SA-ID stopped working
+const (
+	CodeRevokedRegenerated = "regenerated"
+	CodeRevokedExpired     = "expired"
+	CodeRevokedDeleted     = "account_deleted"
+)
+
+// CodeRevocation records an SA-ID that was replaced. Revoked codes are never
+// handed out again.
+type CodeRevocation struct {
+	ID        uint      `gorm:"primaryKey;column:ID"`
+	RevokedAt time.Time `gorm:"column:RevokedAt"`
+	UserID    int       `gorm:"column:UserID;not null"`
+	Code      string    `gorm:"column:Code;size:10;unique;not null"`
+	Reason    string    `gorm:"column:Reason;size:20;not null"`
+}
+
+func (CodeRevocation) TableName() string {
+	return "school_messenger_code_revocations"
+}
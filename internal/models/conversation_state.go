@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+// ConversationState is a user's position in a conversation flow, persisted so it
+// survives restarts and is shared between replicas
+type ConversationState struct {
+	PSID       string    `gorm:"primaryKey;column:PSID;size:100"`
+	State      string    `gorm:"column:State;size:50;not null"`
+	Data       string    `gorm:"column:Data;type:mediumtext"`
+	LastActive time.Time `gorm:"column:LastActive;index"`
+}
+
+func (ConversationState) TableName() string {
+	return "school_messenger_conversation_states"
+}
@@ -17,9 +17,9 @@ type DTRRecord struct {
 	DateTimeIN     time.Time `gorm:"column:DateTimeIN;not null;index" json:"date_time_in"`
 	IPAddress      string    `gorm:"column:IPAddress;size:100;not null;index" json:"ip_address"`
 	BatchID        string    `gorm:"column:BatchID;size:100;not null;index" json:"batch_id"`
-	Extra1         string    `gorm:"column:Extra1;size:100;not null;default:'."' json:"extra1"`
-	Extra2         string    `gorm:"column:Extra2;size:100;not null;default:'."' json:"extra2"`
-	Extra3         string    `gorm:"column:Extra3;size:100;not null;default:'."' json:"extra3"`
+	Extra1         string    `gorm:"column:Extra1;size:100;not null;default:'.'" json:"extra1"`
+	Extra2         string    `gorm:"column:Extra2;size:100;not null;default:'.'" json:"extra2"`
+	Extra3         string    `gorm:"column:Extra3;size:100;not null;default:'.'" json:"extra3"`
 	Notes1         *string   `gorm:"column:Notes1;type:text" json:"notes1,omitempty"`
 	Notes2         *string   `gorm:"column:Notes2;type:text" json:"notes2,omitempty"`
 }
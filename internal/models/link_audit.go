@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// How a student link was requested or removed
+const (
+	LinkMethodSelfService = "self_service"
+	LinkMethodToken       = "link_token"
+	LinkMethodAdmin       = "admin_api"
+)
+
+// Steps recorded while a user links or unlinks a student, or deletes their
+// account. The failures count towards the self-service attempt limit.
+const (
+	LinkEventStarted           = "started"
+	LinkEventStudentNotFound   = "student_not_found"
+	LinkEventBirthdateMismatch = "birthdate_mismatch"
+	LinkEventCodeSent          = "code_sent"
+	LinkEventCodeMismatch      = "code_mismatch"
+	LinkEventCodeExpired       = "code_expired"
+	LinkEventLocked            = "locked"
+	LinkEventLinked            = "linked"
+	LinkEventUnlinked          = "unlinked"
+	LinkEventAccountDeleted    = "account_deleted"
+	LinkEventPrimarySet        = "primary_set"
+)
+
+// LinkFailureEvents are the events that count as failed verification attempts
+var LinkFailureEvents = []string{
+	LinkEventStudentNotFound,
+	LinkEventBirthdateMismatch,
+	LinkEventCodeMismatch,
+}
+
+// LinkAudit is one step of a user linking or unlinking a student
+type LinkAudit struct {
+	ID        uint      `gorm:"primaryKey;column:ID"`
+	CreatedAt time.Time `gorm:"column:CreatedAt"`
+	PSID      string    `gorm:"column:PSID;size:100;not null"`
+	UserID    int       `gorm:"column:UserID;not null"`
+	SchoolID  string    `gorm:"column:SchoolID;size:50"`
+	StudentID string    `gorm:"column:StudentID;size:50"`
+	Method    string    `gorm:"column:Method;size:20;not null"`
+	Event     string    `gorm:"column:Event;size:30;not null"`
+	Detail    string    `gorm:"column:Detail;size:255"`
+}
+
+func (LinkAudit) TableName() string {
+	return "school_messenger_link_audit"
+}
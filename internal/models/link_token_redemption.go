@@ -0,0 +1,17 @@
+package models
+
+import "time"
+
+// LinkTokenRedemption records which user redeemed a school's link token, so a
+// forwarded link cannot link someone else to the same student
+type LinkTokenRedemption struct {
+	Signature  string    `gorm:"primaryKey;column:Signature;size:64"`
+	UserID     int       `gorm:"column:UserID;not null"`
+	SchoolID   string    `gorm:"column:SchoolID;size:50;not null"`
+	StudentID  string    `gorm:"column:StudentID;size:50;not null"`
+	RedeemedAt time.Time `gorm:"column:RedeemedAt"`
+}
+
+func (LinkTokenRedemption) TableName() string {
+	return "school_messenger_link_token_redemptions"
+}
@@ -1,5 +1,15 @@
 package models
 
+// Who a messenger user is to the student they are linked to
+const (
+	LinkRoleStudent  = "student"
+	LinkRoleGuardian = "guardian"
+	LinkRoleSponsor  = "sponsor"
+)
+
+// LinkRoles lists every link role
+var LinkRoles = []string{LinkRoleStudent, LinkRoleGuardian, LinkRoleSponsor}
+
 // UserLink represents the relationship between a user and a student in the system
 type UserLink struct {
 	ID          int    `gorm:"primaryKey;column:ID"`
@@ -9,6 +19,15 @@ type UserLink struct {
 	UserID      int    `gorm:"column:UserID"`
 	StudentID   string `gorm:"column:StudentID"`
 	SchoolID    string `gorm:"column:SchoolID"`
+	Role        string `gorm:"column:Role"`
 	IsNewlyLink bool   `gorm:"column:IsNewlyLink"`
 	IsPrimary   bool   `gorm:"column:IsPrimary"`
 }
+
+// LinkRole returns the link's role, treating links without one as a guardian's
+func (l UserLink) LinkRole() string {
+	if l.Role == "" {
+		return LinkRoleGuardian
+	}
+	return l.Role
+}
@@ -0,0 +1,52 @@
+// Package notify delivers messages to students and guardians outside Messenger,
+// such as the one-time codes used to verify a student link
+package notify
+
+import (
+	"fmt"
+	"log"
+
+	"school-assistant-wh/internal/config"
+)
+
+// Channel is how a message reaches its recipient
+type Channel string
+
+const (
+	SMS   Channel = "sms"
+	Email Channel = "email"
+)
+
+// Message is a notice for a single recipient
+type Message struct {
+	Channel Channel
+	// To is a mobile number for SMS and an address for email
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier sends messages through an SMS or email gateway
+type Notifier interface {
+	Notify(msg Message) error
+}
+
+// New returns the notifier selected by NOTIFIER
+func New(cfg config.NotifyConfig) (Notifier, error) {
+	switch cfg.Driver {
+	case "log":
+		log.Println("Warning: NOTIFIER is \"log\", notices are written to the log instead of being delivered")
+		return LogNotifier{}, nil
+	default:
+		return nil, fmt.Errorf("unknown NOTIFIER %q, expected log", cfg.Driver)
+	}
+}
+
+// LogNotifier writes messages to the log instead of delivering them. It stands
+// in for a real gateway during development.
+type LogNotifier struct{}
+
+func (LogNotifier) Notify(msg Message) error {
+	log.Printf("[notify] %s to %s: %s %s", msg.Channel, msg.To, msg.Subject, msg.Body)
+	return nil
+}
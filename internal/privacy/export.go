@@ -0,0 +1,223 @@
+package privacy
+
+import (
+	"encoding/json"
+	"html/template"
+	"io"
+	"time"
+
+	"school-assistant-wh/internal/i18n"
+	"school-assistant-wh/internal/models"
+)
+
+// Export is everything the bot stores about a messenger user
+type Export struct {
+	GeneratedAt    time.Time       `json:"generated_at"`
+	Account        Account         `json:"account"`
+	Links          []Link          `json:"links"`
+	Messages       []Message       `json:"messages"`
+	SupportTickets []SupportTicket `json:"support_tickets"`
+}
+
+// Account is the user's messenger account
+type Account struct {
+	ID            uint       `json:"id"`
+	PSID          string     `json:"psid"`
+	Name          string     `json:"name"`
+	Email         string     `json:"email,omitempty"`
+	ImageURL      string     `json:"image_url,omitempty"`
+	Language      string     `json:"language"`
+	SaID          string     `json:"sa_id,omitempty"`
+	SaIDExpiresAt *time.Time `json:"sa_id_expires_at,omitempty"`
+	Active        bool       `json:"active"`
+	RegisteredAt  time.Time  `json:"registered_at"`
+	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
+	Notes         string     `json:"notes,omitempty"`
+}
+
+// Link is a student the user is or was linked to
+type Link struct {
+	SchoolID  string `json:"school_id"`
+	StudentID string `json:"student_id"`
+	Role      string `json:"role"`
+	Active    bool   `json:"active"`
+	Primary   bool   `json:"primary"`
+	LinkedAt  string `json:"linked_at"`
+}
+
+// Message is a message the user sent that the bot stored
+type Message struct {
+	SentAt time.Time `json:"sent_at"`
+	Text   string    `json:"text"`
+}
+
+// SupportTicket is a support thread opened for a linked student, with its replies
+type SupportTicket struct {
+	SchoolID    string           `json:"school_id"`
+	ThreadID    string           `json:"thread_id"`
+	Topic       string           `json:"topic"`
+	Subject     string           `json:"subject,omitempty"`
+	Status      string           `json:"status,omitempty"`
+	OpenedAt    *time.Time       `json:"opened_at,omitempty"`
+	CompletedAt *time.Time       `json:"completed_at,omitempty"`
+	Messages    []SupportMessage `json:"messages"`
+}
+
+// SupportMessage is one message of a support ticket
+type SupportMessage struct {
+	SentAt time.Time `json:"sent_at"`
+	From   string    `json:"from"`
+	Text   string    `json:"text"`
+}
+
+// AccountOf describes the user's account
+func AccountOf(u models.User) Account {
+	return Account{
+		ID:            u.ID,
+		PSID:          u.PSID,
+		Name:          u.FBName,
+		Email:         deref(u.Email),
+		ImageURL:      deref(u.FBImgURL),
+		Language:      u.Language,
+		SaID:          deref(u.Code),
+		SaIDExpiresAt: u.CodeExpiresAt,
+		Active:        u.IsActive,
+		RegisteredAt:  u.CreatedAt,
+		LastLoginAt:   u.LastLoginAt,
+		Notes:         deref(u.Notes1),
+	}
+}
+
+// LinksOf describes the user's student links
+func LinksOf(links []models.UserLink) []Link {
+	out := make([]Link, 0, len(links))
+	for _, l := range links {
+		out = append(out, Link{
+			SchoolID:  l.SchoolID,
+			StudentID: l.StudentID,
+			Role:      l.LinkRole(),
+			Active:    l.IsActive,
+			Primary:   l.IsPrimary,
+			LinkedAt:  l.CreatedAt,
+		})
+	}
+	return out
+}
+
+// MessagesOf describes the user's stored messages
+func MessagesOf(messages []models.Message) []Message {
+	out := make([]Message, 0, len(messages))
+	for _, m := range messages {
+		out = append(out, Message{SentAt: m.CreatedAt, Text: m.Text})
+	}
+	return out
+}
+
+// TicketOf describes a support thread and its messages
+func TicketOf(schoolID string, thread models.SupportThread, messages []*models.SupportConversation) SupportTicket {
+	t := SupportTicket{
+		SchoolID:    schoolID,
+		ThreadID:    thread.ThreadID,
+		Topic:       thread.HelpTopic,
+		Subject:     deref(thread.Subject),
+		Status:      deref(thread.Status),
+		OpenedAt:    thread.DateTimeIN,
+		CompletedAt: thread.DateTimeCompleted,
+		Messages:    make([]SupportMessage, 0, len(messages)),
+	}
+	for _, m := range messages {
+		t.Messages = append(t.Messages, SupportMessage{SentAt: m.DateTimeIN, From: m.ReplySupportName, Text: m.Message})
+	}
+	return t
+}
+
+// WriteJSON writes the export as indented JSON
+func WriteJSON(w io.Writer, e Export) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(e)
+}
+
+// WriteHTML writes the export as a standalone HTML page in the language
+func WriteHTML(w io.Writer, lang i18n.Lang, e Export) error {
+	page, err := exportPage.Clone()
+	if err != nil {
+		return err
+	}
+	page.Funcs(template.FuncMap{"t": func(id string) string { return i18n.T(lang, id) }})
+	return page.Execute(w, e)
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+const timeLayout = "Jan 2, 2006 3:04 PM"
+
+// exportPage renders an Export; "t" is replaced with the reader's catalog
+var exportPage = template.Must(template.New("export").Funcs(template.FuncMap{
+	"t": func(id string) string { return id },
+	"when": func(t any) string {
+		switch v := t.(type) {
+		case time.Time:
+			return v.Format(timeLayout)
+		case *time.Time:
+			if v != nil {
+				return v.Format(timeLayout)
+			}
+		}
+		return ""
+	},
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{t "export.title"}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; vertical-align: top; }
+</style>
+</head>
+<body>
+<h1>{{t "export.title"}}</h1>
+<p>{{t "export.generated"}} {{when .GeneratedAt}}</p>
+
+<h2>{{t "export.account"}}</h2>
+<table>
+<tr><th>ID</th><td>{{.Account.ID}}</td></tr>
+<tr><th>PSID</th><td>{{.Account.PSID}}</td></tr>
+<tr><th>{{t "export.name"}}</th><td>{{.Account.Name}}</td></tr>
+<tr><th>Email</th><td>{{.Account.Email}}</td></tr>
+<tr><th>{{t "export.image"}}</th><td>{{.Account.ImageURL}}</td></tr>
+<tr><th>{{t "export.language"}}</th><td>{{.Account.Language}}</td></tr>
+<tr><th>SA-ID</th><td>{{.Account.SaID}}</td></tr>
+<tr><th>{{t "export.registered"}}</th><td>{{when .Account.RegisteredAt}}</td></tr>
+<tr><th>{{t "export.last_login"}}</th><td>{{when .Account.LastLoginAt}}</td></tr>
+<tr><th>{{t "export.notes"}}</th><td>{{.Account.Notes}}</td></tr>
+</table>
+
+<h2>{{t "export.links"}}</h2>
+{{if .Links}}<table>
+<tr><th>School</th><th>Student ID</th><th>{{t "export.role"}}</th><th>{{t "export.active"}}</th><th>{{t "export.primary"}}</th><th>{{t "export.linked"}}</th></tr>
+{{range .Links}}<tr><td>{{.SchoolID}}</td><td>{{.StudentID}}</td><td>{{.Role}}</td><td>{{.Active}}</td><td>{{.Primary}}</td><td>{{.LinkedAt}}</td></tr>
+{{end}}</table>{{else}}<p>{{t "export.none"}}</p>{{end}}
+
+<h2>{{t "export.messages"}}</h2>
+{{if .Messages}}<table>
+{{range .Messages}}<tr><td>{{when .SentAt}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>{{else}}<p>{{t "export.none"}}</p>{{end}}
+
+<h2>{{t "export.tickets"}}</h2>
+{{range .SupportTickets}}<h3>{{.ThreadID}} · {{.Topic}}{{if .Subject}} · {{.Subject}}{{end}}</h3>
+<p>{{.SchoolID}} · {{when .OpenedAt}} · {{.Status}}</p>
+<table>
+{{range .Messages}}<tr><td>{{when .SentAt}}</td><td>{{.From}}</td><td>{{.Text}}</td></tr>
+{{end}}</table>
+{{else}}<p>{{t "export.none"}}</p>{{end}}
+</body>
+</html>
+`))
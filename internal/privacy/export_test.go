@@ -0,0 +1,30 @@
+package privacy
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"school-assistant-wh/internal/i18n"
+)
+
+func TestWriteHTMLEscapesUserContent(t *testing.T) {
+	e := Export{
+		GeneratedAt: time.Unix(1_700_000_000, 0),
+		Account:     Account{ID: 1, PSID: "psid-1", Name: "Ana <b>Cruz</b>"},
+		Messages:    []Message{{SentAt: time.Unix(1_700_000_000, 0), Text: "<script>alert(1)</script>"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, i18n.Default, e); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if strings.Contains(page, "<script>") || strings.Contains(page, "<b>Cruz</b>") {
+		t.Fatal("user content was not escaped")
+	}
+	if !strings.Contains(page, "&lt;script&gt;alert(1)&lt;/script&gt;") {
+		t.Fatal("stored message is missing from the page")
+	}
+}
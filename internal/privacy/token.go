@@ -0,0 +1,54 @@
+package privacy
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"school-assistant-wh/internal/signedtoken"
+)
+
+// purpose keeps export tokens from being accepted as any other signed token
+const purpose = "export"
+
+var (
+	// ErrMalformed means the token is not an export token
+	ErrMalformed = signedtoken.ErrMalformed
+	// ErrBadSignature means the token was altered or signed with another secret
+	ErrBadSignature = signedtoken.ErrBadSignature
+	// ErrExpired means the token is past its expiry
+	ErrExpired = signedtoken.ErrExpired
+)
+
+// Signer issues and verifies the tokens in export download URLs. A signer
+// without a secret rejects every token.
+type Signer struct {
+	tokens *signedtoken.Signer
+}
+
+func NewSigner(secret string) *Signer {
+	return &Signer{tokens: signedtoken.NewSigner(purpose, secret)}
+}
+
+// Issue returns a URL-safe token letting its holder download the user's export
+// until expiresAt
+func (s *Signer) Issue(userID int, expiresAt time.Time) (string, error) {
+	return s.tokens.Issue(expiresAt, strconv.Itoa(userID))
+}
+
+// Verify checks the token's signature and expiry and returns the user it was
+// issued for
+func (s *Signer) Verify(token string, now time.Time) (int, error) {
+	t, err := s.tokens.Verify(token, now)
+	if err != nil && !errors.Is(err, ErrExpired) {
+		return 0, err
+	}
+	if len(t.Fields) != 1 {
+		return 0, ErrMalformed
+	}
+	userID, convErr := strconv.Atoi(t.Fields[0])
+	if convErr != nil {
+		return 0, ErrMalformed
+	}
+	return userID, err
+}
@@ -0,0 +1,66 @@
+package privacy
+
+import (
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueVerifyRoundTrip(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Unix(1_700_000_000, 0)
+
+	token, err := s.Issue(42, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	userID, err := s.Verify(token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if userID != 42 {
+		t.Fatalf("user ID = %d, want 42", userID)
+	}
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	s := NewSigner("secret")
+	now := time.Unix(1_700_000_000, 0)
+	token, err := s.Issue(42, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, sig, _ := strings.Cut(token, ".")
+	other, err := NewSigner("other").Issue(42, now.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		now   time.Time
+		want  error
+	}{
+		{"other user", base64.RawURLEncoding.EncodeToString([]byte("43|1700003600")) + "." + sig, now, ErrBadSignature},
+		{"other secret", other, now, ErrBadSignature},
+		{"no signature", strings.Split(token, ".")[0], now, ErrMalformed},
+		{"not base64", "!!!." + sig, now, ErrMalformed},
+		{"expired", token, now.Add(time.Hour), ErrExpired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := s.Verify(tt.token, tt.now); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestSignerWithoutSecret(t *testing.T) {
+	if _, err := NewSigner("").Issue(42, time.Now().Add(time.Hour)); err == nil {
+		t.Fatal("Issue succeeded without a secret")
+	}
+}
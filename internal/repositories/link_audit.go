@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"fmt"
+	"time"
+
+	"school-assistant-wh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type LinkAuditRepository struct {
+	db *gorm.DB
+}
+
+func NewLinkAuditRepository(db *gorm.DB) *LinkAuditRepository {
+	return &LinkAuditRepository{db: db}
+}
+
+// Record appends a step to the link audit trail
+func (r *LinkAuditRepository) Record(entry models.LinkAudit) error {
+	if entry.CreatedAt.IsZero() {
+		entry.CreatedAt = time.Now()
+	}
+	if err := r.db.Create(&entry).Error; err != nil {
+		return fmt.Errorf("failed to record link %s: %w", entry.Event, err)
+	}
+	return nil
+}
+
+// CountEvents counts the user's audit entries of the given events since the time
+func (r *LinkAuditRepository) CountEvents(userID int, events []string, since time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.LinkAudit{}).
+		Where("UserID = ? AND Event IN ? AND CreatedAt >= ?", userID, events, since).
+		Count(&count).Error
+	return count, err
+}
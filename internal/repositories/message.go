@@ -0,0 +1,39 @@
+package repositories
+
+import (
+	"fmt"
+
+	"school-assistant-wh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type MessageRepository struct {
+	db *gorm.DB
+}
+
+func NewMessageRepository(db *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: db}
+}
+
+// WithTx returns a copy of the repository running its queries in tx
+func (r *MessageRepository) WithTx(tx *gorm.DB) *MessageRepository {
+	return &MessageRepository{db: tx}
+}
+
+// ListBySender returns the stored messages the user sent, oldest first
+func (r *MessageRepository) ListBySender(psid string) ([]models.Message, error) {
+	var messages []models.Message
+	if err := r.db.Where("sender_id = ?", psid).Order("id").Find(&messages).Error; err != nil {
+		return nil, fmt.Errorf("failed to list messages: %w", err)
+	}
+	return messages, nil
+}
+
+// DeleteBySender permanently deletes the stored messages the user sent
+func (r *MessageRepository) DeleteBySender(psid string) error {
+	if err := r.db.Unscoped().Where("sender_id = ?", psid).Delete(&models.Message{}).Error; err != nil {
+		return fmt.Errorf("failed to delete messages: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,46 @@
+package repositories
+
+import (
+	"school-assistant-wh/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type SchoolRepository struct {
+	db *gorm.DB
+}
+
+func NewSchoolRepository(db *gorm.DB) *SchoolRepository {
+	return &SchoolRepository{db: db}
+}
+
+// GetSchool returns the school with the given SchoolID, or gorm.ErrRecordNotFound
+func (r *SchoolRepository) GetSchool(schoolID string) (*models.School, error) {
+	var school models.School
+	err := r.db.Table("gk_miniapps.school").
+		Where("SchoolID = ?", schoolID).
+		First(&school).Error
+	if err != nil {
+		return nil, err
+	}
+	return &school, nil
+}
+
+// ListActiveSchools returns the schools users can link students from, by name
+func (r *SchoolRepository) ListActiveSchools() ([]models.School, error) {
+	var schools []models.School
+	err := r.db.Table("gk_miniapps.school").
+		Where("Status = ?", "Active").
+		Order("SchoolName").
+		Find(&schools).Error
+	return schools, err
+}
+
+// ListSchools returns every school, by name
+func (r *SchoolRepository) ListSchools() ([]models.School, error) {
+	var schools []models.School
+	err := r.db.Table("gk_miniapps.school").
+		Order("SchoolName").
+		Find(&schools).Error
+	return schools, err
+}
@@ -42,7 +42,7 @@ func (r *StudentProfileRepository) GetStudentProfile(schoolID, studentID string)
 		First(&student).Error
 
 	if err != nil {
-		return nil, fmt.Errorf("error fetching student profile: %v", err)
+		return nil, fmt.Errorf("error fetching student profile: %w", err)
 	}
 
 	// Fetch school information
@@ -4,9 +4,11 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"strings"
 	"time"
 
 	"school-assistant-wh/internal/cache"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/models"
 	"school-assistant-wh/internal/services/facebook"
 	"school-assistant-wh/internal/utils"
@@ -30,6 +32,14 @@ func NewUserRepository(db *gorm.DB, fbSvc *facebook.Service) *UserRepository {
 	}
 }
 
+// WithTx returns a copy of the repository running its queries in tx. It shares
+// the user cache with r.
+func (r *UserRepository) WithTx(tx *gorm.DB) *UserRepository {
+	bound := *r
+	bound.db = tx
+	return &bound
+}
+
 func (r *UserRepository) GetUserByPSID(psid string) (*models.User, error) {
 	// Check cache first
 	if cachedUser, found := r.cache.GetUser(psid); found {
@@ -56,6 +66,16 @@ func (r *UserRepository) GetUserByPSID(psid string) (*models.User, error) {
 	return &user, err
 }
 
+// GetUserByID returns the user with the ID. Unlike GetUserByPSID it does not
+// count as the user being seen, so background jobs can use it.
+func (r *UserRepository) GetUserByID(id int) (*models.User, error) {
+	var user models.User
+	if err := r.db.Where("ID = ?", id).First(&user).Error; err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
 func (r *UserRepository) RegisterUser(psid string) (*models.User, error) {
 	existingUser, err := r.GetUserByPSID(psid)
 	if err == nil {
@@ -79,6 +99,7 @@ func (r *UserRepository) RegisterUser(psid string) (*models.User, error) {
 	fbName := "USER"
 	var fbImgURL *string
 	var email *string
+	language := i18n.Default
 
 	profile, err := r.fbSvc.GetUserProfile(psid)
 	if err != nil {
@@ -91,6 +112,7 @@ func (r *UserRepository) RegisterUser(psid string) (*models.User, error) {
 		if profile.Email != "" {
 			email = &profile.Email
 		}
+		language = i18n.FromLocale(profile.Locale)
 	}
 
 	code, err := utils.GenerateUniqueCode(r.db)
@@ -103,6 +125,7 @@ func (r *UserRepository) RegisterUser(psid string) (*models.User, error) {
 		FBName:      fbName,
 		FBImgURL:    fbImgURL,
 		Email:       email,
+		Language:    string(language),
 		Code:        &code,
 		IsActive:    true,
 		LastLoginAt: &now,
@@ -122,6 +145,173 @@ func (r *UserRepository) UpdateUser(user *models.User) error {
 	return r.db.Save(user).Error
 }
 
+// SetLanguage stores the language the user wants the bot to reply in
+func (r *UserRepository) SetLanguage(psid string, lang i18n.Lang) error {
+	r.cache.Invalidate(psid)
+	err := r.db.Model(&models.User{}).
+		Where("PSID = ?", psid).
+		Update("Language", string(lang)).Error
+	if err != nil {
+		return fmt.Errorf("failed to set language: %w", err)
+	}
+	return nil
+}
+
+// RegenerateCode gives the user a new SA-ID and records why the old one was
+// revoked. With a ttl above 0 the new SA-ID expires after it.
+func (r *UserRepository) RegenerateCode(user *models.User, reason string, ttl time.Duration) error {
+	code, err := utils.GenerateUniqueCode(r.db)
+	if err != nil {
+		return fmt.Errorf("failed to generate code: %w", err)
+	}
+	var expiresAt *time.Time
+	if ttl > 0 {
+		t := time.Now().Add(ttl)
+		expiresAt = &t
+	}
+
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		if user.Code != nil {
+			revocation := models.CodeRevocation{
+				RevokedAt: time.Now(),
+				UserID:    int(user.ID),
+				Code:      *user.Code,
+				Reason:    reason,
+			}
+			if err := tx.Create(&revocation).Error; err != nil {
+				return fmt.Errorf("failed to revoke code: %w", err)
+			}
+		}
+		return tx.Model(&models.User{}).
+			Where("ID = ?", user.ID).
+			Updates(map[string]interface{}{"Code": code, "CodeExpiresAt": expiresAt}).Error
+	})
+	r.cache.Invalidate(user.PSID)
+	if err != nil {
+		return fmt.Errorf("failed to regenerate code: %w", err)
+	}
+
+	user.Code = &code
+	user.CodeExpiresAt = expiresAt
+	return nil
+}
+
+// LinkingCode returns the SA-ID to show the user for linking. With a ttl above
+// 0 an SA-ID without an expiry starts expiring now and an expired SA-ID is
+// replaced; with a ttl of 0 SA-IDs never expire.
+func (r *UserRepository) LinkingCode(user *models.User, ttl time.Duration) (string, error) {
+	if user.Code == nil {
+		return "", fmt.Errorf("user %d has no code", user.ID)
+	}
+	if ttl <= 0 {
+		return *user.Code, nil
+	}
+
+	if user.CodeExpiresAt == nil {
+		expiresAt := time.Now().Add(ttl)
+		err := r.db.Model(&models.User{}).Where("ID = ?", user.ID).Update("CodeExpiresAt", expiresAt).Error
+		r.cache.Invalidate(user.PSID)
+		if err != nil {
+			return "", fmt.Errorf("failed to set code expiry: %w", err)
+		}
+		user.CodeExpiresAt = &expiresAt
+	} else if time.Now().After(*user.CodeExpiresAt) {
+		if err := r.RegenerateCode(user, models.CodeRevokedExpired, ttl); err != nil {
+			return "", err
+		}
+	}
+	return *user.Code, nil
+}
+
+// ScrubUser deactivates the user and erases what identifies them, keeping the
+// row so audit records still point at it. Their SA-ID is revoked and their PSID
+// replaced, so messaging the page again starts a new registration.
+func (r *UserRepository) ScrubUser(user *models.User) error {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if user.Code != nil {
+			revocation := models.CodeRevocation{
+				RevokedAt: time.Now(),
+				UserID:    int(user.ID),
+				Code:      *user.Code,
+				Reason:    models.CodeRevokedDeleted,
+			}
+			if err := tx.Create(&revocation).Error; err != nil {
+				return fmt.Errorf("failed to revoke code: %w", err)
+			}
+		}
+		// LastLoginAt is NOT NULL, so it goes back to its zero default
+		return tx.Model(&models.User{}).
+			Where("ID = ?", user.ID).
+			Updates(map[string]interface{}{
+				"IsActive":      false,
+				"PSID":          fmt.Sprintf("deleted-%d", user.ID),
+				"FBName":        "",
+				"FBImgURL":      nil,
+				"Email":         nil,
+				"Code":          nil,
+				"CodeExpiresAt": nil,
+				"LastLoginAt":   gorm.Expr("DEFAULT"),
+				"Notes1":        nil,
+			}).Error
+	})
+	r.cache.Invalidate(user.PSID)
+	if err != nil {
+		return fmt.Errorf("failed to scrub user: %w", err)
+	}
+	return nil
+}
+
+// UserQuery selects users for admin lookups by PSID, SA-ID or part of their
+// name. SchoolID limits the results to users actively linked to that school.
+type UserQuery struct {
+	PSID     string
+	Code     string
+	Name     string
+	SchoolID string
+	Limit    int
+}
+
+// likeEscaper makes LIKE match wildcard characters in a search literally
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// FindUsers returns the users matching the query, by ID. Unlike GetUserByPSID
+// it does not count as the users being seen.
+func (r *UserRepository) FindUsers(q UserQuery) ([]models.User, error) {
+	db := r.db.Model(&models.User{})
+	if q.PSID != "" {
+		db = db.Where("school_messenger_users.PSID = ?", q.PSID)
+	}
+	if q.Code != "" {
+		db = db.Where("school_messenger_users.Code = ?", q.Code)
+	}
+	if q.Name != "" {
+		db = db.Where(`school_messenger_users.FBName LIKE ? ESCAPE '\\'`, "%"+likeEscaper.Replace(q.Name)+"%")
+	}
+	if q.SchoolID != "" {
+		db = db.Where("EXISTS (SELECT 1 FROM gk_miniapps.school_link_user l WHERE l.UserID = school_messenger_users.ID AND l.SchoolID = ? AND l.IsActive = ?)", q.SchoolID, true)
+	}
+	if q.Limit > 0 {
+		db = db.Limit(q.Limit)
+	}
+
+	var users []models.User
+	if err := db.Order("school_messenger_users.ID").Find(&users).Error; err != nil {
+		return nil, fmt.Errorf("failed to find users: %w", err)
+	}
+	return users, nil
+}
+
+// SetActive activates or deactivates the user
+func (r *UserRepository) SetActive(user *models.User, active bool) error {
+	err := r.db.Model(&models.User{}).Where("ID = ?", user.ID).Update("IsActive", active).Error
+	r.cache.Invalidate(user.PSID)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+	user.IsActive = active
+	return nil
+}
+
 func (r *UserRepository) MarkUserAsRegistered(psid string) error {
 	r.cache.Invalidate(psid)
 	return r.db.Model(&models.User{}).
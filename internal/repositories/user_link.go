@@ -4,12 +4,16 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"time"
 
 	"school-assistant-wh/internal/models"
 
 	"gorm.io/gorm"
 )
 
+// ErrLinkTokenRedeemed means another user already redeemed the link token
+var ErrLinkTokenRedeemed = errors.New("link token already redeemed by another user")
+
 type UserLinkRepository struct {
 	db                 *gorm.DB
 	studentProfileRepo *StudentProfileRepository
@@ -22,6 +26,13 @@ func NewUserLinkRepository(db *gorm.DB, studentProfileRepo *StudentProfileReposi
 	}
 }
 
+// WithTx returns a copy of the repository running its queries in tx
+func (r *UserLinkRepository) WithTx(tx *gorm.DB) *UserLinkRepository {
+	bound := *r
+	bound.db = tx
+	return &bound
+}
+
 func (r *UserLinkRepository) GetUserLinks(userID int) ([]models.UserLinkWithStudent, error) {
 	var links []models.UserLink
 	err := r.db.Table("gk_miniapps.school_link_user").
@@ -104,3 +115,286 @@ func (r *UserLinkRepository) UpdatePrimaryStatus(userID int, studentID string, s
 
 	return tx.Commit().Error
 }
+
+// RedeemLinkToken links the user to the student a school's link token was issued
+// for. Redeeming the same token again as the same user is harmless.
+func (r *UserLinkRepository) RedeemLinkToken(signature string, userID int, schoolID, studentID string) (*models.UserLinkWithStudent, error) {
+	student, err := r.studentProfileRepo.GetStudentProfile(schoolID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var link models.UserLink
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		var redemption models.LinkTokenRedemption
+		err := tx.Where("Signature = ?", signature).First(&redemption).Error
+		switch {
+		case err == nil && redemption.UserID != userID:
+			return ErrLinkTokenRedeemed
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			redemption = models.LinkTokenRedemption{
+				Signature:  signature,
+				UserID:     userID,
+				SchoolID:   schoolID,
+				StudentID:  studentID,
+				RedeemedAt: time.Now(),
+			}
+			if err := tx.Create(&redemption).Error; err != nil {
+				return fmt.Errorf("failed to record link token: %w", err)
+			}
+		case err != nil:
+			return fmt.Errorf("failed to check link token: %w", err)
+		}
+
+		link, err = linkStudent(tx, userID, schoolID, studentID, models.LinkRoleGuardian, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserLinkWithStudent{UserLink: link, Student: student}, nil
+}
+
+// LinkStudent links the user, in the given role, to a student whose identity
+// they verified
+func (r *UserLinkRepository) LinkStudent(userID int, schoolID, studentID, role string) (*models.UserLinkWithStudent, error) {
+	student, err := r.studentProfileRepo.GetStudentProfile(schoolID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var link models.UserLink
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		link, err = linkStudent(tx, userID, schoolID, studentID, role, false)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.UserLinkWithStudent{UserLink: link, Student: student}, nil
+}
+
+// AdminLinkStudent links the user to a student on a school admin's behalf and
+// flags the link so the user is told about it
+func (r *UserLinkRepository) AdminLinkStudent(userID int, schoolID, studentID, role string) (*models.UserLinkWithStudent, error) {
+	student, err := r.studentProfileRepo.GetStudentProfile(schoolID, studentID)
+	if err != nil {
+		return nil, err
+	}
+
+	var link models.UserLink
+	err = r.db.Transaction(func(tx *gorm.DB) error {
+		link, err = linkStudent(tx, userID, schoolID, studentID, role, true)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.studentProfileRepo.InvalidateCache(schoolID, studentID)
+	return &models.UserLinkWithStudent{UserLink: link, Student: student}, nil
+}
+
+// HasActiveLink reports whether the user is already linked to the student
+func (r *UserLinkRepository) HasActiveLink(userID int, schoolID, studentID string) (bool, error) {
+	var count int64
+	err := r.db.Table("gk_miniapps.school_link_user").
+		Where("UserID = ? AND StudentID = ? AND SchoolID = ? AND IsActive = ?", userID, studentID, schoolID, true).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// linkStudent creates or reactivates the user's link to the student in the role.
+// The first link a user gets becomes their primary profile. Announced links are
+// flagged IsNewlyLink so the user is told about them, and get a fresh CreatedAt
+// so ClearStaleNewlyLinked gives them the full notice age.
+func linkStudent(tx *gorm.DB, userID int, schoolID, studentID, role string, announce bool) (models.UserLink, error) {
+	var link models.UserLink
+
+	var primaries int64
+	if err := tx.Table("gk_miniapps.school_link_user").
+		Where("UserID = ? AND IsPrimary = ? AND IsActive = ?", userID, true, true).
+		Count(&primaries).Error; err != nil {
+		return link, err
+	}
+
+	err := tx.Table("gk_miniapps.school_link_user").
+		Where("UserID = ? AND StudentID = ? AND SchoolID = ?", userID, studentID, schoolID).
+		First(&link).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		if err := tx.Table("gk_miniapps.school_link_user").Create(map[string]interface{}{
+			"CreatedAt":   time.Now(),
+			"UserID":      userID,
+			"StudentID":   studentID,
+			"SchoolID":    schoolID,
+			"Role":        role,
+			"IsActive":    true,
+			"IsNewlyLink": announce,
+			"IsPrimary":   primaries == 0,
+		}).Error; err != nil {
+			return link, fmt.Errorf("failed to create link: %w", err)
+		}
+		err = tx.Table("gk_miniapps.school_link_user").
+			Where("UserID = ? AND StudentID = ? AND SchoolID = ?", userID, studentID, schoolID).
+			First(&link).Error
+		return link, err
+	}
+	if err != nil {
+		return link, err
+	}
+
+	if link.IsActive {
+		return link, nil
+	}
+	link.IsActive = true
+	link.IsPrimary = primaries == 0
+	link.Role = role
+	link.IsNewlyLink = announce
+	updates := map[string]interface{}{"IsActive": true, "IsPrimary": link.IsPrimary, "Role": role, "IsNewlyLink": announce}
+	if announce {
+		updates["CreatedAt"] = time.Now()
+	}
+	err = tx.Table("gk_miniapps.school_link_user").
+		Where("ID = ?", link.ID).
+		Updates(updates).Error
+	return link, err
+}
+
+// UnlinkStudent deactivates the user's link to the student. When it was the
+// primary link, the user's oldest remaining link becomes primary and is
+// returned; otherwise the returned link is nil.
+func (r *UserLinkRepository) UnlinkStudent(userID int, schoolID, studentID string) (*models.UserLinkWithStudent, error) {
+	var primary *models.UserLink
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var link models.UserLink
+		if err := tx.Table("gk_miniapps.school_link_user").
+			Where("UserID = ? AND StudentID = ? AND SchoolID = ? AND IsActive = ?", userID, studentID, schoolID, true).
+			First(&link).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Table("gk_miniapps.school_link_user").
+			Where("ID = ?", link.ID).
+			Updates(map[string]interface{}{"IsActive": false, "IsPrimary": false}).Error; err != nil {
+			return fmt.Errorf("failed to deactivate link: %w", err)
+		}
+		if !link.IsPrimary {
+			return nil
+		}
+
+		var next models.UserLink
+		err := tx.Table("gk_miniapps.school_link_user").
+			Where("UserID = ? AND IsActive = ?", userID, true).
+			Order("ID").
+			First(&next).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		next.IsPrimary = true
+		primary = &next
+		return tx.Table("gk_miniapps.school_link_user").
+			Where("ID = ?", next.ID).
+			Update("IsPrimary", true).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	r.studentProfileRepo.InvalidateCache(schoolID, studentID)
+	if primary == nil {
+		return nil, nil
+	}
+
+	result := &models.UserLinkWithStudent{UserLink: *primary}
+	student, err := r.studentProfileRepo.GetStudentProfile(primary.SchoolID, primary.StudentID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to fetch student profile: %w", err)
+	}
+	result.Student = student
+	return result, nil
+}
+
+// ListAllLinks returns every link of the user, including deactivated ones
+func (r *UserLinkRepository) ListAllLinks(userID int) ([]models.UserLink, error) {
+	var links []models.UserLink
+	err := r.db.Table("gk_miniapps.school_link_user").
+		Where("UserID = ?", userID).
+		Order("ID").
+		Find(&links).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to list links: %w", err)
+	}
+	return links, nil
+}
+
+// RemoveAllLinks deletes every link of the user and returns how many there were
+func (r *UserLinkRepository) RemoveAllLinks(userID int) (int, error) {
+	links, err := r.ListAllLinks(userID)
+	if err != nil {
+		return 0, err
+	}
+	if err := r.db.Table("gk_miniapps.school_link_user").
+		Where("UserID = ?", userID).
+		Delete(&models.UserLink{}).Error; err != nil {
+		return 0, fmt.Errorf("failed to remove links: %w", err)
+	}
+
+	for _, link := range links {
+		r.studentProfileRepo.InvalidateCache(link.SchoolID, link.StudentID)
+	}
+	return len(links), nil
+}
+
+// ListNewlyLinked returns active links a school admin created that the user has
+// not been told about yet, oldest first. Links of deactivated users and of the
+// excluded users are left out.
+func (r *UserLinkRepository) ListNewlyLinked(excludeUserIDs []int, limit int) ([]models.UserLink, error) {
+	query := r.db.Table("gk_miniapps.school_link_user AS l").
+		Select("l.*").
+		Joins("JOIN school_messenger_users AS u ON u.ID = l.UserID AND u.IsActive = ?", true).
+		Where("l.IsNewlyLink = ? AND l.IsActive = ?", true, true)
+	if len(excludeUserIDs) > 0 {
+		query = query.Where("l.UserID NOT IN ?", excludeUserIDs)
+	}
+
+	var links []models.UserLink
+	err := query.Order("l.ID").Limit(limit).Find(&links).Error
+	return links, err
+}
+
+// ClearNewlyLinked marks the link as announced to the user
+func (r *UserLinkRepository) ClearNewlyLinked(linkID int) error {
+	return r.db.Table("gk_miniapps.school_link_user").
+		Where("ID = ?", linkID).
+		Update("IsNewlyLink", false).Error
+}
+
+// ClearStaleNewlyLinked marks links created before the time as announced
+// without telling the user, returning how many there were. linkStudent sets
+// CreatedAt rather than relying on a column default.
+func (r *UserLinkRepository) ClearStaleNewlyLinked(before time.Time) (int64, error) {
+	result := r.db.Table("gk_miniapps.school_link_user").
+		Where("IsNewlyLink = ? AND CreatedAt < ?", true, before).
+		Update("IsNewlyLink", false)
+	return result.RowsAffected, result.Error
+}
+
+// MakePrimary makes the user's active link with the ID their primary profile
+func (r *UserLinkRepository) MakePrimary(userID, linkID int) (*models.UserLinkWithStudent, error) {
+	var link models.UserLink
+	if err := r.db.Table("gk_miniapps.school_link_user").
+		Where("ID = ? AND UserID = ? AND IsActive = ?", linkID, userID, true).
+		First(&link).Error; err != nil {
+		return nil, err
+	}
+	if err := r.UpdatePrimaryStatus(userID, link.StudentID, link.SchoolID); err != nil {
+		return nil, err
+	}
+	return r.GetPrimaryLink(userID)
+}
@@ -0,0 +1,212 @@
+package facebook
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"school-assistant-wh/internal/config"
+)
+
+const (
+	retryBaseDelay = 250 * time.Millisecond
+	retryMaxDelay  = 8 * time.Second
+)
+
+// client is the Graph API HTTP client shared by every Service method
+type client struct {
+	http        *http.Client
+	baseURL     string
+	version     string
+	accessToken string
+	maxRetries  int
+}
+
+func newClient(cfg config.FacebookConfig) *client {
+	return &client{
+		http:        &http.Client{Timeout: cfg.HTTPTimeout},
+		baseURL:     strings.TrimRight(cfg.GraphBaseURL, "/"),
+		version:     cfg.GraphAPIVersion,
+		accessToken: cfg.PageAccessToken,
+		maxRetries:  cfg.MaxRetries,
+	}
+}
+
+// get calls a Graph API endpoint and decodes the JSON response into out
+func (c *client) get(path string, query url.Values, out any) error {
+	return c.do(http.MethodGet, path, query, nil, "", out)
+}
+
+// post sends body as JSON to a Graph API endpoint and decodes the response into out
+func (c *client) post(path string, body any, out any) error {
+	jsonData, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshaling request: %w", err)
+	}
+	return c.do(http.MethodPost, path, nil, jsonData, "application/json", out)
+}
+
+// formFile is a file sent in a multipart form
+type formFile struct {
+	field       string
+	filename    string
+	contentType string
+	data        []byte
+}
+
+// postForm sends fields and file as a multipart form to a Graph API endpoint
+// and decodes the response into out
+func (c *client) postForm(path string, fields map[string]string, file formFile, out any) error {
+	var buf bytes.Buffer
+	w := multipart.NewWriter(&buf)
+	for name, value := range fields {
+		if err := w.WriteField(name, value); err != nil {
+			return fmt.Errorf("error writing form field %s: %w", name, err)
+		}
+	}
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, file.field, file.filename))
+	header.Set("Content-Type", file.contentType)
+	part, err := w.CreatePart(header)
+	if err != nil {
+		return fmt.Errorf("error creating form file: %w", err)
+	}
+	if _, err := part.Write(file.data); err != nil {
+		return fmt.Errorf("error writing form file: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("error closing form: %w", err)
+	}
+	return c.do(http.MethodPost, path, nil, buf.Bytes(), w.FormDataContentType(), out)
+}
+
+// do performs the request, retrying 429/5xx responses and transient Graph error
+// codes with jittered exponential backoff. Network failures are only retried
+// when resending cannot deliver a message twice: for GETs, or when the request
+// never reached Graph.
+func (c *client) do(method, path string, query url.Values, body []byte, contentType string, out any) error {
+	var lastErr error
+	for attempt := 0; attempt <= c.maxRetries; attempt++ {
+		if attempt > 0 {
+			delay := backoff(attempt, lastErr)
+			log.Printf("Retrying Graph API %s %s in %s (attempt %d/%d): %v", method, path, delay, attempt, c.maxRetries, lastErr)
+			time.Sleep(delay)
+		}
+
+		retry, err := c.attempt(method, path, query, body, contentType, out)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return lastErr
+}
+
+// attempt performs a single request and reports whether a failure is worth retrying
+func (c *client) attempt(method, path string, query url.Values, body []byte, contentType string, out any) (bool, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequest(method, c.url(path, query), reader)
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return method == http.MethodGet || notSent(err), fmt.Errorf("error calling Graph API: %w", err)
+	}
+	defer resp.Body.Close()
+
+	// Graph has handled the request by now, so only a GET is safe to repeat
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return method == http.MethodGet, fmt.Errorf("error reading Graph API response: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		graphErr := parseGraphError(resp, respBody)
+		return graphErr.Temporary(), graphErr
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(respBody, out); err != nil {
+			return false, fmt.Errorf("error decoding response: %w", err)
+		}
+	}
+	return false, nil
+}
+
+// notSent reports whether the request failed before reaching Graph, because no
+// connection could be made
+func notSent(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func (c *client) url(path string, query url.Values) string {
+	q := url.Values{}
+	for k, v := range query {
+		q[k] = v
+	}
+	q.Set("access_token", c.accessToken)
+	return fmt.Sprintf("%s/%s/%s?%s", c.baseURL, c.version, strings.TrimLeft(path, "/"), q.Encode())
+}
+
+// parseGraphError decodes the {"error": {...}} body of a failed request
+func parseGraphError(resp *http.Response, body []byte) *GraphError {
+	var envelope struct {
+		Error *GraphError `json:"error"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil || envelope.Error == nil {
+		envelope.Error = &GraphError{Message: strings.TrimSpace(string(body))}
+	}
+	envelope.Error.StatusCode = resp.StatusCode
+	envelope.Error.RetryAfter = retryAfter(resp)
+	return envelope.Error
+}
+
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds <= 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// backoff returns the delay before the given retry attempt: a random duration up
+// to an exponentially growing cap, or the server's Retry-After when it is longer
+func backoff(attempt int, lastErr error) time.Duration {
+	ceiling := retryBaseDelay << (attempt - 1)
+	if ceiling > retryMaxDelay || ceiling <= 0 {
+		ceiling = retryMaxDelay
+	}
+	delay := ceiling/2 + time.Duration(rand.Int63n(int64(ceiling/2)+1))
+
+	var graphErr *GraphError
+	if errors.As(lastErr, &graphErr) && graphErr.RetryAfter > delay {
+		delay = graphErr.RetryAfter
+	}
+	return delay
+}
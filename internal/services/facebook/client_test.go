@@ -0,0 +1,262 @@
+package facebook
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"school-assistant-wh/internal/config"
+)
+
+var sentinels = []error{ErrUserUnavailable, ErrOutsideWindow, ErrInvalidRecipient, ErrRateLimited, ErrInvalidToken}
+
+func TestGraphErrorIs(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *GraphError
+		want error
+	}{
+		{"user unavailable code", &GraphError{StatusCode: 400, Code: 551}, ErrUserUnavailable},
+		{"user blocked page", &GraphError{StatusCode: 400, Code: 10, Subcode: 1545041}, ErrUserUnavailable},
+		{"user unavailable subcode", &GraphError{StatusCode: 400, Code: 100, Subcode: 2018108}, ErrUserUnavailable},
+		{"outside window", &GraphError{StatusCode: 400, Code: 10, Subcode: 2018278}, ErrOutsideWindow},
+		{"outside window policy", &GraphError{StatusCode: 400, Code: 10, Subcode: 2018065}, ErrOutsideWindow},
+		{"no matching user", &GraphError{StatusCode: 400, Code: 100, Subcode: 2018001}, ErrInvalidRecipient},
+		{"429", &GraphError{StatusCode: http.StatusTooManyRequests}, ErrRateLimited},
+		{"app rate limit", &GraphError{StatusCode: 400, Code: 4}, ErrRateLimited},
+		{"user rate limit", &GraphError{StatusCode: 400, Code: 17}, ErrRateLimited},
+		{"page rate limit", &GraphError{StatusCode: 400, Code: 32}, ErrRateLimited},
+		{"custom rate limit", &GraphError{StatusCode: 400, Code: 613}, ErrRateLimited},
+		{"invalid token", &GraphError{StatusCode: 401, Code: 190}, ErrInvalidToken},
+		{"other code 100", &GraphError{StatusCode: 400, Code: 100}, nil},
+		{"other code 10", &GraphError{StatusCode: 400, Code: 10}, nil},
+		{"server error", &GraphError{StatusCode: 500, Code: 1}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Wrapped the way Service methods return them
+			err := fmt.Errorf("error sending message: %w", tt.err)
+			for _, sentinel := range sentinels {
+				if got := errors.Is(err, sentinel); got != (sentinel == tt.want) {
+					t.Errorf("errors.Is(%v, %v) = %v", tt.err, sentinel, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGraphErrorTemporary(t *testing.T) {
+	tests := []struct {
+		name string
+		err  *GraphError
+		want bool
+	}{
+		{"429", &GraphError{StatusCode: http.StatusTooManyRequests}, true},
+		{"500", &GraphError{StatusCode: http.StatusInternalServerError}, true},
+		{"503", &GraphError{StatusCode: http.StatusServiceUnavailable}, true},
+		{"unknown error", &GraphError{StatusCode: 400, Code: 1}, true},
+		{"service unavailable", &GraphError{StatusCode: 400, Code: 2}, true},
+		{"app rate limit", &GraphError{StatusCode: 400, Code: 4}, true},
+		{"custom rate limit", &GraphError{StatusCode: 400, Code: 613}, true},
+		{"flagged transient", &GraphError{StatusCode: 400, Code: 100, IsTransient: true}, true},
+		{"invalid token", &GraphError{StatusCode: 401, Code: 190}, false},
+		{"invalid parameter", &GraphError{StatusCode: 400, Code: 100}, false},
+		{"outside window", &GraphError{StatusCode: 400, Code: 10, Subcode: 2018278}, false},
+		{"user unavailable", &GraphError{StatusCode: 400, Code: 551}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.err.Temporary(); got != tt.want {
+				t.Fatalf("Temporary() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGraphError(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string
+		body       string
+		want       GraphError
+	}{
+		{
+			name:   "graph error",
+			status: 400,
+			body:   `{"error":{"message":"Invalid OAuth access token.","type":"OAuthException","code":190,"error_subcode":463,"fbtrace_id":"abc"}}`,
+			want:   GraphError{StatusCode: 400, Message: "Invalid OAuth access token.", Type: "OAuthException", Code: 190, Subcode: 463, FBTraceID: "abc"},
+		},
+		{
+			name:       "retry after",
+			status:     429,
+			retryAfter: "7",
+			body:       `{"error":{"message":"Too many calls","code":4,"is_transient":true}}`,
+			want:       GraphError{StatusCode: 429, Message: "Too many calls", Code: 4, IsTransient: true, RetryAfter: 7 * time.Second},
+		},
+		{
+			name:       "retry after date is ignored",
+			status:     503,
+			retryAfter: "Wed, 21 Oct 2015 07:28:00 GMT",
+			body:       `{"error":{"message":"unavailable","code":2}}`,
+			want:       GraphError{StatusCode: 503, Message: "unavailable", Code: 2},
+		},
+		{
+			name:   "not json",
+			status: 502,
+			body:   " Bad Gateway \n",
+			want:   GraphError{StatusCode: 502, Message: "Bad Gateway"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			if tt.retryAfter != "" {
+				resp.Header.Set("Retry-After", tt.retryAfter)
+			}
+			if got := parseGraphError(resp, []byte(tt.body)); *got != tt.want {
+				t.Fatalf("parseGraphError() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	for attempt := 1; attempt <= 10; attempt++ {
+		ceiling := retryBaseDelay << (attempt - 1)
+		if ceiling > retryMaxDelay {
+			ceiling = retryMaxDelay
+		}
+		for i := 0; i < 50; i++ {
+			if d := backoff(attempt, errors.New("network error")); d < ceiling/2 || d > ceiling {
+				t.Fatalf("backoff(%d) = %s, want between %s and %s", attempt, d, ceiling/2, ceiling)
+			}
+		}
+	}
+
+	if d := backoff(64, nil); d < retryMaxDelay/2 || d > retryMaxDelay {
+		t.Fatalf("backoff(64) = %s, want it capped at %s", d, retryMaxDelay)
+	}
+
+	// A longer Retry-After wins over the backoff, a shorter one does not
+	wrapped := fmt.Errorf("error sending message: %w", &GraphError{StatusCode: 429, RetryAfter: 5 * time.Second})
+	if d := backoff(1, wrapped); d != 5*time.Second {
+		t.Fatalf("backoff with Retry-After 5s = %s, want 5s", d)
+	}
+	short := &GraphError{StatusCode: 429, RetryAfter: time.Millisecond}
+	if d := backoff(3, short); d < retryBaseDelay*2 {
+		t.Fatalf("backoff with Retry-After 1ms = %s, want at least %s", d, retryBaseDelay*2)
+	}
+}
+
+func TestClientRetries(t *testing.T) {
+	const maxRetries = 2
+
+	tests := []struct {
+		name     string
+		method   string
+		status   int
+		body     string
+		slow     bool
+		attempts int32
+		want     error
+	}{
+		{"success", http.MethodPost, 200, `{"message_id":"m_1"}`, false, 1, nil},
+		{"invalid token", http.MethodPost, 401, `{"error":{"message":"Invalid OAuth access token.","code":190}}`, false, 1, ErrInvalidToken},
+		{"permission denied", http.MethodPost, 403, `{"error":{"message":"Permissions error","code":10}}`, false, 1, nil},
+		{"outside window", http.MethodPost, 400, `{"error":{"message":"outside window","code":10,"error_subcode":2018278}}`, false, 1, ErrOutsideWindow},
+		{"server error", http.MethodPost, 500, `{"error":{"message":"unknown","code":1}}`, false, maxRetries + 1, nil},
+		{"rate limited", http.MethodPost, 429, `{"error":{"message":"Too many calls","code":4}}`, false, maxRetries + 1, ErrRateLimited},
+		// Graph may have delivered a message whose response timed out, so
+		// sending it again could deliver it twice
+		{"post timeout", http.MethodPost, 200, `{"message_id":"m_1"}`, true, 1, nil},
+		{"get timeout", http.MethodGet, 200, `{}`, true, maxRetries + 1, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				if tt.slow {
+					time.Sleep(300 * time.Millisecond)
+				}
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			}))
+			defer srv.Close()
+
+			c := newClient(config.FacebookConfig{
+				PageAccessToken: "token",
+				GraphBaseURL:    srv.URL,
+				GraphAPIVersion: "v18.0",
+				HTTPTimeout:     100 * time.Millisecond,
+				MaxRetries:      maxRetries,
+			})
+			var err error
+			if tt.method == http.MethodGet {
+				err = c.get("/me/messenger_profile", nil, nil)
+			} else {
+				err = c.post("/me/messages", map[string]string{"text": "hi"}, nil)
+			}
+
+			if got := attempts.Load(); got != tt.attempts {
+				t.Fatalf("attempts = %d, want %d", got, tt.attempts)
+			}
+			if (err == nil) != (tt.status == 200 && !tt.slow) {
+				t.Fatalf("post() error = %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("post() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotSent(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	closed := srv.URL
+	srv.Close()
+
+	_, err := http.Get(closed)
+	if err == nil || !notSent(err) {
+		t.Fatalf("notSent(%v) = false for a refused connection, want true", err)
+	}
+	if notSent(context.DeadlineExceeded) {
+		t.Fatal("notSent(deadline exceeded) = true, want false")
+	}
+}
+
+func TestClientHonorsRetryAfter(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error":{"message":"Too many calls","code":4}}`))
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer srv.Close()
+
+	c := newClient(config.FacebookConfig{GraphBaseURL: srv.URL, GraphAPIVersion: "v18.0", HTTPTimeout: 5 * time.Second, MaxRetries: 1})
+	start := time.Now()
+	if err := c.post("/me/messages", map[string]string{"text": "hi"}, nil); err != nil {
+		t.Fatalf("post() error = %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < time.Second {
+		t.Fatalf("retried after %s, want the server's Retry-After of 1s", elapsed)
+	}
+	if got := attempts.Load(); got != 2 {
+		t.Fatalf("attempts = %d, want 2", got)
+	}
+}
@@ -0,0 +1,70 @@
+package facebook
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Errors that callers can match with errors.Is on the error returned by any
+// Service method
+var (
+	// ErrUserUnavailable means the user blocked the page or can no longer be messaged
+	ErrUserUnavailable = errors.New("facebook: user is unavailable")
+	// ErrOutsideWindow means the message was sent outside the 24-hour standard messaging window
+	ErrOutsideWindow = errors.New("facebook: outside the allowed messaging window")
+	// ErrInvalidRecipient means no user matches the recipient PSID
+	ErrInvalidRecipient = errors.New("facebook: no matching user for recipient")
+	// ErrRateLimited means the page or app exceeded a Graph API rate limit
+	ErrRateLimited = errors.New("facebook: rate limited")
+	// ErrInvalidToken means the page access token is invalid or expired
+	ErrInvalidToken = errors.New("facebook: invalid access token")
+)
+
+// GraphError is an error response returned by the Graph API
+type GraphError struct {
+	StatusCode  int    `json:"-"`
+	Message     string `json:"message"`
+	Type        string `json:"type"`
+	Code        int    `json:"code"`
+	Subcode     int    `json:"error_subcode"`
+	IsTransient bool   `json:"is_transient"`
+	FBTraceID   string `json:"fbtrace_id"`
+
+	// RetryAfter is the delay requested by the server's Retry-After header, if any
+	RetryAfter time.Duration `json:"-"`
+}
+
+func (e *GraphError) Error() string {
+	return fmt.Sprintf("facebook API error %d (code %d, subcode %d): %s", e.StatusCode, e.Code, e.Subcode, e.Message)
+}
+
+// Is maps Graph API error codes onto the package's sentinel errors
+func (e *GraphError) Is(target error) bool {
+	switch target {
+	case ErrUserUnavailable:
+		return e.Code == 551 || e.Subcode == 1545041 || e.Subcode == 2018108
+	case ErrOutsideWindow:
+		return e.Code == 10 && (e.Subcode == 2018278 || e.Subcode == 2018065)
+	case ErrInvalidRecipient:
+		return e.Code == 100 && e.Subcode == 2018001
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests || e.Code == 4 || e.Code == 17 || e.Code == 32 || e.Code == 613
+	case ErrInvalidToken:
+		return e.Code == 190
+	}
+	return false
+}
+
+// Temporary reports whether the request may succeed if retried
+func (e *GraphError) Temporary() bool {
+	if e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError {
+		return true
+	}
+	switch e.Code {
+	case 1, 2, 4, 613:
+		return true
+	}
+	return e.IsTransient
+}
@@ -0,0 +1,198 @@
+package facebook
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"school-assistant-wh/internal/config"
+)
+
+// Messenger profile limits
+const (
+	maxMenuItems      = 20
+	maxMenuItemTitle  = 30
+	maxGreetingText   = 160
+	maxIceBreakers    = 4
+	maxIceBreakerText = 80
+)
+
+// profileFields are the Messenger profile fields managed by ApplyMessengerProfile
+var profileFields = []string{"get_started", "greeting", "persistent_menu", "ice_breakers"}
+
+// MessengerProfile is the subset of the page's Messenger profile the bot manages
+type MessengerProfile struct {
+	GetStarted     *GetStarted      `json:"get_started,omitempty"`
+	Greeting       []Greeting       `json:"greeting,omitempty"`
+	PersistentMenu []PersistentMenu `json:"persistent_menu,omitempty"`
+	IceBreakers    []IceBreakerSet  `json:"ice_breakers,omitempty"`
+}
+
+type GetStarted struct {
+	Payload string `json:"payload"`
+}
+
+type Greeting struct {
+	Locale string `json:"locale"`
+	Text   string `json:"text"`
+}
+
+// PersistentMenu is the menu shown for one locale
+type PersistentMenu struct {
+	Locale                string     `json:"locale"`
+	ComposerInputDisabled bool       `json:"composer_input_disabled"`
+	CallToActions         []MenuItem `json:"call_to_actions"`
+}
+
+// MenuItem is a postback or web_url entry of the persistent menu
+type MenuItem struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	Payload string `json:"payload,omitempty"`
+	URL     string `json:"url,omitempty"`
+}
+
+// IceBreakerSet holds the ice breakers shown for one locale
+type IceBreakerSet struct {
+	Locale        string       `json:"locale"`
+	CallToActions []IceBreaker `json:"call_to_actions"`
+}
+
+type IceBreaker struct {
+	Question string `json:"question"`
+	Payload  string `json:"payload"`
+}
+
+// ProfileFromConfig builds a Messenger profile from its config definition,
+// rejecting entries that Messenger would refuse
+func ProfileFromConfig(cfg config.MessengerProfileConfig) (MessengerProfile, error) {
+	var profile MessengerProfile
+
+	if cfg.GetStartedPayload != "" {
+		profile.GetStarted = &GetStarted{Payload: cfg.GetStartedPayload}
+	}
+
+	hasDefault := len(cfg.Greetings) == 0
+	for _, g := range cfg.Greetings {
+		if g.Locale == "" || g.Text == "" {
+			return MessengerProfile{}, fmt.Errorf("greeting needs a locale and text")
+		}
+		if len([]rune(g.Text)) > maxGreetingText {
+			return MessengerProfile{}, fmt.Errorf("greeting for %s is longer than %d characters", g.Locale, maxGreetingText)
+		}
+		hasDefault = hasDefault || g.Locale == "default"
+		profile.Greeting = append(profile.Greeting, Greeting{Locale: g.Locale, Text: g.Text})
+	}
+	if !hasDefault {
+		return MessengerProfile{}, fmt.Errorf("greetings must include the default locale")
+	}
+
+	if len(cfg.PersistentMenu) > maxMenuItems {
+		return MessengerProfile{}, fmt.Errorf("persistent menu supports at most %d items, got %d", maxMenuItems, len(cfg.PersistentMenu))
+	}
+	if len(cfg.PersistentMenu) > 0 {
+		menu := PersistentMenu{Locale: "default", ComposerInputDisabled: cfg.ComposerInputDisabled}
+		for _, item := range cfg.PersistentMenu {
+			if item.Title == "" || len([]rune(item.Title)) > maxMenuItemTitle {
+				return MessengerProfile{}, fmt.Errorf("menu item title %q must be 1-%d characters", item.Title, maxMenuItemTitle)
+			}
+			switch {
+			case item.URL != "":
+				menu.CallToActions = append(menu.CallToActions, MenuItem{Type: "web_url", Title: item.Title, URL: item.URL})
+			case item.Payload != "":
+				menu.CallToActions = append(menu.CallToActions, MenuItem{Type: "postback", Title: item.Title, Payload: item.Payload})
+			default:
+				return MessengerProfile{}, fmt.Errorf("menu item %q needs a payload or url", item.Title)
+			}
+		}
+		profile.PersistentMenu = []PersistentMenu{menu}
+	}
+
+	if len(cfg.IceBreakers) > maxIceBreakers {
+		return MessengerProfile{}, fmt.Errorf("at most %d ice breakers are supported, got %d", maxIceBreakers, len(cfg.IceBreakers))
+	}
+	if len(cfg.IceBreakers) > 0 {
+		set := IceBreakerSet{Locale: "default"}
+		for _, ib := range cfg.IceBreakers {
+			if ib.Question == "" || ib.Payload == "" || len([]rune(ib.Question)) > maxIceBreakerText {
+				return MessengerProfile{}, fmt.Errorf("ice breaker %q needs a payload and a question of 1-%d characters", ib.Question, maxIceBreakerText)
+			}
+			set.CallToActions = append(set.CallToActions, IceBreaker{Question: ib.Question, Payload: ib.Payload})
+		}
+		profile.IceBreakers = []IceBreakerSet{set}
+	}
+
+	return profile, nil
+}
+
+// GetMessengerProfile fetches the page's current Messenger profile
+func (s *Service) GetMessengerProfile() (*MessengerProfile, error) {
+	var resp struct {
+		Data []MessengerProfile `json:"data"`
+	}
+
+	query := url.Values{"fields": {strings.Join(profileFields, ",")}}
+	if err := s.client.get("/me/messenger_profile", query, &resp); err != nil {
+		return nil, fmt.Errorf("error fetching messenger profile: %w", err)
+	}
+
+	if len(resp.Data) == 0 {
+		return &MessengerProfile{}, nil
+	}
+	return &resp.Data[0], nil
+}
+
+// ApplyMessengerProfile updates only the profile fields that differ from the
+// page's current profile and returns their names. Applying the same profile
+// twice makes no changes the second time.
+func (s *Service) ApplyMessengerProfile(desired MessengerProfile) ([]string, error) {
+	current, err := s.GetMessengerProfile()
+	if err != nil {
+		return nil, err
+	}
+
+	want, err := profileFieldValues(desired)
+	if err != nil {
+		return nil, err
+	}
+	have, err := profileFieldValues(*current)
+	if err != nil {
+		return nil, err
+	}
+
+	update := map[string]json.RawMessage{}
+	var changed []string
+	for _, field := range profileFields {
+		value, ok := want[field]
+		if !ok || bytes.Equal(value, have[field]) {
+			continue
+		}
+		update[field] = value
+		changed = append(changed, field)
+	}
+
+	if len(update) == 0 {
+		return nil, nil
+	}
+
+	if err := s.client.post("/me/messenger_profile", update, nil); err != nil {
+		return nil, fmt.Errorf("error updating messenger profile fields %s: %w", strings.Join(changed, ", "), err)
+	}
+	return changed, nil
+}
+
+// profileFieldValues encodes each set field of the profile for comparison
+func profileFieldValues(profile MessengerProfile) (map[string]json.RawMessage, error) {
+	data, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("error encoding messenger profile: %w", err)
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("error decoding messenger profile: %w", err)
+	}
+	return fields, nil
+}
@@ -0,0 +1,220 @@
+package facebook
+
+import (
+	"log"
+	"strings"
+	"sync"
+	"time"
+)
+
+// MaxTextLength is the longest text Messenger accepts in a single message
+const MaxTextLength = 2000
+
+// Message is the body of an outbound message. Set Text or Attachment, optionally
+// with QuickReplies.
+type Message struct {
+	Text         string             `json:"text,omitempty"`
+	Attachment   *MessageAttachment `json:"attachment,omitempty"`
+	QuickReplies []QuickReply       `json:"quick_replies,omitempty"`
+
+	// Optional messages that fail to send are logged and skipped instead of
+	// aborting the rest of the sequence
+	Optional bool `json:"-"`
+}
+
+// MessageAttachment is an image, file or template attached to a message
+type MessageAttachment struct {
+	Type    string `json:"type"`
+	Payload any    `json:"payload"`
+}
+
+// TextMessage builds a plain text message
+func TextMessage(text string) Message {
+	return Message{Text: text}
+}
+
+// SplitText breaks text into parts of at most max characters, cutting at a
+// paragraph, line or word break where one falls in the second half of a part
+func SplitText(text string, max int) []string {
+	var parts []string
+	runes := []rune(strings.TrimSpace(text))
+	for len(runes) > max {
+		window := string(runes[:max])
+		cut := -1
+		for _, sep := range []string{"\n\n", "\n", " "} {
+			if i := strings.LastIndex(window, sep); i >= len(window)/2 {
+				cut = len([]rune(window[:i]))
+				break
+			}
+		}
+		if cut <= 0 {
+			cut = max
+		}
+		parts = append(parts, strings.TrimSpace(string(runes[:cut])))
+		runes = []rune(strings.TrimSpace(string(runes[cut:])))
+	}
+	if len(runes) > 0 {
+		parts = append(parts, string(runes))
+	}
+	return parts
+}
+
+// QuickRepliesMessage builds a text message with quick replies
+func QuickRepliesMessage(text string, quickReplies []QuickReply) Message {
+	return Message{Text: text, QuickReplies: quickReplies}
+}
+
+// ImageMessage builds an image message from a public image URL
+func ImageMessage(imageURL string) Message {
+	return Message{
+		Attachment: &MessageAttachment{
+			Type: "image",
+			Payload: map[string]any{
+				"url":         imageURL,
+				"is_reusable": true,
+			},
+		},
+	}
+}
+
+// UploadedImageMessage builds an image message from an attachment uploaded with
+// UploadImage
+func UploadedImageMessage(attachmentID string) Message {
+	return Message{
+		Attachment: &MessageAttachment{
+			Type:    "image",
+			Payload: map[string]any{"attachment_id": attachmentID},
+		},
+	}
+}
+
+// outbound is a single Send API request body
+type outbound struct {
+	payload  map[string]any
+	optional bool
+	// unpaced requests, like sender actions, skip the per-recipient interval
+	unpaced bool
+}
+
+// sendJob is a batch of requests that must reach one recipient in order
+type sendJob struct {
+	requests []outbound
+	done     chan error
+}
+
+// recipientQueue holds the jobs waiting for one recipient
+type recipientQueue struct {
+	jobs    chan *sendJob
+	pending int
+}
+
+// sendQueue serializes outbound messages per recipient, so they arrive in the
+// order they were sent, and paces them to stay within Messenger rate limits.
+// Each recipient gets a goroutine only while it has messages waiting.
+type sendQueue struct {
+	post     func(payload map[string]any) error
+	interval time.Duration
+	limiter  *rateLimiter
+
+	mu         sync.Mutex
+	recipients map[string]*recipientQueue
+}
+
+func newSendQueue(post func(payload map[string]any) error, interval time.Duration, ratePerSecond int) *sendQueue {
+	return &sendQueue{
+		post:       post,
+		interval:   interval,
+		limiter:    newRateLimiter(ratePerSecond),
+		recipients: make(map[string]*recipientQueue),
+	}
+}
+
+// send queues the requests behind anything already waiting for the recipient
+// and blocks until they have been sent. Sending stops at the first required
+// request that fails, so later messages never arrive without the earlier ones.
+func (q *sendQueue) send(recipientID string, requests []outbound) error {
+	job := &sendJob{requests: requests, done: make(chan error, 1)}
+
+	q.mu.Lock()
+	rq, exists := q.recipients[recipientID]
+	if !exists {
+		rq = &recipientQueue{jobs: make(chan *sendJob, 16)}
+		q.recipients[recipientID] = rq
+		go q.run(recipientID, rq)
+	}
+	rq.pending++
+	q.mu.Unlock()
+
+	rq.jobs <- job
+	return <-job.done
+}
+
+func (q *sendQueue) run(recipientID string, rq *recipientQueue) {
+	var lastSent time.Time
+	for job := range rq.jobs {
+		job.done <- q.deliver(recipientID, job, &lastSent)
+
+		q.mu.Lock()
+		rq.pending--
+		if rq.pending == 0 {
+			delete(q.recipients, recipientID)
+			q.mu.Unlock()
+			return
+		}
+		q.mu.Unlock()
+	}
+}
+
+func (q *sendQueue) deliver(recipientID string, job *sendJob, lastSent *time.Time) error {
+	for _, req := range job.requests {
+		if wait := q.interval - time.Since(*lastSent); wait > 0 && !req.unpaced {
+			time.Sleep(wait)
+		}
+		q.limiter.wait()
+
+		err := q.post(req.payload)
+		if !req.unpaced {
+			*lastSent = time.Now()
+		}
+		if err != nil {
+			if req.optional {
+				log.Printf("Skipping optional message to %s: %v", recipientID, err)
+				continue
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// rateLimiter spaces out requests across all recipients
+type rateLimiter struct {
+	mu    sync.Mutex
+	every time.Duration
+	next  time.Time
+}
+
+func newRateLimiter(perSecond int) *rateLimiter {
+	if perSecond <= 0 {
+		return &rateLimiter{}
+	}
+	return &rateLimiter{every: time.Second / time.Duration(perSecond)}
+}
+
+// wait blocks until the caller may make the next request
+func (l *rateLimiter) wait() {
+	if l.every == 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	wait := l.next.Sub(now)
+	l.next = l.next.Add(l.every)
+	l.mu.Unlock()
+
+	time.Sleep(wait)
+}
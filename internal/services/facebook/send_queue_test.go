@@ -0,0 +1,279 @@
+package facebook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"school-assistant-wh/internal/config"
+)
+
+// received is a message as it reached the fake Send API
+type received struct {
+	recipient string
+	text      string
+	at        time.Time
+}
+
+// sendAPI is a fake Send API that records messages in arrival order and fails
+// those whose text starts with "fail"
+type sendAPI struct {
+	mu       sync.Mutex
+	messages []received
+}
+
+func (s *sendAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Recipient struct {
+			ID string `json:"id"`
+		} `json:"recipient"`
+		Message struct {
+			Text string `json:"text"`
+		} `json:"message"`
+	}
+	json.NewDecoder(r.Body).Decode(&body)
+
+	s.mu.Lock()
+	s.messages = append(s.messages, received{recipient: body.Recipient.ID, text: body.Message.Text, at: time.Now()})
+	s.mu.Unlock()
+
+	if strings.HasPrefix(body.Message.Text, "fail") {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":{"message":"Invalid parameter","code":100}}`))
+		return
+	}
+	w.Write([]byte(`{"recipient_id":"` + body.Recipient.ID + `","message_id":"m_1"}`))
+}
+
+// texts returns the texts the recipient was sent, in arrival order
+func (s *sendAPI) texts(recipient string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var texts []string
+	for _, m := range s.messages {
+		if m.recipient == recipient {
+			texts = append(texts, m.text)
+		}
+	}
+	return texts
+}
+
+func (s *sendAPI) received() []received {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]received(nil), s.messages...)
+}
+
+func newQueueTest(t *testing.T, interval time.Duration, ratePerSecond int) (*Service, *sendAPI) {
+	t.Helper()
+	api := &sendAPI{}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+
+	return NewService(config.FacebookConfig{
+		PageAccessToken:   "page-token",
+		GraphBaseURL:      srv.URL,
+		GraphAPIVersion:   "v18.0",
+		HTTPTimeout:       5 * time.Second,
+		SendInterval:      interval,
+		SendRatePerSecond: ratePerSecond,
+	}), api
+}
+
+func texts(prefix string, n int) []Message {
+	messages := make([]Message, n)
+	for i := range messages {
+		messages[i] = TextMessage(fmt.Sprintf("%s-%d", prefix, i))
+	}
+	return messages
+}
+
+func TestSendSequenceKeepsOrderPerRecipient(t *testing.T) {
+	svc, api := newQueueTest(t, 0, 0)
+
+	// Sequences sent at the same time to one recipient arrive one after another,
+	// never interleaved
+	const senders, perSequence = 8, 4
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		for _, psid := range []string{"psid-1", "psid-2"} {
+			wg.Add(1)
+			go func(psid string, i int) {
+				defer wg.Done()
+				if err := svc.SendSequence(psid, texts(fmt.Sprintf("seq%d", i), perSequence)); err != nil {
+					t.Errorf("SendSequence: %v", err)
+				}
+			}(psid, i)
+		}
+	}
+	wg.Wait()
+
+	for _, psid := range []string{"psid-1", "psid-2"} {
+		got := api.texts(psid)
+		if len(got) != senders*perSequence {
+			t.Fatalf("%s got %d messages, want %d", psid, len(got), senders*perSequence)
+		}
+		for start := 0; start < len(got); start += perSequence {
+			prefix := strings.Split(got[start], "-")[0]
+			for j := 0; j < perSequence; j++ {
+				if want := fmt.Sprintf("%s-%d", prefix, j); got[start+j] != want {
+					t.Fatalf("%s message %d = %q, want %q in %q", psid, start+j, got[start+j], want, got)
+				}
+			}
+		}
+	}
+}
+
+func TestSendSequencePacesEachRecipient(t *testing.T) {
+	const interval = 50 * time.Millisecond
+	svc, api := newQueueTest(t, interval, 0)
+
+	if err := svc.SendSequence("psid-1", texts("m", 4)); err != nil {
+		t.Fatal(err)
+	}
+
+	got := api.received()
+	for i := 1; i < len(got); i++ {
+		if gap := got[i].at.Sub(got[i-1].at); gap < interval-5*time.Millisecond {
+			t.Fatalf("message %d arrived %s after the previous one, want at least %s", i, gap, interval)
+		}
+	}
+}
+
+func TestSendQueueRateLimitsAcrossRecipients(t *testing.T) {
+	const perSecond = 20
+	svc, api := newQueueTest(t, 0, perSecond)
+
+	const recipients = 6
+	var wg sync.WaitGroup
+	for i := 0; i < recipients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if err := svc.Send(fmt.Sprintf("psid-%d", i), TextMessage("hi")); err != nil {
+				t.Errorf("Send: %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	got := api.received()
+	if len(got) != recipients {
+		t.Fatalf("got %d messages, want %d", len(got), recipients)
+	}
+	// The first request goes out at once, each later one waits its turn
+	want := time.Duration(recipients-1) * time.Second / perSecond
+	if span := got[len(got)-1].at.Sub(got[0].at); span < want-10*time.Millisecond {
+		t.Fatalf("%d messages went out within %s, want them spread over %s", recipients, span, want)
+	}
+}
+
+func TestSendSequenceSkipsFailedOptionalMessages(t *testing.T) {
+	svc, api := newQueueTest(t, 0, 0)
+
+	optional := TextMessage("fail-optional")
+	optional.Optional = true
+	err := svc.SendSequence("psid-1", []Message{TextMessage("first"), optional, TextMessage("last")})
+	if err != nil {
+		t.Fatalf("SendSequence: %v", err)
+	}
+
+	if got := strings.Join(api.texts("psid-1"), ","); got != "first,fail-optional,last" {
+		t.Fatalf("sent %q, want the sequence to go on after the optional message", got)
+	}
+}
+
+func TestSendSequenceStopsAtFailedRequiredMessage(t *testing.T) {
+	svc, api := newQueueTest(t, 0, 0)
+
+	err := svc.SendSequence("psid-1", []Message{TextMessage("first"), TextMessage("fail-required"), TextMessage("last")})
+	if err == nil {
+		t.Fatal("SendSequence succeeded, want the required message's error")
+	}
+
+	if got := strings.Join(api.texts("psid-1"), ","); got != "first,fail-required" {
+		t.Fatalf("sent %q, want nothing after the failed message", got)
+	}
+
+	// The recipient's queue still works for the next send
+	if err := svc.Send("psid-1", TextMessage("again")); err != nil {
+		t.Fatalf("Send after failure: %v", err)
+	}
+}
+
+func TestSendQueueRecreatesRecipientQueues(t *testing.T) {
+	svc, api := newQueueTest(t, 0, 0)
+
+	// Bursts of sends separated by idle moments, so recipient queues are removed
+	// when they drain and created again while other sends race them
+	const workers, rounds = 8, 25
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for r := 0; r < rounds; r++ {
+				psid := fmt.Sprintf("psid-%d", (w+r)%3)
+				if err := svc.Send(psid, TextMessage(fmt.Sprintf("w%d-r%d", w, r))); err != nil {
+					t.Errorf("Send: %v", err)
+				}
+				if r%5 == 0 {
+					time.Sleep(time.Millisecond)
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	if got := len(api.received()); got != workers*rounds {
+		t.Fatalf("got %d messages, want %d", got, workers*rounds)
+	}
+
+	svc.queue.mu.Lock()
+	defer svc.queue.mu.Unlock()
+	if n := len(svc.queue.recipients); n != 0 {
+		t.Fatalf("%d recipient queues left after every send finished, want 0", n)
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	long := strings.Repeat("word ", 500) // 2500 characters
+	tests := []struct {
+		name string
+		text string
+		max  int
+		want []string
+	}{
+		{"short", "hello", 10, []string{"hello"}},
+		{"exactly max", "0123456789", 10, []string{"0123456789"}},
+		{"paragraph break", "first para\n\nsecond", 15, []string{"first para", "second"}},
+		{"line break before space", "one two\nthree four", 12, []string{"one two", "three four"}},
+		{"word break", "alpha beta gamma", 12, []string{"alpha beta", "gamma"}},
+		{"no break", "abcdefghijkl", 5, []string{"abcde", "fghij", "kl"}},
+		{"break too early", "a bcdefghijkl", 6, []string{"a bcde", "fghijk", "l"}},
+		{"multibyte", "ñññññ ñññññ", 7, []string{"ñññññ", "ñññññ"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SplitText(tt.text, tt.max); strings.Join(got, "|") != strings.Join(tt.want, "|") {
+				t.Fatalf("SplitText(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+			}
+		})
+	}
+
+	parts := SplitText(long, MaxTextLength)
+	if len(parts) != 2 {
+		t.Fatalf("split %d characters into %d parts, want 2", len(long), len(parts))
+	}
+	for _, part := range parts {
+		if n := len([]rune(part)); n > MaxTextLength {
+			t.Fatalf("part of %d characters exceeds %d", n, MaxTextLength)
+		}
+	}
+}
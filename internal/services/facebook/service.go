@@ -1,12 +1,9 @@
 package facebook
 
 import (
-	"bytes"
 	"encoding/json"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
 	"net/url"
 	"strings"
 
@@ -15,51 +12,75 @@ import (
 
 type Service struct {
 	config config.FacebookConfig
+	client *client
+	queue  *sendQueue
 }
 
 func NewService(cfg config.FacebookConfig) *Service {
-	return &Service{
+	s := &Service{
 		config: cfg,
+		client: newClient(cfg),
 	}
+	s.queue = newSendQueue(func(payload map[string]any) error {
+		return s.client.post("/me/messages", payload, nil)
+	}, cfg.SendInterval, cfg.SendRatePerSecond)
+	return s
 }
 
-func (s *Service) SendTextMessage(recipientID, text string) error {
-	url := "https://graph.facebook.com/v18.0/me/messages"
+// SendSequence sends the messages to the recipient one after another. They are
+// queued behind anything already being sent to the recipient, so they arrive in
+// order, and sending stops at the first message that fails.
+func (s *Service) SendSequence(recipientID string, messages []Message) error {
+	return s.sendSequence(recipientID, messages, "")
+}
 
-	payload := map[string]interface{}{
-		"recipient": map[string]string{
-			"id": recipientID,
-		},
-		"message": map[string]string{
-			"text": text,
-		},
-	}
+// MessageTag lets a message be sent outside the 24-hour standard messaging
+// window for one of the purposes Messenger allows
+type MessageTag string
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("error marshaling message: %v", err)
+// AccountUpdate tags a notice about a non-recurring change to the user's account
+const AccountUpdate MessageTag = "ACCOUNT_UPDATE"
+
+// SendTagged sends a single message with a message tag, for notices that may
+// reach the user outside the standard messaging window. The message must fit
+// the tag's purpose.
+func (s *Service) SendTagged(recipientID string, tag MessageTag, msg Message) error {
+	return s.sendSequence(recipientID, []Message{msg}, tag)
+}
+
+func (s *Service) sendSequence(recipientID string, messages []Message, tag MessageTag) error {
+	requests := make([]outbound, 0, len(messages))
+	for _, msg := range messages {
+		payload := map[string]interface{}{
+			"recipient": map[string]string{
+				"id": recipientID,
+			},
+			"messaging_type": "RESPONSE",
+			"message":        msg,
+		}
+		if tag != "" {
+			payload["messaging_type"] = "MESSAGE_TAG"
+			payload["tag"] = tag
+		}
+		requests = append(requests, outbound{payload: payload, optional: msg.Optional})
 	}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
+	if err := s.queue.send(recipientID, requests); err != nil {
+		return fmt.Errorf("error sending message: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
-	q := req.URL.Query()
-	q.Add("access_token", s.config.PageAccessToken)
-	req.URL.RawQuery = q.Encode()
+	log.Printf("%d message(s) sent to %s", len(messages), recipientID)
+	return nil
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending message: %v", err)
-	}
-	defer resp.Body.Close()
+// Send sends a single message to the recipient
+func (s *Service) Send(recipientID string, msg Message) error {
+	return s.SendSequence(recipientID, []Message{msg})
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("facebook API error: %s - %s", resp.Status, string(body))
+func (s *Service) SendTextMessage(recipientID, text string) error {
+	if err := s.Send(recipientID, TextMessage(text)); err != nil {
+		return err
 	}
 
 	log.Printf("Message sent to %s: %s", recipientID, text)
@@ -73,36 +94,37 @@ func (s *Service) SendWithPayload(recipientID string, payload map[string]interfa
 		}
 	}
 
-	jsonData, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("error marshaling payload: %v", err)
+	if err := s.queue.send(recipientID, []outbound{{payload: payload}}); err != nil {
+		return fmt.Errorf("error sending payload: %w", err)
 	}
 
-	url := "https://graph.facebook.com/v18.0/me/messages"
+	log.Printf("Payload sent to %s", recipientID)
+	return nil
+}
 
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("error creating request: %v", err)
-	}
+// SenderAction is a typing indicator or read receipt shown to the recipient
+type SenderAction string
 
-	req.Header.Set("Content-Type", "application/json")
-	q := req.URL.Query()
-	q.Add("access_token", s.config.PageAccessToken)
-	req.URL.RawQuery = q.Encode()
+const (
+	TypingOn  SenderAction = "typing_on"
+	TypingOff SenderAction = "typing_off"
+	MarkSeen  SenderAction = "mark_seen"
+)
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return fmt.Errorf("error sending payload: %v", err)
+// SendSenderAction shows a typing indicator or marks the conversation seen. It is
+// queued behind messages already waiting for the recipient, so typing_off never
+// overtakes a reply, but is not held back by per-recipient pacing.
+func (s *Service) SendSenderAction(recipientID string, action SenderAction) error {
+	payload := map[string]any{
+		"recipient": map[string]string{
+			"id": recipientID,
+		},
+		"sender_action": action,
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("facebook API error: %s - %s", resp.Status, string(body))
+	if err := s.queue.send(recipientID, []outbound{{payload: payload, unpaced: true}}); err != nil {
+		return fmt.Errorf("error sending %s: %w", action, err)
 	}
-
-	log.Printf("Payload sent to %s", recipientID)
 	return nil
 }
 
@@ -114,116 +136,69 @@ type QuickReply struct {
 }
 
 func (s *Service) SendQuickReplies(recipientID, text string, quickReplies []QuickReply) error {
-	payload := map[string]interface{}{
-		"recipient": map[string]string{
-			"id": recipientID,
-		},
-		"messaging_type": "RESPONSE",
-		"message": map[string]interface{}{
-			"text":          text,
-			"quick_replies": quickReplies,
-		},
-	}
-
-	return s.SendWithPayload(recipientID, payload)
+	return s.Send(recipientID, QuickRepliesMessage(text, quickReplies))
 }
 
 func (s *Service) GetUserProfile(userID string) (*UserProfile, error) {
-	url := fmt.Sprintf("https://graph.facebook.com/v18.0/%s", userID)
-
-	fields := "id,name,first_name,last_name,email,picture.type(large)"
-
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
-	}
-
-	q := req.URL.Query()
-	q.Add("fields", fields)
-	q.Add("access_token", s.config.PageAccessToken)
-	req.URL.RawQuery = q.Encode()
-
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error making request: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("Facebook API error: %s", string(body))
-	}
+	query := url.Values{}
+	query.Set("fields", "id,name,first_name,last_name,email,locale,picture.type(large)")
 
 	var profile UserProfile
-	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
-		return nil, fmt.Errorf("error decoding response: %v", err)
+	if err := s.client.get("/"+userID, query, &profile); err != nil {
+		return nil, fmt.Errorf("error fetching user profile: %w", err)
 	}
 
 	return &profile, nil
 }
 
 func (s *Service) SetGetStartedButton(payload string) error {
-	url := fmt.Sprintf("https://graph.facebook.com/v18.0/me/messenger_profile?access_token=%s", s.config.PageAccessToken)
-
 	requestBody := map[string]interface{}{
 		"get_started": map[string]string{
 			"payload": payload,
 		},
 	}
 
-	jsonData, err := json.Marshal(requestBody)
-	if err != nil {
-		return fmt.Errorf("error marshaling get started button: %v", err)
+	if err := s.client.post("/me/messenger_profile", requestBody, nil); err != nil {
+		return fmt.Errorf("error setting get started button: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("error setting get started button: %v", err)
-	}
-	defer resp.Body.Close()
+	return nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Facebook API error: %s", string(body))
+// SendImage sends an image to the specified recipient using the image URL
+func (s *Service) SendImage(recipientID, imageURL string) error {
+	if err := ValidateImageURL(imageURL); err != nil {
+		return err
 	}
 
-	return nil
+	return s.Send(recipientID, ImageMessage(imageURL))
 }
 
-func (s *Service) SetGreetingText(text string) error {
-	url := fmt.Sprintf("https://graph.facebook.com/v18.0/me/messenger_profile?access_token=%s", s.config.PageAccessToken)
-
-	requestBody := map[string]interface{}{
-		"greeting": []map[string]string{
-			{
-				"locale": "default",
-				"text":   text,
-			},
+// UploadImage uploads a PNG image to Messenger and returns the attachment ID to
+// send it with. The attachment is not reusable, so upload it for every send.
+func (s *Service) UploadImage(filename string, png []byte) (string, error) {
+	message, err := json.Marshal(map[string]any{
+		"attachment": map[string]any{
+			"type":    "image",
+			"payload": map[string]any{"is_reusable": false},
 		},
-	}
-
-	jsonData, err := json.Marshal(requestBody)
+	})
 	if err != nil {
-		return fmt.Errorf("error marshaling greeting text: %v", err)
+		return "", fmt.Errorf("error marshaling attachment: %w", err)
 	}
 
-	resp, err := http.Post(url, "application/json", bytes.NewBuffer(jsonData))
-	if err != nil {
-		return fmt.Errorf("error setting greeting text: %v", err)
+	var resp struct {
+		AttachmentID string `json:"attachment_id"`
 	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("Facebook API error: %s", string(body))
+	file := formFile{field: "filedata", filename: filename, contentType: "image/png", data: png}
+	if err := s.client.postForm("/me/message_attachments", map[string]string{"message": string(message)}, file, &resp); err != nil {
+		return "", fmt.Errorf("error uploading image: %w", err)
 	}
-
-	return nil
+	return resp.AttachmentID, nil
 }
 
-// SendImage sends an image to the specified recipient using the image URL
-func (s *Service) SendImage(recipientID, imageURL string) error {
+// ValidateImageURL checks that an image URL can be sent as an attachment
+func ValidateImageURL(imageURL string) error {
 	if imageURL == "" {
 		return fmt.Errorf("image URL cannot be empty")
 	}
@@ -236,20 +211,5 @@ func (s *Service) SendImage(recipientID, imageURL string) error {
 		return fmt.Errorf("invalid image URL format: %v", err)
 	}
 
-	payload := map[string]interface{}{
-		"recipient": map[string]string{
-			"id": recipientID,
-		},
-		"message": map[string]interface{}{
-			"attachment": map[string]interface{}{
-				"type": "image",
-				"payload": map[string]string{
-					"url":         imageURL,
-					"is_reusable": "true",
-				},
-			},
-		},
-	}
-
-	return s.SendWithPayload(recipientID, payload)
+	return nil
 }
@@ -0,0 +1,101 @@
+package facebook
+
+import "fmt"
+
+// Generic template limits imposed by Messenger
+const (
+	MaxGenericElements = 10
+	MaxElementButtons  = 3
+	maxElementTitle    = 80
+	maxElementSubtitle = 80
+	maxButtonTitle     = 20
+)
+
+// GenericElement is one card of a generic template carousel
+type GenericElement struct {
+	Title         string     `json:"title"`
+	Subtitle      string     `json:"subtitle,omitempty"`
+	ImageURL      string     `json:"image_url,omitempty"`
+	DefaultAction *URLAction `json:"default_action,omitempty"`
+	Buttons       []Button   `json:"buttons,omitempty"`
+}
+
+// URLAction opens a URL when the card itself is tapped
+type URLAction struct {
+	Type               string `json:"type"`
+	URL                string `json:"url"`
+	WebviewHeightRatio string `json:"webview_height_ratio,omitempty"`
+}
+
+// Button is a URL or postback button on a template
+type Button struct {
+	Type    string `json:"type"`
+	Title   string `json:"title"`
+	URL     string `json:"url,omitempty"`
+	Payload string `json:"payload,omitempty"`
+}
+
+// NewURLAction builds a default action that opens url in a full-height webview
+func NewURLAction(url string) *URLAction {
+	return &URLAction{Type: "web_url", URL: url, WebviewHeightRatio: "full"}
+}
+
+// URLButton builds a button that opens url
+func URLButton(title, url string) Button {
+	return Button{Type: "web_url", Title: truncate(title, maxButtonTitle), URL: url}
+}
+
+// PostbackButton builds a button that sends payload back to the webhook
+func PostbackButton(title, payload string) Button {
+	return Button{Type: "postback", Title: truncate(title, maxButtonTitle), Payload: payload}
+}
+
+// GenericTemplateMessage builds a carousel message from the elements, trimming
+// titles, subtitles and buttons to Messenger's limits
+func GenericTemplateMessage(elements []GenericElement) (Message, error) {
+	if len(elements) == 0 {
+		return Message{}, fmt.Errorf("generic template needs at least one element")
+	}
+	if len(elements) > MaxGenericElements {
+		return Message{}, fmt.Errorf("generic template supports at most %d elements, got %d", MaxGenericElements, len(elements))
+	}
+
+	cards := make([]GenericElement, len(elements))
+	for i, el := range elements {
+		el.Title = truncate(el.Title, maxElementTitle)
+		el.Subtitle = truncate(el.Subtitle, maxElementSubtitle)
+		if len(el.Buttons) > MaxElementButtons {
+			el.Buttons = el.Buttons[:MaxElementButtons]
+		}
+		cards[i] = el
+	}
+
+	return Message{
+		Attachment: &MessageAttachment{
+			Type: "template",
+			Payload: map[string]any{
+				"template_type":      "generic",
+				"image_aspect_ratio": "horizontal",
+				"elements":           cards,
+			},
+		},
+	}, nil
+}
+
+// SendGenericTemplate sends the elements as a single carousel
+func (s *Service) SendGenericTemplate(recipientID string, elements []GenericElement) error {
+	msg, err := GenericTemplateMessage(elements)
+	if err != nil {
+		return err
+	}
+	return s.Send(recipientID, msg)
+}
+
+// truncate shortens s to at most max characters, marking the cut with an ellipsis
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max-1]) + "…"
+}
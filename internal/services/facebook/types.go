@@ -1,11 +1,14 @@
 package facebook
 
+import "fmt"
+
 type UserProfile struct {
 	ID        string `json:"id"`
 	FirstName string `json:"first_name,omitempty"`
 	LastName  string `json:"last_name,omitempty"`
 	Name      string `json:"name,omitempty"`
 	Email     string `json:"email,omitempty"`
+	Locale    string `json:"locale,omitempty"`
 	Picture   struct {
 		Data struct {
 			URL string `json:"url"`
@@ -14,18 +17,117 @@ type UserProfile struct {
 }
 
 type WebhookRequest struct {
-	Object string `json:"object"`
-	Entry  []struct {
-		Messaging []struct {
-			Sender struct {
-				ID string `json:"id"`
-			} `json:"sender"`
-			Message *struct {
-				Text string `json:"text"`
-			} `json:"message,omitempty"`
-			Postback *struct {
-				Payload string `json:"payload"`
-			} `json:"postback,omitempty"`
-		} `json:"messaging"`
-	} `json:"entry"`
+	Object string  `json:"object"`
+	Entry  []Entry `json:"entry"`
+}
+
+// Entry is a batch of events for a single page
+type Entry struct {
+	ID        string           `json:"id"`
+	Time      int64            `json:"time"`
+	Messaging []MessagingEvent `json:"messaging"`
+}
+
+// Participant identifies the sender or recipient of an event
+type Participant struct {
+	ID      string `json:"id,omitempty"`
+	UserRef string `json:"user_ref,omitempty"`
+}
+
+// MessagingEvent is a single event exchanged between a user and the page.
+// Exactly one of the pointer fields is set, depending on the event type.
+type MessagingEvent struct {
+	Sender    Participant      `json:"sender"`
+	Recipient Participant      `json:"recipient"`
+	Timestamp int64            `json:"timestamp"`
+	Message   *IncomingMessage `json:"message,omitempty"`
+	Postback  *Postback        `json:"postback,omitempty"`
+	Delivery  *Delivery        `json:"delivery,omitempty"`
+	Read      *Read            `json:"read,omitempty"`
+	Referral  *Referral        `json:"referral,omitempty"`
+	Optin     *Optin           `json:"optin,omitempty"`
+}
+
+// DedupKey identifies the event across redeliveries. Messages are keyed on their
+// mid; other events on their type, sender and timestamp. Receipts return an empty
+// key because handling them twice is harmless.
+func (e MessagingEvent) DedupKey() string {
+	switch {
+	case e.Message != nil && e.Message.Mid != "":
+		return "mid:" + e.Message.Mid
+	case e.Postback != nil:
+		return fmt.Sprintf("postback:%s:%d", e.Sender.ID, e.Timestamp)
+	case e.Referral != nil:
+		return fmt.Sprintf("referral:%s:%d", e.Sender.ID, e.Timestamp)
+	case e.Optin != nil:
+		return fmt.Sprintf("optin:%s:%d", e.Sender.ID, e.Timestamp)
+	}
+	return ""
+}
+
+// IncomingMessage is a message sent to the page, or an echo of one the page sent
+type IncomingMessage struct {
+	Mid         string               `json:"mid"`
+	Text        string               `json:"text,omitempty"`
+	IsEcho      bool                 `json:"is_echo,omitempty"`
+	AppID       int64                `json:"app_id,omitempty"`
+	Metadata    string               `json:"metadata,omitempty"`
+	QuickReply  *IncomingQuickReply  `json:"quick_reply,omitempty"`
+	Attachments []IncomingAttachment `json:"attachments,omitempty"`
+}
+
+// IncomingQuickReply carries the payload of the quick reply the user tapped
+type IncomingQuickReply struct {
+	Payload string `json:"payload"`
+}
+
+// IncomingAttachment is a file, location or sticker sent by the user
+type IncomingAttachment struct {
+	Type    string `json:"type"`
+	Payload struct {
+		URL         string `json:"url,omitempty"`
+		Title       string `json:"title,omitempty"`
+		StickerID   int64  `json:"sticker_id,omitempty"`
+		Coordinates *struct {
+			Lat  float64 `json:"lat"`
+			Long float64 `json:"long"`
+		} `json:"coordinates,omitempty"`
+	} `json:"payload"`
+}
+
+// Postback is sent when the user taps a postback button, Get Started or a persistent menu item
+type Postback struct {
+	Mid      string    `json:"mid,omitempty"`
+	Title    string    `json:"title,omitempty"`
+	Payload  string    `json:"payload"`
+	Referral *Referral `json:"referral,omitempty"`
+}
+
+// Delivery confirms that messages sent by the page were delivered
+type Delivery struct {
+	Mids      []string `json:"mids,omitempty"`
+	Watermark int64    `json:"watermark"`
+}
+
+// Read confirms that the user read every message sent before the watermark
+type Read struct {
+	Watermark int64 `json:"watermark"`
+}
+
+// Referral is sent when a user enters the conversation through an m.me link, ad or plugin
+type Referral struct {
+	Ref        string `json:"ref,omitempty"`
+	Source     string `json:"source,omitempty"`
+	Type       string `json:"type,omitempty"`
+	AdID       string `json:"ad_id,omitempty"`
+	RefererURI string `json:"referer_uri,omitempty"`
+}
+
+// Optin is sent when a user opts in through a plugin or a notification request
+type Optin struct {
+	Ref                       string `json:"ref,omitempty"`
+	UserRef                   string `json:"user_ref,omitempty"`
+	Type                      string `json:"type,omitempty"`
+	Payload                   string `json:"payload,omitempty"`
+	NotificationMessagesToken string `json:"notification_messages_token,omitempty"`
 }
@@ -0,0 +1,54 @@
+package facebook
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func event(t *testing.T, raw string) MessagingEvent {
+	t.Helper()
+	var e MessagingEvent
+	if err := json.Unmarshal([]byte(raw), &e); err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func TestDedupKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		event string
+		want  string
+	}{
+		{"message", `{"sender":{"id":"u1"},"timestamp":1,"message":{"mid":"m_1","text":"hi"}}`, "mid:m_1"},
+		{"quick reply", `{"sender":{"id":"u1"},"timestamp":1,"message":{"mid":"m_2","text":"Menu","quick_reply":{"payload":"MENU"}}}`, "mid:m_2"},
+		{"postback", `{"sender":{"id":"u1"},"timestamp":1700000000000,"postback":{"payload":"MENU"}}`, "postback:u1:1700000000000"},
+		{"referral", `{"sender":{"id":"u1"},"timestamp":5,"referral":{"ref":"abc"}}`, "referral:u1:5"},
+		{"read receipt", `{"sender":{"id":"u1"},"timestamp":5,"read":{"watermark":5}}`, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := event(t, tt.event).DedupKey(); got != tt.want {
+				t.Fatalf("DedupKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDedupKeyIdentifiesRedeliveries(t *testing.T) {
+	// A redelivered message keeps its mid even when the batch is re-timestamped
+	first := event(t, `{"sender":{"id":"u1"},"timestamp":1,"message":{"mid":"m_1","text":"hi"}}`)
+	again := event(t, `{"sender":{"id":"u1"},"timestamp":2,"message":{"mid":"m_1","text":"hi"}}`)
+	if first.DedupKey() != again.DedupKey() {
+		t.Fatal("a redelivered message got a different key")
+	}
+
+	// Postbacks have no mid, so the same tap by another user or at another time
+	// is a different event
+	tap := event(t, `{"sender":{"id":"u1"},"timestamp":10,"postback":{"payload":"MENU"}}`)
+	otherUser := event(t, `{"sender":{"id":"u2"},"timestamp":10,"postback":{"payload":"MENU"}}`)
+	later := event(t, `{"sender":{"id":"u1"},"timestamp":11,"postback":{"payload":"MENU"}}`)
+	if tap.DedupKey() == otherUser.DedupKey() || tap.DedupKey() == later.DedupKey() {
+		t.Fatal("distinct postbacks share a key")
+	}
+}
@@ -0,0 +1,261 @@
+package helpers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"school-assistant-wh/internal/i18n"
+)
+
+// Quick reply and postback payloads. Handlers route on these values rather than
+// on the button titles, so titles can be reworded without breaking navigation.
+const (
+	PayloadGetStarted            = "GET_STARTED"
+	PayloadRegister              = "REGISTER"
+	PayloadMenu                  = "MENU"
+	PayloadViewProfile           = "VIEW_PROFILE"
+	PayloadSwitchProfile         = "SWITCH_PROFILE"
+	PayloadMySaID                = "MY_SA_ID"
+	PayloadAboutUs               = "ABOUT_US"
+	PayloadTalkToHuman           = "TALK_TO_HUMAN"
+	PayloadContinue              = "CONTINUE"
+	PayloadNo                    = "NO"
+	PayloadBack                  = "BACK"
+	PayloadHome                  = "HOME"
+	PayloadHelp                  = "HELP"
+	PayloadCancel                = "CANCEL"
+	PayloadViewMore              = "VIEW_MORE"
+	PayloadPaymentLogs           = "PAYMENT_LOGS"
+	PayloadProceed               = "PROCEED"
+	PayloadViewTickets           = "VIEW_TICKETS"
+	PayloadLanguage              = "LANGUAGE"
+	PayloadLinkStudent           = "LINK_STUDENT"
+	PayloadResendCode            = "RESEND_CODE"
+	PayloadUnlinkProfile         = "UNLINK_PROFILE"
+	PayloadConfirmUnlink         = "CONFIRM_UNLINK"
+	PayloadRoleStudent           = "ROLE_STUDENT"
+	PayloadRoleGuardian          = "ROLE_GUARDIAN"
+	PayloadRegenerateSaID        = "REGENERATE_SA_ID"
+	PayloadConfirmRegenerateSaID = "CONFIRM_REGENERATE_SA_ID"
+	PayloadMyData                = "MY_DATA"
+	PayloadExportData            = "EXPORT_DATA"
+	PayloadDeleteAccount         = "DELETE_ACCOUNT"
+	PayloadConfirmDeleteAccount  = "CONFIRM_DELETE_ACCOUNT"
+	PayloadDeleteForever         = "DELETE_FOREVER"
+
+	// Persistent menu and ice breaker shortcuts into the main menu options
+	PayloadViewGrades     = "VIEW_GRADES"
+	PayloadViewFees       = "VIEW_FEES"
+	PayloadViewBulletin   = "VIEW_BULLETIN"
+	PayloadViewAttendance = "VIEW_ATTENDANCE"
+	PayloadSupport        = "SUPPORT"
+
+	// PayloadBulletinPrefix starts the "Read more" payload, followed by the bulletin ID
+	PayloadBulletinPrefix = "BULLETIN:"
+	// PayloadLanguagePrefix starts a language picker payload, followed by the language code
+	PayloadLanguagePrefix = "LANGUAGE:"
+	// PayloadMakePrimaryPrefix starts the payload offering to make a newly linked
+	// profile the active one, followed by the link ID
+	PayloadMakePrimaryPrefix = "MAKE_PRIMARY:"
+)
+
+// textCommands maps typed button labels to their payloads, so typing "My SA-ID"
+// behaves the same as tapping the quick reply
+var textCommands = map[string]string{
+	"REGISTER":          PayloadRegister,
+	"MENU":              PayloadMenu,
+	"MAIN MENU":         PayloadMenu,
+	"BACK TO MENU":      PayloadMenu,
+	"VIEW PROFILE":      PayloadViewProfile,
+	"VIEW ALL PROFILES": PayloadViewProfile,
+	"SWITCH PROFILE":    PayloadSwitchProfile,
+	"MY SA-ID":          PayloadMySaID,
+	"ABOUT US":          PayloadAboutUs,
+	"TALK TO HUMAN":     PayloadTalkToHuman,
+	"CONTINUE":          PayloadContinue,
+	"NO":                PayloadNo,
+	"BACK":              PayloadBack,
+	"HOME":              PayloadHome,
+	"HELP":              PayloadHelp,
+	"?":                 PayloadHelp,
+	"CANCEL":            PayloadCancel,
+	"RESET":             PayloadCancel,
+	"START OVER":        PayloadCancel,
+	"VIEW MORE":         PayloadViewMore,
+	"PAYMENT LOGS":      PayloadPaymentLogs,
+	"PROCEED":           PayloadProceed,
+	"VIEW TICKETS":      PayloadViewTickets,
+	"LANGUAGE":          PayloadLanguage,
+	"LANG":              PayloadLanguage,
+	"WIKA":              PayloadLanguage,
+	"PINULONGAN":        PayloadLanguage,
+	"LINK STUDENT":      PayloadLinkStudent,
+	"LINK A STUDENT":    PayloadLinkStudent,
+	"RESEND CODE":       PayloadResendCode,
+	"UNLINK PROFILE":    PayloadUnlinkProfile,
+	"REGENERATE SA-ID":  PayloadRegenerateSaID,
+	"MY DATA":           PayloadMyData,
+}
+
+// buttonPayloads lists the catalog IDs of button titles with their payloads, in
+// the order their translations are added to textCommands
+var buttonPayloads = []struct {
+	id      string
+	payload string
+}{
+	{"button.register", PayloadRegister},
+	{"button.menu", PayloadMenu},
+	{"button.back_to_menu", PayloadMenu},
+	{"button.view_profile", PayloadViewProfile},
+	{"button.view_all_profiles", PayloadViewProfile},
+	{"button.switch_profile", PayloadSwitchProfile},
+	{"button.my_sa_id", PayloadMySaID},
+	{"button.about_us", PayloadAboutUs},
+	{"button.talk_to_human", PayloadTalkToHuman},
+	{"button.continue", PayloadContinue},
+	{"button.no", PayloadNo},
+	{"button.back", PayloadBack},
+	{"button.home", PayloadHome},
+	{"button.view_more", PayloadViewMore},
+	{"button.payment_logs", PayloadPaymentLogs},
+	{"button.proceed", PayloadProceed},
+	{"button.view_tickets", PayloadViewTickets},
+	{"button.language", PayloadLanguage},
+	{"button.link_student", PayloadLinkStudent},
+	{"button.resend_code", PayloadResendCode},
+	{"button.unlink_profile", PayloadUnlinkProfile},
+	{"button.confirm_unlink", PayloadConfirmUnlink},
+	{"button.role_student", PayloadRoleStudent},
+	{"button.role_guardian", PayloadRoleGuardian},
+	{"button.not_now", PayloadNo},
+	{"button.regenerate_sa_id", PayloadRegenerateSaID},
+	{"button.confirm_regenerate_sa_id", PayloadConfirmRegenerateSaID},
+	{"button.my_data", PayloadMyData},
+	{"button.export_data", PayloadExportData},
+	{"button.delete_account", PayloadDeleteAccount},
+	{"button.confirm_delete_account", PayloadConfirmDeleteAccount},
+	{"button.delete_forever", PayloadDeleteForever},
+}
+
+// Typing a translated button title or a language's name works like tapping the
+// button. English labels above take precedence over translations.
+func init() {
+	for _, lang := range i18n.Supported {
+		for _, b := range buttonPayloads {
+			label := strings.ToUpper(strings.TrimSpace(strings.TrimPrefix(i18n.T(lang, b.id), "🌐")))
+			if _, exists := textCommands[label]; !exists {
+				textCommands[label] = b.payload
+			}
+		}
+		name := strings.ToUpper(lang.Name())
+		if _, exists := textCommands[name]; !exists {
+			textCommands[name] = LanguagePayload(lang)
+		}
+	}
+}
+
+// payloadAliases maps payloads still carried by older buttons to their current value
+var payloadAliases = map[string]string{
+	"MAIN_MENU":     PayloadMenu,
+	"TALK TO HUMAN": PayloadTalkToHuman,
+	"VIEW MORE":     PayloadViewMore,
+}
+
+// globalCommands are handled the same way regardless of the conversation state
+var globalCommands = map[string]bool{
+	PayloadMenu:           true,
+	PayloadHome:           true,
+	PayloadHelp:           true,
+	PayloadCancel:         true,
+	PayloadSwitchProfile:  true,
+	PayloadMySaID:         true,
+	PayloadRegenerateSaID: true,
+	PayloadMyData:         true,
+	PayloadAboutUs:        true,
+	PayloadTalkToHuman:    true,
+	PayloadRegister:       true,
+	PayloadViewProfile:    true,
+	PayloadContinue:       true,
+	PayloadNo:             true,
+	PayloadLanguage:       true,
+	PayloadLinkStudent:    true,
+
+	PayloadViewGrades:     true,
+	PayloadViewFees:       true,
+	PayloadViewBulletin:   true,
+	PayloadViewAttendance: true,
+	PayloadSupport:        true,
+}
+
+// CommandFromPayload normalizes a quick reply or postback payload into a command
+func CommandFromPayload(payload string) string {
+	command := strings.ToUpper(strings.TrimSpace(payload))
+	if alias, ok := payloadAliases[command]; ok {
+		return alias
+	}
+	return command
+}
+
+// CommandFromText normalizes typed text into a command. Text matching a button
+// label becomes that button's payload; anything else (e.g. a menu number) is
+// returned upper-cased.
+func CommandFromText(text string) string {
+	command := strings.ToUpper(strings.TrimSpace(text))
+	if payload, ok := textCommands[command]; ok {
+		return payload
+	}
+	return command
+}
+
+// IsGlobalCommand checks if the command bypasses the current conversation state
+func IsGlobalCommand(command string) bool {
+	return globalCommands[command]
+}
+
+// BulletinPayload builds the "Read more" payload for a bulletin
+func BulletinPayload(bulletinID int) string {
+	return fmt.Sprintf("%s%d", PayloadBulletinPrefix, bulletinID)
+}
+
+// ParseBulletinPayload extracts the bulletin ID from a "Read more" payload
+func ParseBulletinPayload(command string) (int, bool) {
+	if !strings.HasPrefix(command, PayloadBulletinPrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(command, PayloadBulletinPrefix))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// MakePrimaryPayload builds the payload making the link the active profile
+func MakePrimaryPayload(linkID int) string {
+	return fmt.Sprintf("%s%d", PayloadMakePrimaryPrefix, linkID)
+}
+
+// ParseMakePrimaryPayload extracts the link ID from a make primary payload
+func ParseMakePrimaryPayload(command string) (int, bool) {
+	if !strings.HasPrefix(command, PayloadMakePrimaryPrefix) {
+		return 0, false
+	}
+	id, err := strconv.Atoi(strings.TrimPrefix(command, PayloadMakePrimaryPrefix))
+	if err != nil {
+		return 0, false
+	}
+	return id, true
+}
+
+// LanguagePayload builds the language picker payload for lang
+func LanguagePayload(lang i18n.Lang) string {
+	return PayloadLanguagePrefix + strings.ToUpper(string(lang))
+}
+
+// ParseLanguagePayload extracts the language from a language picker payload
+func ParseLanguagePayload(command string) (i18n.Lang, bool) {
+	if !strings.HasPrefix(command, PayloadLanguagePrefix) {
+		return "", false
+	}
+	return i18n.Parse(strings.TrimPrefix(command, PayloadLanguagePrefix))
+}
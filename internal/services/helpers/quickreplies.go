@@ -2,248 +2,223 @@ package helpers
 
 import (
 	"school-assistant-wh/internal/constants"
+	"school-assistant-wh/internal/i18n"
 	"school-assistant-wh/internal/services/facebook"
-	"strings"
 )
 
-// validQuickReplyPayloads is a map of all valid quick reply payloads
-var validQuickReplyPayloads = map[string]bool{
-	"MENU":           true,
-	"SWITCH PROFILE": true,
-	"MY SA-ID":       true,
-	"ABOUT US":       true,
-	"TALK TO HUMAN":  true,
-	"REGISTER":       true,
-	"VIEW PROFILE":   true,
-	"MAIN MENU":      true,
-	"CONTINUE":       true,
-	"NO":             true,
-}
-
-// IsQuickReplyPayload checks if the given message is a valid quick reply payload
-func IsQuickReplyPayload(message string) bool {
-	_, exists := validQuickReplyPayloads[strings.ToUpper(message)]
-	return exists
+// quickReply builds a text quick reply titled with the catalog message titleID
+func quickReply(lang i18n.Lang, titleID, payload string) facebook.QuickReply {
+	return facebook.QuickReply{
+		ContentType: "text",
+		Title:       i18n.T(lang, titleID),
+		Payload:     payload,
+	}
 }
 
 // GetQuickReplies returns the appropriate quick replies based on user status
-func GetQuickReplies(status string) []facebook.QuickReply {
+func GetQuickReplies(lang i18n.Lang, status string) []facebook.QuickReply {
 	switch status {
 	case constants.UserStatusRegistered:
 		return []facebook.QuickReply{
-			{
-				ContentType: "text",
-				Title:       "View Profile",
-				Payload:     "VIEW_PROFILE",
-			},
-			{
-				ContentType: "text",
-				Title:       "My SA-ID",
-				Payload:     "MY_SA_ID",
-			},
-			{
-				ContentType: "text",
-				Title:       "About Us",
-				Payload:     "ABOUT_US",
-			},
-			{
-				ContentType: "text",
-				Title:       "Talk to Human",
-				Payload:     "TALK_TO_HUMAN",
-			},
+			quickReply(lang, "button.link_student", PayloadLinkStudent),
+			quickReply(lang, "button.view_profile", PayloadViewProfile),
+			quickReply(lang, "button.my_sa_id", PayloadMySaID),
+			quickReply(lang, "button.about_us", PayloadAboutUs),
+			quickReply(lang, "button.talk_to_human", PayloadTalkToHuman),
+			quickReply(lang, "button.language", PayloadLanguage),
+			quickReply(lang, "button.my_data", PayloadMyData),
 		}
 	case constants.UserStatusLinkedPrimary:
 		return []facebook.QuickReply{
-			{
-				ContentType: "text",
-				Title:       "Menu",
-				Payload:     "MENU",
-			},
-			{
-				ContentType: "text",
-				Title:       "View Profile",
-				Payload:     "VIEW_PROFILE",
-			},
-			{
-				ContentType: "text",
-				Title:       "Switch Profile",
-				Payload:     "SWITCH_PROFILE",
-			},
-			{
-				ContentType: "text",
-				Title:       "My SA-ID",
-				Payload:     "MY_SA_ID",
-			},
+			quickReply(lang, "button.menu", PayloadMenu),
+			quickReply(lang, "button.view_profile", PayloadViewProfile),
+			quickReply(lang, "button.switch_profile", PayloadSwitchProfile),
+			quickReply(lang, "button.my_sa_id", PayloadMySaID),
+			quickReply(lang, "button.language", PayloadLanguage),
+			quickReply(lang, "button.my_data", PayloadMyData),
 		}
 	default: // Unregistered
 		return []facebook.QuickReply{
-			{
-				ContentType: "text",
-				Title:       "Register",
-				Payload:     "REGISTER",
-			},
-			{
-				ContentType: "text",
-				Title:       "About Us",
-				Payload:     "ABOUT_US",
-			},
-			{
-				ContentType: "text",
-				Title:       "Talk to Human",
-				Payload:     "TALK_TO_HUMAN",
-			},
+			quickReply(lang, "button.register", PayloadRegister),
+			quickReply(lang, "button.about_us", PayloadAboutUs),
+			quickReply(lang, "button.talk_to_human", PayloadTalkToHuman),
 		}
 	}
 }
 
 // GetAccountManagementReplies returns quick replies for account management
-func GetAccountManagementReplies() []facebook.QuickReply {
+func GetAccountManagementReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Switch Profile",
-			Payload:     "SWITCH_PROFILE",
-		},
-		{
-			ContentType: "text",
-			Title:       "View All Profiles",
-			Payload:     "VIEW_PROFILE",
-		},
-		{
-			ContentType: "text",
-			Title:       "Back to Menu",
-			Payload:     "MAIN_MENU",
-		},
+		quickReply(lang, "button.switch_profile", PayloadSwitchProfile),
+		quickReply(lang, "button.view_all_profiles", PayloadViewProfile),
+		quickReply(lang, "button.link_student", PayloadLinkStudent),
+		quickReply(lang, "button.back_to_menu", PayloadMenu),
 	}
 }
 
 // GetMainMenuReplies returns the main menu quick replies
-func GetMainMenuReplies() []facebook.QuickReply {
+func GetMainMenuReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Switch Profile",
-			Payload:     "SWITCH_PROFILE",
-		},
-		{
-			ContentType: "text",
-			Title:       "My SA-ID",
-			Payload:     "MY_SA_ID",
-		},
-		{
-			ContentType: "text",
-			Title:       "Talk to Human",
-			Payload:     "TALK TO HUMAN",
-		},
+		quickReply(lang, "button.switch_profile", PayloadSwitchProfile),
+		quickReply(lang, "button.my_sa_id", PayloadMySaID),
+		quickReply(lang, "button.talk_to_human", PayloadTalkToHuman),
+		quickReply(lang, "button.language", PayloadLanguage),
 	}
 }
 
 // GetProfileConfirmationReplies returns quick replies for profile confirmation
-func GetProfileConfirmationReplies() []facebook.QuickReply {
+func GetProfileConfirmationReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Continue",
-			Payload:     "CONTINUE",
-		},
-		{
-			ContentType: "text",
-			Title:       "No",
-			Payload:     "NO",
-		},
+		quickReply(lang, "button.continue", PayloadContinue),
+		quickReply(lang, "button.no", PayloadNo),
 	}
 }
 
 // GetProfileManagementReplies returns quick replies for profile management
-func GetProfileManagementReplies() []facebook.QuickReply {
+func GetProfileManagementReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Continue",
-			Payload:     "CONTINUE",
-		},
-		{
-			ContentType: "text",
-			Title:       "Switch Profile",
-			Payload:     "SWITCH PROFILE",
-		},
+		quickReply(lang, "button.continue", PayloadContinue),
+		quickReply(lang, "button.switch_profile", PayloadSwitchProfile),
 	}
 }
 
-// GetBackToMainMenuReplies returns quick replies for going back to main menu
-func GetBack() []facebook.QuickReply {
+// GetBack returns quick replies for going back to the previous screen or home
+func GetBack(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Back",
-			Payload:     "BACK",
-		},
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.home", PayloadHome),
 	}
 }
 
 // GetPaymentReplies returns quick replies for payment-related actions
-func GetPaymentReplies() []facebook.QuickReply {
+func GetPaymentReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Back",
-			Payload:     "BACK",
-		},
+		quickReply(lang, "button.back", PayloadBack),
 		// {
 		// 	ContentType: "text",
 		// 	Title:       "Pay Now",
 		// 	Payload:     "PAY_NOW",
 		// },
-		{
-			ContentType: "text",
-			Title:       "Payment Logs",
-			Payload:     "PAYMENT_LOGS",
-		},
+		quickReply(lang, "button.payment_logs", PayloadPaymentLogs),
 	}
 }
 
 // GetViewMoreReplies returns quick replies for viewing more items with pagination
-func GetViewMoreReplies() []facebook.QuickReply {
+func GetViewMoreReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Back",
-			Payload:     "MENU",
-		},
-		{
-			ContentType: "text",
-			Title:       "View More",
-			Payload:     "VIEW MORE",
-		},
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.view_more", PayloadViewMore),
 	}
 }
 
-func GetConfirmProfileSwitch() []facebook.QuickReply {
+func GetConfirmProfileSwitch(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Back",
-			Payload:     "BACK",
-		},
-		{
-			ContentType: "text",
-			Title:       "Proceed",
-			Payload:     "PROCEED",
-		},
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.proceed", PayloadProceed),
 	}
 }
 
-func GetAskSupportReplies() []facebook.QuickReply {
+func GetAskSupportReplies(lang i18n.Lang) []facebook.QuickReply {
 	return []facebook.QuickReply{
-		{
-			ContentType: "text",
-			Title:       "Back",
-			Payload:     "BACK",
-		},
-		{
-			ContentType: "text",
-			Title:       "View Tickets",
-			Payload:     "VIEW_TICKETS",
-		},
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.view_tickets", PayloadViewTickets),
+	}
+}
+
+// GetConfirmUnlinkReplies returns quick replies for confirming a profile unlink
+func GetConfirmUnlinkReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.confirm_unlink", PayloadConfirmUnlink),
+	}
+}
+
+// GetSaIDReplies returns quick replies shown with the user's SA-ID
+func GetSaIDReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.menu", PayloadMenu),
+		quickReply(lang, "button.regenerate_sa_id", PayloadRegenerateSaID),
+	}
+}
+
+// GetConfirmRegenerateSaIDReplies returns quick replies for confirming a new SA-ID
+func GetConfirmRegenerateSaIDReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.confirm_regenerate_sa_id", PayloadConfirmRegenerateSaID),
+	}
+}
+
+// GetMyDataReplies returns quick replies for the My Data menu
+func GetMyDataReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.export_data", PayloadExportData),
+		quickReply(lang, "button.delete_account", PayloadDeleteAccount),
+		quickReply(lang, "button.back", PayloadBack),
+	}
+}
+
+// GetConfirmDeleteAccountReplies returns quick replies for the first account
+// deletion confirmation
+func GetConfirmDeleteAccountReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.confirm_delete_account", PayloadConfirmDeleteAccount),
+	}
+}
+
+// GetDeleteForeverReplies returns quick replies for the final account deletion
+// confirmation
+func GetDeleteForeverReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.delete_forever", PayloadDeleteForever),
+	}
+}
+
+// GetLinkRoleReplies returns quick replies for saying who the user is to the
+// student being linked
+func GetLinkRoleReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.role_student", PayloadRoleStudent),
+		quickReply(lang, "button.role_guardian", PayloadRoleGuardian),
+	}
+}
+
+// GetMakePrimaryReplies returns quick replies offering to make a newly linked
+// profile the active one
+func GetMakePrimaryReplies(lang i18n.Lang, linkID int) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.make_primary", MakePrimaryPayload(linkID)),
+		quickReply(lang, "button.not_now", PayloadNo),
+	}
+}
+
+// GetMenuReplies returns a quick reply opening the main menu
+func GetMenuReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.menu", PayloadMenu),
+	}
+}
+
+// GetResendCodeReplies returns quick replies for the one-time code prompt
+func GetResendCodeReplies(lang i18n.Lang) []facebook.QuickReply {
+	return []facebook.QuickReply{
+		quickReply(lang, "button.back", PayloadBack),
+		quickReply(lang, "button.resend_code", PayloadResendCode),
+	}
+}
+
+// GetLanguageReplies offers every supported language, each titled in itself
+func GetLanguageReplies() []facebook.QuickReply {
+	replies := make([]facebook.QuickReply, 0, len(i18n.Supported))
+	for _, lang := range i18n.Supported {
+		replies = append(replies, facebook.QuickReply{
+			ContentType: "text",
+			Title:       lang.Name(),
+			Payload:     LanguagePayload(lang),
+		})
 	}
+	return replies
 }
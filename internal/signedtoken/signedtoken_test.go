@@ -0,0 +1,94 @@
+package signedtoken
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueVerifyRoundTrip(t *testing.T) {
+	s := NewSigner("link", "secret")
+	now := time.Unix(1_700_000_000, 0)
+	want := Token{Fields: []string{"demo_school", "2024-001"}, ExpiresAt: now.Add(time.Hour)}
+
+	token, err := s.Issue(want.ExpiresAt, want.Fields...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := s.Verify(token, now)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("token = %+v, want %+v", got, want)
+	}
+}
+
+func TestVerifyRejectsBadTokens(t *testing.T) {
+	s := NewSigner("link", "secret")
+	now := time.Unix(1_700_000_000, 0)
+	expiry := now.Add(time.Hour)
+	token := mustIssue(t, s, expiry, "42")
+	_, sig, _ := strings.Cut(token, ".")
+
+	tests := []struct {
+		name     string
+		verifier *Signer
+		token    string
+		want     error
+	}{
+		{"other fields", s, encode([]byte("43|1700003600")) + "." + sig, ErrBadSignature},
+		{"other secret", s, mustIssue(t, NewSigner("link", "other"), expiry, "42"), ErrBadSignature},
+		{"other purpose", s, mustIssue(t, NewSigner("export", "secret"), expiry, "42"), ErrBadSignature},
+		{"no signature", s, strings.Split(token, ".")[0], ErrMalformed},
+		{"not base64", s, "!!!." + sig, ErrMalformed},
+		{"no secret", NewSigner("link", ""), token, ErrBadSignature},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := tt.verifier.Verify(tt.token, now); !errors.Is(err, tt.want) {
+				t.Fatalf("err = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyExpiredToken(t *testing.T) {
+	s := NewSigner("export", "secret")
+	now := time.Unix(1_700_000_000, 0)
+	token := mustIssue(t, s, now.Add(time.Hour), "42")
+
+	got, err := s.Verify(token, now.Add(time.Hour))
+	if !errors.Is(err, ErrExpired) {
+		t.Fatalf("at expiry: err = %v, want %v", err, ErrExpired)
+	}
+	if len(got.Fields) != 1 || got.Fields[0] != "42" {
+		t.Fatalf("expired token fields = %q, want [42]", got.Fields)
+	}
+	if _, err := s.Verify(token, now.Add(time.Hour-time.Second)); err != nil {
+		t.Fatalf("before expiry: %v", err)
+	}
+}
+
+func TestIssueRejectsBadInput(t *testing.T) {
+	expiry := time.Now().Add(time.Hour)
+	if _, err := NewSigner("link", "").Issue(expiry, "42"); !errors.Is(err, ErrNoSecret) {
+		t.Fatalf("without a secret: err = %v, want %v", err, ErrNoSecret)
+	}
+	for _, field := range []string{"", "a|b"} {
+		if _, err := NewSigner("link", "secret").Issue(expiry, field); err == nil {
+			t.Fatalf("Issue accepted field %q", field)
+		}
+	}
+}
+
+func mustIssue(t *testing.T, s *Signer, expiresAt time.Time, fields ...string) string {
+	t.Helper()
+	token, err := s.Issue(expiresAt, fields...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,99 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// envelope is a payload as persisted: its schema version next to the encoded
+// value. Payloads stored with another version are dropped when read back.
+type envelope struct {
+	Version int             `json:"version"`
+	Value   json.RawMessage `json:"value"`
+}
+
+// payloadCodec describes how one payload kind is persisted
+type payloadCodec struct {
+	// version is bumped whenever the payload's fields change incompatibly
+	version int
+	decode  func(raw json.RawMessage) (Payload, error)
+}
+
+// payloadCodecs lists every payload kind that can be persisted, by key
+var payloadCodecs = map[string]payloadCodec{
+	KeyProfileMap:     {version: 1, decode: decodePayload[ProfileChoices]},
+	KeySchoolYearMap:  {version: 1, decode: decodePayload[YearChoices]},
+	KeyTicketMap:      {version: 1, decode: decodePayload[TicketChoices]},
+	KeyPagination:     {version: 1, decode: decodePayload[Pagination]},
+	KeyThreadID:       {version: 1, decode: decodePayload[SupportThread]},
+	KeyHistory:        {version: 1, decode: decodePayload[History]},
+	KeyReferredSchool: {version: 1, decode: decodePayload[ReferredSchool]},
+	KeySchoolMap:      {version: 1, decode: decodePayload[SchoolChoices]},
+	KeyLinkRequest:    {version: 1, decode: decodePayload[LinkRequest]},
+	KeyUnlinkRequest:  {version: 1, decode: decodePayload[UnlinkRequest]},
+}
+
+// encodeData serializes a user's payloads
+func encodeData(data Data) ([]byte, error) {
+	envelopes := make(map[string]envelope, len(data))
+	for key, p := range data {
+		codec, ok := payloadCodecs[key]
+		if !ok {
+			return nil, fmt.Errorf("no codec registered for state payload %q", key)
+		}
+
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return nil, fmt.Errorf("state payload %q: %w", key, err)
+		}
+		envelopes[key] = envelope{Version: codec.version, Value: raw}
+	}
+	return json.Marshal(envelopes)
+}
+
+// decodeData restores payloads written by encodeData. Payloads that are unknown,
+// from another schema version or invalid are dropped, so the states needing them
+// see ErrStalePayload and can start over.
+func decodeData(raw []byte) (Data, error) {
+	data := make(Data)
+	if len(raw) == 0 {
+		return data, nil
+	}
+
+	var envelopes map[string]envelope
+	if err := json.Unmarshal(raw, &envelopes); err != nil {
+		return nil, fmt.Errorf("invalid state data: %w", err)
+	}
+
+	for key, env := range envelopes {
+		codec, ok := payloadCodecs[key]
+		if !ok {
+			log.Printf("Dropping unknown state payload %q", key)
+			continue
+		}
+		if env.Version != codec.version {
+			log.Printf("Dropping state payload %q stored with version %d, expected %d", key, env.Version, codec.version)
+			continue
+		}
+
+		p, err := codec.decode(env.Value)
+		if err != nil {
+			log.Printf("Dropping unreadable state payload %q: %v", key, err)
+			continue
+		}
+		data[key] = p
+	}
+	return data, nil
+}
+
+func decodePayload[T Payload](raw json.RawMessage) (Payload, error) {
+	var p T
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return nil, err
+	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
@@ -0,0 +1,83 @@
+package state
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	data := Data{
+		KeyProfileMap: ProfileChoices{"1": {StudentID: "2024-001", SchoolID: "demo_school"}},
+		KeyPagination: Pagination{Page: 2, Size: 5, Pages: 3, Total: 12, Offset: 5, Items: 5},
+		KeyThreadID:   SupportThread{ThreadID: "T-100"},
+		KeyTicketMap:  TicketChoices{"1": "T-100"},
+	}
+
+	raw, err := encodeData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var envelopes map[string]envelope
+	if err := json.Unmarshal(raw, &envelopes); err != nil {
+		t.Fatal(err)
+	}
+	for key, env := range envelopes {
+		if env.Version != payloadCodecs[key].version {
+			t.Fatalf("%s stored with version %d, want %d", key, env.Version, payloadCodecs[key].version)
+		}
+	}
+
+	got, err := decodeData(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, data) {
+		t.Fatalf("decoded %+v, want %+v", got, data)
+	}
+}
+
+func TestDecodeDropsStalePayloads(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+	}{
+		{"other version", `{"KeyThreadID":{"version":99,"value":{"thread_id":"T-100"}}}`},
+		{"invalid value", `{"KeyThreadID":{"version":1,"value":{"thread_id":""}}}`},
+		{"wrong shape", `{"KeyThreadID":{"version":1,"value":"T-100"}}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := decodeData([]byte(tt.raw))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := Get[SupportThread](data); !errors.Is(err, ErrStalePayload) {
+				t.Fatalf("err = %v, want %v", err, ErrStalePayload)
+			}
+		})
+	}
+}
+
+func TestDecodeKeepsOtherPayloads(t *testing.T) {
+	raw := `{"KeyThreadID":{"version":99,"value":{"thread_id":"T-100"}},"unknown":{"version":1,"value":{}},` +
+		`"KeyTicketMap":{"version":1,"value":{"1":"T-100"}}}`
+	data, err := decodeData([]byte(raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tickets, err := Get[TicketChoices](data)
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if tickets["1"] != "T-100" {
+		t.Fatalf("tickets = %v", tickets)
+	}
+}
+
+func TestDecodeRejectsInvalidJSON(t *testing.T) {
+	if _, err := decodeData([]byte("not json")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
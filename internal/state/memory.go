@@ -0,0 +1,71 @@
+package state
+
+import (
+	"sync"
+	"time"
+)
+
+// MemoryStateManager keeps states in process memory. States are lost on restart
+// and are not shared between replicas.
+type MemoryStateManager struct {
+	mu     sync.RWMutex
+	states map[string]*StateData
+}
+
+func NewMemoryStateManager() *MemoryStateManager {
+	return &MemoryStateManager{
+		states: make(map[string]*StateData),
+	}
+}
+
+// SetState sets the current state for a user
+func (sm *MemoryStateManager) SetState(userID string, state State, payloads ...Payload) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	if _, exists := sm.states[userID]; !exists {
+		sm.states[userID] = &StateData{
+			Data: make(Data),
+		}
+	}
+
+	sm.states[userID].CurrentState = state
+	sm.states[userID].LastActive = time.Now()
+
+	sm.states[userID].Data.merge(payloads)
+	return nil
+}
+
+// GetState returns the current state and data for a user
+func (sm *MemoryStateManager) GetState(userID string) (State, Data) {
+	sm.mu.RLock()
+	defer sm.mu.RUnlock()
+
+	if stateData, exists := sm.states[userID]; exists {
+		return stateData.CurrentState, stateData.Data.clone()
+	}
+
+	return StateInitial, nil
+}
+
+// ClearState clears the state for a user
+func (sm *MemoryStateManager) ClearState(userID string) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+	delete(sm.states, userID)
+	return nil
+}
+
+// CleanupInactive removes states that haven't been active for a duration
+func (sm *MemoryStateManager) CleanupInactive(timeout time.Duration) error {
+	sm.mu.Lock()
+	defer sm.mu.Unlock()
+
+	now := time.Now()
+	for userID, stateData := range sm.states {
+		if now.Sub(stateData.LastActive) > timeout {
+			delete(sm.states, userID)
+		}
+	}
+	return nil
+}
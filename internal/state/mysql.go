@@ -0,0 +1,107 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"school-assistant-wh/internal/models"
+)
+
+// MySQLStateManager keeps states in the school_messenger_conversation_states
+// table, so conversations survive restarts and can be served by any replica
+type MySQLStateManager struct {
+	db *gorm.DB
+}
+
+func NewMySQLStateManager(db *gorm.DB) *MySQLStateManager {
+	return &MySQLStateManager{db: db}
+}
+
+// SetState sets the current state for a user, merging the payloads into the
+// stored ones inside a transaction that locks the user's row
+func (sm *MySQLStateManager) SetState(userID string, state State, payloads ...Payload) error {
+	return sm.db.Transaction(func(tx *gorm.DB) error {
+		merged := make(Data)
+
+		var row models.ConversationState
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("PSID = ?", userID).
+			Take(&row).Error
+		switch {
+		case err == nil:
+			existing, err := decodeData([]byte(row.Data))
+			if err != nil {
+				log.Printf("Discarding unreadable state data for %s: %v", userID, err)
+			} else {
+				merged = existing
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return fmt.Errorf("failed to load state: %w", err)
+		}
+
+		merged.merge(payloads)
+
+		encoded, err := encodeData(merged)
+		if err != nil {
+			return fmt.Errorf("failed to encode state data: %w", err)
+		}
+
+		row = models.ConversationState{
+			PSID:       userID,
+			State:      string(state),
+			Data:       string(encoded),
+			LastActive: time.Now(),
+		}
+		if err := tx.Clauses(clause.OnConflict{UpdateAll: true}).Create(&row).Error; err != nil {
+			return fmt.Errorf("failed to save state: %w", err)
+		}
+		return nil
+	})
+}
+
+// GetState returns the current state and data for a user. A state that cannot be
+// loaded is treated as StateInitial so the user can start over.
+func (sm *MySQLStateManager) GetState(userID string) (State, Data) {
+	var row models.ConversationState
+	err := sm.db.Where("PSID = ?", userID).Take(&row).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return StateInitial, nil
+	}
+	if err != nil {
+		log.Printf("Error loading state for %s: %v", userID, err)
+		return StateInitial, nil
+	}
+
+	data, err := decodeData([]byte(row.Data))
+	if err != nil {
+		log.Printf("Error decoding state data for %s: %v", userID, err)
+		return StateInitial, nil
+	}
+
+	return State(row.State), data
+}
+
+// ClearState clears the state for a user
+func (sm *MySQLStateManager) ClearState(userID string) error {
+	if err := sm.db.Where("PSID = ?", userID).Delete(&models.ConversationState{}).Error; err != nil {
+		return fmt.Errorf("failed to clear state: %w", err)
+	}
+	return nil
+}
+
+// CleanupInactive removes states that haven't been active for a duration
+func (sm *MySQLStateManager) CleanupInactive(timeout time.Duration) error {
+	result := sm.db.Where("LastActive < ?", time.Now().Add(-timeout)).Delete(&models.ConversationState{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to clean up inactive states: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("Removed %d inactive conversation state(s)", result.RowsAffected)
+	}
+	return nil
+}
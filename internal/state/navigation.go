@@ -0,0 +1,97 @@
+package state
+
+// MaxHistory bounds how many screens a user's navigation history keeps
+const MaxHistory = 10
+
+// Screen parameters restored when the user navigates back to a screen
+const (
+	ParamPage     = "page"
+	ParamYear     = "year"
+	ParamTicketID = "ticket"
+	ParamID       = "id"
+	ParamMonth    = "month"
+)
+
+// MonthLayout formats the ParamMonth parameter
+const MonthLayout = "2006-01"
+
+// Screen is an entry of a user's navigation history: the state it was shown in
+// and the parameters needed to render it again
+type Screen struct {
+	State  State             `json:"state"`
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// historyOf returns the navigation history kept in the state data
+func historyOf(data Data) History {
+	history, err := Get[History](data)
+	if err != nil {
+		return nil
+	}
+	return history
+}
+
+// EnterScreen moves the user to the screen and records it in their navigation
+// history. Rendering the screen on top again does not record it twice, and the
+// main menu starts a new history.
+func EnterScreen(sm StateManager, userID string, screen Screen, payloads ...Payload) error {
+	_, current := sm.GetState(userID)
+
+	history := historyOf(current)
+	if screen.State == StateMainMenu {
+		history = nil
+	}
+	if n := len(history); n > 0 && history[n-1].equal(screen) {
+		history = history[:n-1]
+	}
+	history = append(append(History(nil), history...), screen)
+	if len(history) > MaxHistory {
+		history = history[len(history)-MaxHistory:]
+	}
+
+	return sm.SetState(userID, screen.State, append(payloads, history)...)
+}
+
+// Back drops the current screen from the user's history and returns the screen
+// before it. It reports false when there is nothing to go back to.
+func Back(sm StateManager, userID string) (Screen, bool, error) {
+	current, data := sm.GetState(userID)
+
+	history := historyOf(data)
+	if len(history) < 2 {
+		return Screen{}, false, nil
+	}
+
+	history = history[:len(history)-1]
+	if err := sm.SetState(userID, current, history); err != nil {
+		return Screen{}, false, err
+	}
+	return history[len(history)-1], true, nil
+}
+
+// CurrentScreen returns the screen on top of the navigation history in data
+func CurrentScreen(data Data) (Screen, bool) {
+	history := historyOf(data)
+	if len(history) == 0 {
+		return Screen{}, false
+	}
+	return history[len(history)-1], true
+}
+
+// ClearHistory forgets the user's navigation history
+func ClearHistory(sm StateManager, userID string) error {
+	current, _ := sm.GetState(userID)
+	return sm.SetState(userID, current, History{})
+}
+
+func (s Screen) equal(other Screen) bool {
+	if s.State != other.State || len(s.Params) != len(other.Params) {
+		return false
+	}
+	for k, v := range s.Params {
+		if other.Params[k] != v {
+			return false
+		}
+	}
+	return true
+}
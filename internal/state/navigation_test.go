@@ -0,0 +1,112 @@
+package state
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBackRestoresScreenParams(t *testing.T) {
+	sm := NewMemoryStateManager()
+	screens := []Screen{
+		{State: StateMainMenu},
+		{State: StateViewBulletin, Params: map[string]string{ParamPage: "3"}},
+		{State: StateViewBulletinDetails, Params: map[string]string{ParamID: "42"}},
+	}
+	for _, s := range screens {
+		if err := EnterScreen(sm, "psid-1", s); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, ok, err := Back(sm, "psid-1")
+	if err != nil || !ok {
+		t.Fatalf("Back() = %v, %v, want the bulletin list", ok, err)
+	}
+	if !reflect.DeepEqual(got, screens[1]) {
+		t.Fatalf("Back() = %+v, want %+v", got, screens[1])
+	}
+
+	_, data := sm.GetState("psid-1")
+	if current, _ := CurrentScreen(data); !reflect.DeepEqual(current, screens[1]) {
+		t.Fatalf("current screen after Back = %+v, want %+v", current, screens[1])
+	}
+
+	if got, ok, _ := Back(sm, "psid-1"); !ok || got.State != StateMainMenu {
+		t.Fatalf("second Back() = %+v, %v, want the main menu", got, ok)
+	}
+}
+
+func TestBackWithoutHistory(t *testing.T) {
+	sm := NewMemoryStateManager()
+	if _, ok, err := Back(sm, "psid-1"); ok || err != nil {
+		t.Fatalf("Back() on a new user = %v, %v, want nothing to go back to", ok, err)
+	}
+
+	if err := EnterScreen(sm, "psid-1", Screen{State: StateMainMenu}); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, err := Back(sm, "psid-1"); ok || err != nil {
+		t.Fatalf("Back() from the only screen = %v, %v, want nothing to go back to", ok, err)
+	}
+
+	if err := ClearHistory(sm, "psid-1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok, _ := Back(sm, "psid-1"); ok {
+		t.Fatal("Back() after ClearHistory found a screen")
+	}
+}
+
+func TestEnterScreenHistory(t *testing.T) {
+	page := func(n string) Screen {
+		return Screen{State: StateViewBulletin, Params: map[string]string{ParamPage: n}}
+	}
+
+	tests := []struct {
+		name    string
+		screens []Screen
+		want    History
+	}{
+		{
+			name:    "rendering the top screen again",
+			screens: []Screen{{State: StateMainMenu}, page("1"), page("1")},
+			want:    History{{State: StateMainMenu}, page("1")},
+		},
+		{
+			name:    "same state with other params",
+			screens: []Screen{{State: StateMainMenu}, page("1"), page("2")},
+			want:    History{{State: StateMainMenu}, page("1"), page("2")},
+		},
+		{
+			name:    "main menu starts over",
+			screens: []Screen{{State: StateMainMenu}, page("1"), {State: StateMainMenu}},
+			want:    History{{State: StateMainMenu}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := NewMemoryStateManager()
+			for _, s := range tt.screens {
+				if err := EnterScreen(sm, "psid-1", s); err != nil {
+					t.Fatal(err)
+				}
+			}
+			_, data := sm.GetState("psid-1")
+			if got := historyOf(data); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("history = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+
+	sm := NewMemoryStateManager()
+	for i := 0; i < MaxHistory+5; i++ {
+		if err := EnterScreen(sm, "psid-1", page(string(rune('a'+i)))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, data := sm.GetState("psid-1")
+	if got := historyOf(data); len(got) != MaxHistory || !reflect.DeepEqual(got[len(got)-1], page(string(rune('a'+MaxHistory+4)))) {
+		t.Fatalf("history kept %d screens ending with %+v, want the last %d", len(got), got[len(got)-1], MaxHistory)
+	}
+}
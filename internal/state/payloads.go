@@ -0,0 +1,227 @@
+package state
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrStalePayload means a payload a state needs is missing or no longer valid,
+// for example because it was stored by an older version of the bot
+var ErrStalePayload = errors.New("stale state payload")
+
+// Payload is a typed value kept in a user's state data. Each payload kind has
+// its own key, so a user holds at most one payload of each kind.
+type Payload interface {
+	// Key is where the payload is kept in the state data
+	Key() string
+	// Validate reports whether the payload is usable
+	Validate() error
+}
+
+// Data holds a user's payloads by key
+type Data map[string]Payload
+
+// Get returns the user's payload of type T, or ErrStalePayload when it is
+// missing or invalid
+func Get[T Payload](data Data) (T, error) {
+	var zero T
+	p, ok := data[zero.Key()].(T)
+	if !ok {
+		return zero, fmt.Errorf("%w: %s missing", ErrStalePayload, zero.Key())
+	}
+	if err := p.Validate(); err != nil {
+		return zero, fmt.Errorf("%w: %s: %v", ErrStalePayload, zero.Key(), err)
+	}
+	return p, nil
+}
+
+// merge stores the payloads into data, replacing payloads of the same kind
+func (d Data) merge(payloads []Payload) {
+	for _, p := range payloads {
+		if p != nil {
+			d[p.Key()] = p
+		}
+	}
+}
+
+func (d Data) clone() Data {
+	c := make(Data, len(d))
+	for k, v := range d {
+		c[k] = v
+	}
+	return c
+}
+
+// ProfileChoice identifies one of the profiles listed for selection
+type ProfileChoice struct {
+	StudentID string `json:"student_id"`
+	SchoolID  string `json:"school_id"`
+}
+
+// ProfileChoices maps the numbers shown in a profile list to the profiles
+type ProfileChoices map[string]ProfileChoice
+
+func (ProfileChoices) Key() string { return KeyProfileMap }
+
+func (p ProfileChoices) Validate() error {
+	if len(p) == 0 {
+		return errors.New("no profiles")
+	}
+	for n, choice := range p {
+		if choice.StudentID == "" || choice.SchoolID == "" {
+			return fmt.Errorf("profile %s has no student or school", n)
+		}
+	}
+	return nil
+}
+
+// YearChoices maps the numbers shown in a school year list to the school years
+type YearChoices map[string]string
+
+func (YearChoices) Key() string { return KeySchoolYearMap }
+
+func (y YearChoices) Validate() error {
+	for n, year := range y {
+		if year == "" {
+			return fmt.Errorf("school year %s is empty", n)
+		}
+	}
+	return nil
+}
+
+// TicketChoices maps the numbers shown in a ticket list to the thread IDs
+type TicketChoices map[string]string
+
+func (TicketChoices) Key() string { return KeyTicketMap }
+
+func (t TicketChoices) Validate() error {
+	for n, id := range t {
+		if id == "" {
+			return fmt.Errorf("ticket %s has no thread ID", n)
+		}
+	}
+	return nil
+}
+
+// Pagination describes the page of a list the user is looking at
+type Pagination struct {
+	Offset int `json:"offset"`
+	Items  int `json:"items"`
+	Total  int `json:"total"`
+	Page   int `json:"page"`
+	Size   int `json:"size"`
+	Pages  int `json:"pages"`
+}
+
+func (Pagination) Key() string { return KeyPagination }
+
+func (p Pagination) Validate() error {
+	if p.Page < 1 || p.Size < 1 {
+		return fmt.Errorf("invalid page %d of size %d", p.Page, p.Size)
+	}
+	return nil
+}
+
+// SupportThread is the support ticket the user's messages are added to
+type SupportThread struct {
+	ThreadID string `json:"thread_id"`
+}
+
+func (SupportThread) Key() string { return KeyThreadID }
+
+func (t SupportThread) Validate() error {
+	if t.ThreadID == "" {
+		return errors.New("no thread ID")
+	}
+	return nil
+}
+
+// History is the user's navigation history, oldest screen first
+type History []Screen
+
+func (History) Key() string { return KeyHistory }
+
+func (h History) Validate() error {
+	for i, screen := range h {
+		if screen.State == "" {
+			return fmt.Errorf("screen %d has no state", i)
+		}
+	}
+	return nil
+}
+
+// ReferredSchool is the school the user came from through a school's m.me link
+type ReferredSchool struct {
+	SchoolID   string `json:"school_id"`
+	SchoolName string `json:"school_name"`
+}
+
+func (ReferredSchool) Key() string { return KeyReferredSchool }
+
+func (s ReferredSchool) Validate() error {
+	if s.SchoolID == "" {
+		return errors.New("no school ID")
+	}
+	return nil
+}
+
+// SchoolChoice is one of the schools listed for selection
+type SchoolChoice struct {
+	SchoolID   string `json:"school_id"`
+	SchoolName string `json:"school_name"`
+}
+
+// SchoolChoices maps the numbers shown in a school list to the schools
+type SchoolChoices map[string]SchoolChoice
+
+func (SchoolChoices) Key() string { return KeySchoolMap }
+
+func (s SchoolChoices) Validate() error {
+	if len(s) == 0 {
+		return errors.New("no schools")
+	}
+	for n, choice := range s {
+		if choice.SchoolID == "" {
+			return fmt.Errorf("school %s has no ID", n)
+		}
+	}
+	return nil
+}
+
+// LinkRequest is the student a user is verifying in order to link them, and who
+// the user is to the student. The one-time code is kept only as a hash.
+type LinkRequest struct {
+	SchoolID    string    `json:"school_id"`
+	SchoolName  string    `json:"school_name"`
+	Role        string    `json:"role,omitempty"`
+	StudentID   string    `json:"student_id,omitempty"`
+	Destination string    `json:"destination,omitempty"`
+	CodeHash    string    `json:"code_hash,omitempty"`
+	CodeExpires time.Time `json:"code_expires,omitempty"`
+}
+
+func (LinkRequest) Key() string { return KeyLinkRequest }
+
+func (r LinkRequest) Validate() error {
+	if r.SchoolID == "" {
+		return errors.New("no school ID")
+	}
+	return nil
+}
+
+// UnlinkRequest is the linked student a user asked to remove from their account
+type UnlinkRequest struct {
+	SchoolID    string `json:"school_id"`
+	StudentID   string `json:"student_id"`
+	StudentName string `json:"student_name"`
+}
+
+func (UnlinkRequest) Key() string { return KeyUnlinkRequest }
+
+func (r UnlinkRequest) Validate() error {
+	if r.SchoolID == "" || r.StudentID == "" {
+		return errors.New("no student or school")
+	}
+	return nil
+}
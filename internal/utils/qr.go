@@ -0,0 +1,11 @@
+package utils
+
+import qrcode "github.com/skip2/go-qrcode"
+
+// qrSize is the width and height in pixels of generated QR codes
+const qrSize = 256
+
+// QRCodePNG encodes content as a QR code PNG image
+func QRCodePNG(content string) ([]byte, error) {
+	return qrcode.Encode(content, qrcode.Medium, qrSize)
+}
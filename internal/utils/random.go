@@ -30,7 +30,14 @@ func GenerateUniqueCode(db *gorm.DB) (string, error) {
 		if err := db.Model(&models.User{}).Where("Code = ?", code).Count(&count).Error; err != nil {
 			return "", err
 		}
+		if count > 0 {
+			continue
+		}
 
+		// A revoked code may have leaked, so it must not identify anyone else
+		if err := db.Model(&models.CodeRevocation{}).Where("Code = ?", code).Count(&count).Error; err != nil {
+			return "", err
+		}
 		if count == 0 {
 			return code, nil
 		}
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `school_messenger_conversation_states` (
+  `PSID` varchar(100) NOT NULL,
+  `State` varchar(50) NOT NULL,
+  `Data` mediumtext DEFAULT NULL,
+  `LastActive` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`PSID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
+
+CREATE INDEX idx_conversation_states_last_active ON school_messenger_conversation_states (LastActive);
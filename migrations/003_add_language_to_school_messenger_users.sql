@@ -0,0 +1,2 @@
+ALTER TABLE `school_messenger_users`
+  ADD COLUMN `Language` varchar(5) NOT NULL DEFAULT 'en' AFTER `Email`;
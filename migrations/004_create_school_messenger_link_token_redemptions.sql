@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS `school_messenger_link_token_redemptions` (
+  `Signature` varchar(64) NOT NULL,
+  `UserID` int(11) NOT NULL,
+  `SchoolID` varchar(50) NOT NULL,
+  `StudentID` varchar(50) NOT NULL,
+  `RedeemedAt` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  PRIMARY KEY (`Signature`),
+  KEY `idx_user` (`UserID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,14 @@
+CREATE TABLE IF NOT EXISTS `school_messenger_link_audit` (
+  `ID` int(11) NOT NULL AUTO_INCREMENT,
+  `CreatedAt` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `PSID` varchar(100) NOT NULL,
+  `UserID` int(11) NOT NULL,
+  `SchoolID` varchar(50) DEFAULT NULL,
+  `StudentID` varchar(50) DEFAULT NULL,
+  `Method` varchar(20) NOT NULL,
+  `Event` varchar(30) NOT NULL,
+  `Detail` varchar(255) DEFAULT NULL,
+  PRIMARY KEY (`ID`),
+  KEY `idx_link_audit_user_event` (`UserID`, `Event`, `CreatedAt`),
+  KEY `idx_link_audit_student` (`SchoolID`, `StudentID`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;
@@ -0,0 +1,2 @@
+ALTER TABLE `gk_miniapps`.`school_link_user`
+  ADD COLUMN `Role` varchar(20) NOT NULL DEFAULT 'guardian' AFTER `SchoolID`;
@@ -0,0 +1,13 @@
+ALTER TABLE `school_messenger_users`
+  ADD COLUMN `CodeExpiresAt` timestamp NULL DEFAULT NULL AFTER `Code`;
+
+CREATE TABLE IF NOT EXISTS `school_messenger_code_revocations` (
+  `ID` int(11) NOT NULL AUTO_INCREMENT,
+  `RevokedAt` timestamp NOT NULL DEFAULT CURRENT_TIMESTAMP,
+  `UserID` int(11) NOT NULL,
+  `Code` varchar(10) NOT NULL,
+  `Reason` varchar(20) NOT NULL,
+  PRIMARY KEY (`ID`),
+  UNIQUE KEY `idx_code_revocations_code` (`Code`),
+  KEY `idx_code_revocations_user` (`UserID`, `RevokedAt`)
+) ENGINE=InnoDB DEFAULT CHARSET=utf8mb4 COLLATE=utf8mb4_unicode_ci;